@@ -0,0 +1,677 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/lint"
+	"github.com/walonCode/code-lang/internal/symbol"
+)
+
+// CodeActions returns the quick fixes for the problems rng touches and the
+// refactorings that apply to the code it selects.
+func (d *Document) CodeActions(rng lsp.Range) []lsp.CodeAction {
+	actions := []lsp.CodeAction{}
+	if d == nil || d.Program == nil || d.Index == nil {
+		return actions
+	}
+
+	a := &actionBuilder{doc: d, spans: newSpans(d.Text), rng: rng}
+	a.start, a.end = OffsetAt(d.Text, rng.Start), OffsetAt(d.Text, rng.End)
+	a.parents = map[ast.Node]ast.Node{}
+	var stack []ast.Node
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		for len(stack) > 0 {
+			if start, end, ok := a.spans.offsets(stack[len(stack)-1]); ok && node.Pos().Offset >= start && node.Pos().Offset < end {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			a.parents[node] = stack[len(stack)-1]
+		}
+		stack = append(stack, node)
+		return true
+	})
+
+	a.undefinedNames()
+	actions = append(actions, a.actions...)
+	actions = append(actions, d.lintActions(rng)...)
+
+	a.actions = nil
+	a.extractVariable()
+	a.extractFunction()
+	a.inlineVariable()
+	a.letToConst()
+	a.forToWhile()
+	return append(actions, a.actions...)
+}
+
+// actionBuilder collects the code actions for one request. Offsets are
+// byte offsets into the document text.
+type actionBuilder struct {
+	doc        *Document
+	spans      *spans
+	rng        lsp.Range
+	start, end int
+	parents    map[ast.Node]ast.Node
+	actions    []lsp.CodeAction
+}
+
+func (a *actionBuilder) add(title, kind string, edits ...lsp.TextEdit) {
+	a.actions = append(a.actions, lsp.CodeAction{
+		Title: title,
+		Kind:  kind,
+		Edit:  &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{a.doc.URI: edits}},
+	})
+}
+
+func (a *actionBuilder) edit(start, end int, text string) lsp.TextEdit {
+	return lsp.TextEdit{Range: lsp.Range{Start: a.spans.position(start), End: a.spans.position(end)}, NewText: text}
+}
+
+// insertAndReplace inserts text at one offset and replaces a later span,
+// as a single edit when they meet since clients reject touching edits
+// whose order is ambiguous.
+func (a *actionBuilder) insertAndReplace(at int, insert string, start, end int, replace string) []lsp.TextEdit {
+	if at == start {
+		return []lsp.TextEdit{a.edit(start, end, insert+replace)}
+	}
+	return []lsp.TextEdit{a.edit(at, at, insert), a.edit(start, end, replace)}
+}
+
+// undefinedNames offers, for each undefined identifier in the range, to
+// import the std module it names or the std module that has a member of
+// that name, and to declare it.
+func (a *actionBuilder) undefinedNames() {
+	for _, err := range a.doc.SymbolErrors {
+		const prefix = "undefined identifier: "
+		if !strings.HasPrefix(err.Message, prefix) {
+			continue
+		}
+		ident := a.identifierAt(err.Start.Line, err.Start.Column)
+		if ident == nil || !overlaps(nodeRange(ident), a.rng) {
+			continue
+		}
+		name := ident.Value
+
+		at := a.importOffset()
+		if evaluator.IsBuiltinModule(name) && name != globalModule {
+			action := a.fix(fmt.Sprintf("Import %q", name), a.edit(at, at, fmt.Sprintf("import %q;\n", name)))
+			action.IsPreferred = true
+			a.actions = append(a.actions, action)
+		}
+		for _, module := range evaluator.BuiltinModuleNames() {
+			if module == globalModule {
+				continue
+			}
+			if _, ok := lookupModuleMember(module, name); ok {
+				a.actions = append(a.actions, a.fix(fmt.Sprintf("Import %s from %q", name, module),
+					a.edit(at, at, fmt.Sprintf("from %q import %s;\n", module, name))))
+			}
+		}
+
+		if stmt := a.enclosingStatement(ident); stmt != nil {
+			start := stmt.Pos().Offset
+			a.actions = append(a.actions, a.fix(fmt.Sprintf("Create variable '%s'", name),
+				a.edit(start, start, "let "+name+" = null;\n"+a.indentAt(start))))
+		}
+	}
+}
+
+func (a *actionBuilder) fix(title string, edits ...lsp.TextEdit) lsp.CodeAction {
+	return lsp.CodeAction{
+		Title: title,
+		Kind:  "quickfix",
+		Edit:  &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{a.doc.URI: edits}},
+	}
+}
+
+// importOffset is where a new import goes: after the last top-level
+// import, or at the start of the file.
+func (a *actionBuilder) importOffset() int {
+	at := 0
+	for _, stmt := range a.doc.Program.Statements {
+		if imp, ok := stmt.(*ast.ImportStatement); ok && imp != nil {
+			at = imp.End().Offset
+			if i := strings.IndexByte(a.doc.Text[at:], '\n'); i != -1 {
+				at += i + 1
+			} else {
+				at = len(a.doc.Text)
+			}
+		}
+	}
+	return at
+}
+
+// extractVariable moves the selected expression into a let declared just
+// before the statement it is in.
+func (a *actionBuilder) extractVariable() {
+	start, end := a.trimmedSelection()
+	if start == end {
+		return
+	}
+
+	var expr ast.Expression
+	ast.Inspect(a.doc.Program, func(node ast.Node) bool {
+		if e, ok := node.(ast.Expression); ok && node.Pos().Offset == start && node.End().Offset == end {
+			expr = e
+		}
+		return expr == nil
+	})
+	switch expr.(type) {
+	case nil, *ast.Identifier, *ast.ForExpression, *ast.WhileExpression:
+		return
+	}
+
+	stmt := a.enclosingStatement(expr)
+	if stmt == nil {
+		return
+	}
+	// Hoisting must not change when or whether the expression runs.
+	for child, node := ast.Node(expr), a.parents[expr]; node != nil && child != stmt; child, node = node, a.parents[node] {
+		switch n := node.(type) {
+		case *ast.ForExpression, *ast.WhileExpression, *ast.FunctionLiteral:
+			return
+		case *ast.IfExpression:
+			if child != n.Condition {
+				return
+			}
+		case *ast.InfixExpression:
+			if child == n.Left && symbol.IsAssignmentOp(n.Operator) {
+				return
+			}
+			if child == n.Right && (n.Operator == "&&" || n.Operator == "||") {
+				return
+			}
+		}
+	}
+
+	name := a.uniqueName("extracted")
+	at := stmt.Pos().Offset
+	insert := fmt.Sprintf("let %s = %s;\n%s", name, a.doc.Text[start:end], a.indentAt(at))
+	a.add("Extract to variable", "refactor.extract", a.insertAndReplace(at, insert, start, end, name)...)
+}
+
+// extractFunction moves the selected statements into a function declared
+// before the top-level statement they are in, and calls it in their place.
+// Variables the statements use from enclosing functions become parameters.
+func (a *actionBuilder) extractFunction() {
+	start, end := a.trimmedSelection()
+	if start == end {
+		return
+	}
+
+	stmts := a.selectedStatements(start, end)
+	if len(stmts) == 0 {
+		return
+	}
+	first, last := stmts[0].Pos().Offset, stmts[len(stmts)-1].End().Offset
+	if !onlySpaceOrSemicolons(a.doc.Text[last:end]) || first != start {
+		return
+	}
+	selection := lsp.Range{Start: a.spans.position(first), End: a.spans.position(last)}
+
+	for _, stmt := range stmts {
+		if escapesControlFlow(stmt) {
+			return
+		}
+	}
+
+	// Declarations must not be used after the statements.
+	for _, def := range a.doc.Index.Definitions {
+		if def.URI != a.doc.URI || !rangeContains(selection, def.Range) {
+			continue
+		}
+		for _, ref := range a.doc.Index.RefsByDef[def] {
+			if ref.URI == a.doc.URI && !rangeContains(selection, ref.Range) {
+				return
+			}
+		}
+	}
+
+	top := a.topLevelStatement(stmts[0])
+	at := top.Pos().Offset
+
+	writes := a.doc.assignmentTargets()
+	var params []string
+	seen := map[string]bool{}
+	for _, ref := range a.doc.Index.References {
+		def := ref.Def
+		if ref.URI != a.doc.URI || def == nil || def.URI != a.doc.URI || !rangeContains(selection, ref.Range) || rangeContains(selection, def.Range) {
+			continue
+		}
+		if a.doc.scopeAt(def.Range.Start) == a.doc.Index.Scopes[0] && OffsetAt(a.doc.Text, def.Range.Start) < at {
+			continue
+		}
+		if writes[occurrenceKey{ref.Range.Start.Line, ref.Range.Start.Character}] {
+			// The assignment would only change the parameter.
+			return
+		}
+		if !seen[def.Name] {
+			seen[def.Name] = true
+			params = append(params, def.Name)
+		}
+	}
+
+	name := a.uniqueName("extracted")
+	args := strings.Join(params, ", ")
+	lineStart := strings.LastIndexByte(a.doc.Text[:first], '\n') + 1
+	body := reindent(a.doc.Text[lineStart:last], "\t")
+	fn := fmt.Sprintf("let %s = fn(%s) {\n%s\n};\n\n%s", name, args, body, a.indentAt(at))
+
+	call := fmt.Sprintf("%s(%s)", name, args)
+	if strings.HasSuffix(a.doc.Text[first:last], ";") {
+		call += ";"
+	}
+	a.add("Extract to function", "refactor.extract", a.insertAndReplace(at, fn, first, last, call)...)
+}
+
+// inlineVariable replaces every use of the variable under the cursor with
+// its value and removes the declaration.
+func (a *actionBuilder) inlineVariable() {
+	def, stmt, value := a.bindingAtCursor()
+	if def == nil || ast.IsNil(value) {
+		return
+	}
+	if _, ok := value.(*ast.FunctionLiteral); ok {
+		return
+	}
+
+	var refs []*Reference
+	for _, ref := range a.doc.Index.RefsByDef[def] {
+		if ref.URI == a.doc.URI {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 || a.reassigned(def) {
+		return
+	}
+
+	calls := false
+	properties := map[ast.Node]bool{}
+	var free []*ast.Identifier
+	ast.Inspect(value, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.CallExpression:
+			calls = true
+		case *ast.MemberExpression:
+			properties[n.Property] = true
+		case *ast.Identifier:
+			if !properties[n] {
+				free = append(free, n)
+			}
+		}
+		return true
+	})
+	if calls && len(refs) > 1 {
+		// Evaluating the calls more than once could change the result.
+		return
+	}
+	// The names in the value must mean the same thing where it goes.
+	for _, ident := range free {
+		occ := a.doc.FindOccurrenceAt(positionOf(ident.Pos()))
+		if occ == nil || occ.Def == nil {
+			// A builtin, which cannot be shadowed without a warning.
+			continue
+		}
+		for _, ref := range refs {
+			if a.doc.resolveAt(ident.Value, ref.Range.Start) != occ.Def {
+				return
+			}
+		}
+	}
+
+	text := a.doc.Text[value.Pos().Offset:value.End().Offset]
+	var edits []lsp.TextEdit
+	start, end := lint.LineDeletion(a.doc.Text, stmt.Pos(), stmt.End())
+	edits = append(edits, a.edit(start.Offset, end.Offset, ""))
+	for _, ref := range refs {
+		replacement := text
+		if a.needsParens(value, ref) {
+			replacement = "(" + text + ")"
+		}
+		edits = append(edits, lsp.TextEdit{Range: ref.Range, NewText: replacement})
+	}
+	a.add(fmt.Sprintf("Inline variable '%s'", def.Name), "refactor.inline", edits...)
+}
+
+// letToConst declares the binding under the cursor with const when it is
+// never reassigned.
+func (a *actionBuilder) letToConst() {
+	def, stmt, value := a.bindingAtCursor()
+	let, ok := stmt.(*ast.LetStatement)
+	if def == nil || !ok || ast.IsNil(value) || a.reassigned(def) {
+		return
+	}
+
+	// const rejects a name already declared in the same scope.
+	scope := a.doc.scopeAt(def.Range.Start)
+	for _, other := range a.doc.Index.DefsByName[def.Name] {
+		if other != def && other.URI == def.URI && a.doc.scopeAt(other.Range.Start) == scope {
+			return
+		}
+	}
+
+	a.add("Convert let to const", "refactor.rewrite", a.edit(let.Token.Offset, let.Token.End.Offset, "const"))
+}
+
+// forToWhile rewrites the C-style for loop whose header the cursor is in
+// as its init statement followed by a while loop.
+func (a *actionBuilder) forToWhile() {
+	var loop *ast.ForExpression
+	ast.Inspect(a.doc.Program, func(node ast.Node) bool {
+		if f, ok := node.(*ast.ForExpression); ok && a.start >= f.Pos().Offset && a.start < f.Body.Pos().Offset {
+			loop = f
+		}
+		return true
+	})
+	if loop == nil || ast.IsNil(loop.Body) {
+		return
+	}
+	if _, ok := a.parents[loop].(*ast.ExpressionStatement); !ok || a.enclosingStatement(loop) == nil {
+		return
+	}
+	// A continue would skip the post statement once it is in the body.
+	if loop.Post != nil && continues(loop.Body) {
+		return
+	}
+
+	text := a.doc.Text
+	indent := a.indentAt(loop.Pos().Offset)
+	var out strings.Builder
+
+	if !ast.IsNil(loop.Init) {
+		if let, ok := loop.Init.(*ast.LetStatement); ok && let.Name != nil {
+			// The binding moves out of the loop's scope to the one around it.
+			outer := a.doc.scopeAt(nodeRange(let.Name).Start).Parent
+			if outer == nil || outer.Defs[let.Name.Value] != nil {
+				return
+			}
+		}
+		out.WriteString(strings.TrimSuffix(statementText(text, loop.Init), ";"))
+		out.WriteString(";\n" + indent)
+	}
+
+	condition := "true"
+	if !ast.IsNil(loop.Condition) {
+		condition = text[loop.Condition.Pos().Offset:loop.Condition.End().Offset]
+	}
+	fmt.Fprintf(&out, "while (%s) ", condition)
+
+	body := text[loop.Body.Pos().Offset:loop.Body.End().Offset]
+	if !ast.IsNil(loop.Post) {
+		inner := indent + "\t"
+		if len(loop.Body.Statements) > 0 && !ast.IsNil(loop.Body.Statements[0]) {
+			inner = a.indentAt(loop.Body.Statements[0].Pos().Offset)
+		}
+		body = strings.TrimRight(strings.TrimSuffix(body, "}"), " \t\r\n")
+		post := strings.TrimSuffix(statementText(text, loop.Post), ";")
+		body += "\n" + inner + post + ";\n" + indent + "}"
+	}
+	out.WriteString(body)
+
+	a.add("Convert to while loop", "refactor.rewrite", a.edit(loop.Pos().Offset, loop.End().Offset, out.String()))
+}
+
+// trimmedSelection returns the selection without surrounding whitespace.
+func (a *actionBuilder) trimmedSelection() (int, int) {
+	start, end := a.start, a.end
+	for start < end && isSpace(a.doc.Text[start]) {
+		start++
+	}
+	for end > start && isSpace(a.doc.Text[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+// selectedStatements returns the statements of the innermost block that
+// lie within start and end, or nil when the selection cuts through one.
+func (a *actionBuilder) selectedStatements(start, end int) []ast.Statement {
+	var block []ast.Statement
+	ast.Inspect(a.doc.Program, func(node ast.Node) bool {
+		s, e, ok := a.spans.offsets(node)
+		if !ok || s > start || e < end {
+			return true
+		}
+		switch n := node.(type) {
+		case *ast.Program:
+			block = n.Statements
+		case *ast.BlockStatement:
+			block = n.Statements
+		}
+		return true
+	})
+
+	var out []ast.Statement
+	for _, stmt := range block {
+		if ast.IsNil(stmt) {
+			continue
+		}
+		s, e := stmt.Pos().Offset, stmt.End().Offset
+		switch {
+		case s >= start && e <= end:
+			out = append(out, stmt)
+		case s < end && e > start:
+			return nil
+		}
+	}
+	return out
+}
+
+// enclosingStatement returns the statement of a block or of the program
+// that node is part of.
+func (a *actionBuilder) enclosingStatement(node ast.Node) ast.Statement {
+	for child, parent := node, a.parents[node]; parent != nil; child, parent = parent, a.parents[parent] {
+		switch parent.(type) {
+		case *ast.Program, *ast.BlockStatement:
+			stmt, _ := child.(ast.Statement)
+			return stmt
+		}
+	}
+	return nil
+}
+
+func (a *actionBuilder) topLevelStatement(node ast.Node) ast.Node {
+	for a.parents[node] != nil && a.parents[node] != ast.Node(a.doc.Program) {
+		node = a.parents[node]
+	}
+	return node
+}
+
+// bindingAtCursor returns the let or const binding the cursor is on, at
+// its declaration or one of its uses, with its statement and value. Loop
+// initializers are left out since they belong to the loop.
+func (a *actionBuilder) bindingAtCursor() (*Definition, ast.Statement, ast.Expression) {
+	occ := a.doc.FindOccurrenceAt(a.rng.Start)
+	if occ == nil || occ.Def == nil || occ.Def.URI != a.doc.URI {
+		return nil, nil, nil
+	}
+	def := occ.Def
+
+	var stmt ast.Statement
+	var value ast.Expression
+	ast.Inspect(a.doc.Program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			if n.Name != nil && nodeRange(n.Name) == def.Range {
+				stmt, value = n, n.Value
+			}
+		case *ast.ConstStatement:
+			if n.Name != nil && nodeRange(n.Name) == def.Range {
+				stmt, value = n, n.Value
+			}
+		}
+		return stmt == nil
+	})
+	if stmt == nil || a.enclosingStatement(stmt) != stmt {
+		return nil, nil, nil
+	}
+	return def, stmt, value
+}
+
+func (a *actionBuilder) reassigned(def *Definition) bool {
+	writes := a.doc.assignmentTargets()
+	for _, ref := range a.doc.Index.RefsByDef[def] {
+		if ref.URI == a.doc.URI && writes[occurrenceKey{ref.Range.Start.Line, ref.Range.Start.Character}] {
+			return true
+		}
+	}
+	return false
+}
+
+// needsParens reports whether value must be parenthesized to keep its
+// meaning in place of ref.
+func (a *actionBuilder) needsParens(value ast.Expression, ref *Reference) bool {
+	switch value.(type) {
+	case *ast.InfixExpression, *ast.PrefixExpression, *ast.IfExpression:
+	default:
+		return false
+	}
+	ident := a.identifierAt(ref.Range.Start.Line+1, ref.Range.Start.Character+1)
+	switch a.parents[ident].(type) {
+	case *ast.InfixExpression, *ast.PrefixExpression, *ast.MemberExpression, *ast.IndexExpression:
+		return true
+	case *ast.CallExpression:
+		return a.parents[ident].(*ast.CallExpression).Function == ident
+	}
+	return false
+}
+
+// identifierAt returns the identifier at a lexer line and column.
+func (a *actionBuilder) identifierAt(line, col int) *ast.Identifier {
+	var found *ast.Identifier
+	ast.Inspect(a.doc.Program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Pos().Line == line && ident.Pos().Column == col {
+			found = ident
+		}
+		return found == nil
+	})
+	return found
+}
+
+// uniqueName returns base, numbered if the document already uses it.
+func (a *actionBuilder) uniqueName(base string) string {
+	name := base
+	for i := 2; len(a.doc.Index.DefsByName[name]) > 0; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	return name
+}
+
+// indentAt returns the whitespace that begins the line offset is on, up
+// to offset.
+func (a *actionBuilder) indentAt(offset int) string {
+	lineStart := strings.LastIndexByte(a.doc.Text[:offset], '\n') + 1
+	prefix := a.doc.Text[lineStart:offset]
+	return prefix[:len(prefix)-len(strings.TrimLeft(prefix, " \t"))]
+}
+
+// scopeAt returns the innermost scope around pos.
+func (d *Document) scopeAt(pos lsp.Position) *ScopeInfo {
+	global := d.Index.Scopes[0]
+	best := global
+	for _, sc := range d.Index.Scopes[1:] {
+		if sc != nil && contains(sc.Range, pos) && (best == global || rangeContains(best.Range, sc.Range)) {
+			best = sc
+		}
+	}
+	return best
+}
+
+// resolveAt returns the definition name refers to at pos.
+func (d *Document) resolveAt(name string, pos lsp.Position) *Definition {
+	for sc := d.scopeAt(pos); sc != nil; sc = sc.Parent {
+		if def, ok := sc.Defs[name]; ok {
+			return def
+		}
+	}
+	return nil
+}
+
+// escapesControlFlow reports whether stmt returns, or breaks or continues
+// a loop it is not inside of.
+func escapesControlFlow(stmt ast.Statement) bool {
+	escapes := false
+	var visit func(node ast.Node, inLoop bool)
+	visit = func(node ast.Node, inLoop bool) {
+		switch node.(type) {
+		case *ast.FunctionLiteral:
+			return
+		case *ast.ReturnStatement:
+			escapes = true
+			return
+		case *ast.BreakStatement, *ast.ContinueStatement:
+			if !inLoop {
+				escapes = true
+			}
+			return
+		case *ast.ForExpression, *ast.WhileExpression:
+			inLoop = true
+		}
+		for _, child := range ast.Children(node) {
+			visit(child, inLoop)
+		}
+	}
+	visit(stmt, false)
+	return escapes
+}
+
+// continues reports whether body has a continue for its own loop.
+func continues(body ast.Node) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.FunctionLiteral, *ast.ForExpression, *ast.WhileExpression:
+			return false
+		case *ast.ContinueStatement:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// statementText returns the source of stmt.
+func statementText(text string, stmt ast.Statement) string {
+	return strings.TrimSpace(text[stmt.Pos().Offset:stmt.End().Offset])
+}
+
+// reindent strips the indentation the lines of text share and prefixes
+// each with indent instead.
+func reindent(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	common, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			common, first = lead, false
+		}
+		for !strings.HasPrefix(lead, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = indent + strings.TrimPrefix(line, common)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func onlySpaceOrSemicolons(s string) bool {
+	return strings.Trim(s, " \t\r\n;") == ""
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+// selection strips the « and » markers from input and returns the text
+// and the range between them.
+func selection(input string) (string, lsp.Range) {
+	start := strings.Index(input, "«")
+	input = strings.Replace(input, "«", "", 1)
+	end := strings.Index(input, "»")
+	input = strings.Replace(input, "»", "", 1)
+	s := newSpans(input)
+	return input, lsp.Range{Start: s.position(start), End: s.position(end)}
+}
+
+// applyEdits applies non-overlapping edits to text.
+func applyEdits(text string, edits []lsp.TextEdit) string {
+	sorted := append([]lsp.TextEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return positionBefore(sorted[j].Range.Start, sorted[i].Range.Start)
+	})
+	for _, edit := range sorted {
+		start, end := OffsetAt(text, edit.Range.Start), OffsetAt(text, edit.Range.End)
+		text = text[:start] + edit.NewText + text[end:]
+	}
+	return text
+}
+
+func findAction(actions []lsp.CodeAction, title string) *lsp.CodeAction {
+	for i := range actions {
+		if actions[i].Title == title {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+func TestCodeActions(t *testing.T) {
+	tests := []struct {
+		title    string
+		input    string
+		expected string
+	}{
+		{
+			"Extract to variable",
+			"let price = 3;\nlet total = «price * 2» + 1;\nprint(total);",
+			"let price = 3;\nlet extracted = price * 2;\nlet total = extracted + 1;\nprint(total);",
+		},
+		{
+			"Extract to function",
+			"let f = fn(a) {\n\tlet b = a * 2;\n\t«print(a + b);\n\tprint(b);»\n\treturn b;\n};\nf(1);",
+			"let extracted = fn(a, b) {\n\tprint(a + b);\n\tprint(b);\n};\n\nlet f = fn(a) {\n\tlet b = a * 2;\n\textracted(a, b);\n\treturn b;\n};\nf(1);",
+		},
+		{
+			"Inline variable 'n'",
+			"let «»n = 1 + 2;\nprint(n * 3);",
+			"print((1 + 2) * 3);",
+		},
+		{
+			"Convert let to const",
+			"let n = 1;\nprint(«»n);",
+			"const n = 1;\nprint(n);",
+		},
+		{
+			"Convert to while loop",
+			"for («»let i = 0; i < 3; i += 1) {\n\tprint(i);\n};",
+			"let i = 0;\nwhile (i < 3) {\n\tprint(i);\n\ti += 1;\n};",
+		},
+		{
+			`Import "math"`,
+			"print(«»math.sqrt(4));",
+			"import \"math\";\nprint(math.sqrt(4));",
+		},
+		{
+			`Import sqrt from "math"`,
+			"import \"strings\";\nprint(«»sqrt(4));",
+			"import \"strings\";\nfrom \"math\" import sqrt;\nprint(sqrt(4));",
+		},
+		{
+			"Create variable 'x'",
+			"let f = fn() {\n\tprint(«»x);\n};",
+			"let f = fn() {\n\tlet x = null;\n\tprint(x);\n};",
+		},
+	}
+
+	for _, tt := range tests {
+		text, rng := selection(tt.input)
+		doc := Analyze("file:///actions.cl", text)
+		if len(doc.ParserErrors) > 0 {
+			t.Fatalf("%s: parser errors: %v", tt.title, doc.ParserErrors)
+		}
+		action := findAction(doc.CodeActions(rng), tt.title)
+		if action == nil {
+			t.Errorf("%s: action not offered", tt.title)
+			continue
+		}
+		if got := applyEdits(text, action.Edit.Changes[doc.URI]); got != tt.expected {
+			t.Errorf("%s: wrong result.\nwant %q\ngot  %q", tt.title, tt.expected, got)
+		}
+	}
+}
+
+func TestCodeActionsNotOffered(t *testing.T) {
+	tests := []struct {
+		title string
+		input string
+	}{
+		// Reassigned bindings keep their let and cannot be inlined.
+		{"Convert let to const", "let «»n = 1;\nn = 2;\nprint(n);"},
+		{"Inline variable 'n'", "let «»n = 1;\nn = 2;\nprint(n);"},
+		// A call is not repeated by inlining.
+		{"Inline variable 'n'", "let «»n = input();\nprint(n);\nprint(n);"},
+		// Returns cannot move into another function.
+		{"Extract to function", "let f = fn(a) {\n\t«print(a);\n\treturn a;»\n};"},
+		// Declarations used after the selection must stay.
+		{"Extract to function", "«let a = 1;»\nprint(a);"},
+		// Hoisting would run the right side of && unconditionally.
+		{"Extract to variable", "let ok = false && «len(\"x\") > 0»;"},
+		// The post statement would be skipped by continue.
+		{"Convert to while loop", "for («»let i = 0; i < 3; i += 1) {\n\tif (i == 1) { continue; };\n\tprint(i);\n};"},
+	}
+
+	for _, tt := range tests {
+		text, rng := selection(tt.input)
+		doc := Analyze("file:///actions.cl", text)
+		if len(doc.ParserErrors) > 0 {
+			t.Fatalf("%s: parser errors: %v", tt.title, doc.ParserErrors)
+		}
+		if action := findAction(doc.CodeActions(rng), tt.title); action != nil {
+			t.Errorf("%s: unexpectedly offered for %q: %+v", tt.title, text, action)
+		}
+	}
+}
@@ -1,23 +1,29 @@
 package analysis
 
 import (
-	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
 	"strings"
-	"unicode/utf8"
 
 	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
 	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/evaluator"
 	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/lint"
 	"github.com/walonCode/code-lang/internal/parser"
 	"github.com/walonCode/code-lang/internal/symbol"
+	"github.com/walonCode/code-lang/internal/token"
 )
 
 type Document struct {
 	URI          string
 	Text         string
 	Program      *ast.Program
-	ParserErrors []string
-	SymbolErrors []string
+	ParserErrors []*parser.ParseError
+	SymbolErrors []symbol.Error
+	Warnings     []symbol.Warning
+	Lint         []lint.Diagnostic
 	Index        *Index
 }
 
@@ -29,7 +35,10 @@ type Index struct {
 	RefsByDef   map[*Definition][]*Reference
 	Scopes      []*ScopeInfo
 	MemberProps []*Occurrence
-	Imports     map[string]bool
+	Imports     map[string]string
+	Enums       map[string][]*Definition
+	// ModuleMembers are `module.member` accesses on imported modules.
+	ModuleMembers []*ModuleMember
 }
 
 type Definition struct {
@@ -37,6 +46,20 @@ type Definition struct {
 	Kind  symbol.SymbolKind
 	Range lsp.Range
 	URI   string
+	// Import is set for names bound by `from "path" import name`.
+	Import *ImportedName
+}
+
+type ImportedName struct {
+	Path string
+	Name string
+}
+
+type ModuleMember struct {
+	Module string
+	Path   string
+	Name   string
+	Range  lsp.Range
 }
 
 type Reference struct {
@@ -55,12 +78,19 @@ type Occurrence struct {
 }
 
 type ScopeInfo struct {
-	Range lsp.Range
-	Defs  map[string]*Definition
+	Range  lsp.Range
+	Defs   map[string]*Definition
 	Parent *ScopeInfo
 }
 
 func Analyze(uri, text string) *Document {
+	return analyzeWith(uri, text, nil, nil)
+}
+
+// analyzeWith analyzes text with the rules of override taking precedence
+// over the lint config of its project, resolving imports in modulePaths
+// after CODELANG_PATH.
+func analyzeWith(uri, text string, override *lint.Config, modulePaths []string) *Document {
 	if strings.TrimSpace(text) == "" {
 		return &Document{
 			URI:          uri,
@@ -69,14 +99,16 @@ func Analyze(uri, text string) *Document {
 			ParserErrors: nil,
 			SymbolErrors: nil,
 			Index: &Index{
-				Definitions: []*Definition{},
-				References:  []*Reference{},
-				Occurrences: []*Occurrence{},
-				DefsByName:  make(map[string][]*Definition),
-				RefsByDef:   make(map[*Definition][]*Reference),
-				Scopes:      []*ScopeInfo{},
-				MemberProps: []*Occurrence{},
-				Imports:     make(map[string]bool),
+				Definitions:   []*Definition{},
+				References:    []*Reference{},
+				Occurrences:   []*Occurrence{},
+				DefsByName:    make(map[string][]*Definition),
+				RefsByDef:     make(map[*Definition][]*Reference),
+				Scopes:        []*ScopeInfo{},
+				MemberProps:   []*Occurrence{},
+				Imports:       make(map[string]string),
+				Enums:         make(map[string][]*Definition),
+				ModuleMembers: []*ModuleMember{},
 			},
 		}
 	}
@@ -85,14 +117,29 @@ func Analyze(uri, text string) *Document {
 	program := p.ParsePrograme()
 
 	builder := symbol.NewBuilder()
+	builder.ModuleResolver = evaluator.ModuleResolver(URIToPath(uri), modulePaths...)
+	if global, ok := evaluator.BuiltinModule(globalModule); ok {
+		for name := range global.Members {
+			builder.Define(name, symbol.FUNCTION)
+		}
+	}
 	builder.Visit(program)
 
+	// A broken config leaves the default rules in place rather than
+	// hiding every other diagnostic.
+	config, _ := lint.LoadConfig(filepath.Dir(URIToPath(uri)))
+	if override != nil {
+		config = config.Merge(override)
+	}
+
 	doc := &Document{
 		URI:          uri,
 		Text:         text,
 		Program:      program,
-		ParserErrors: p.Errors(),
+		ParserErrors: p.ParseErrors(),
 		SymbolErrors: builder.Errors,
+		Warnings:     builder.Warnings,
+		Lint:         lint.Run(program, text, config),
 		Index:        BuildIndex(uri, program),
 	}
 
@@ -101,11 +148,39 @@ func Analyze(uri, text string) *Document {
 
 func (d *Document) Diagnostics() []lsp.Diagnostic {
 	var diags []lsp.Diagnostic
-	for _, msg := range d.ParserErrors {
-		diags = append(diags, diagnosticFromMessage(msg, "parser"))
+	for _, err := range d.ParserErrors {
+		msg := err.Message
+		if err.Hint != "" {
+			msg += "\nhint: " + err.Hint
+		}
+		diags = append(diags, lsp.Diagnostic{
+			Range:    lsp.Range{Start: positionOf(err.Start), End: positionOf(err.End)},
+			Severity: 1,
+			Message:  msg,
+		})
+	}
+	for _, err := range d.SymbolErrors {
+		diags = append(diags, lsp.Diagnostic{
+			Range:    lsp.Range{Start: positionOf(err.Start), End: positionOf(err.End)},
+			Severity: 1,
+			Message:  err.Message,
+		})
 	}
-	for _, msg := range d.SymbolErrors {
-		diags = append(diags, diagnosticFromMessage(msg, "symbol"))
+	for _, w := range d.Warnings {
+		diag := lsp.Diagnostic{
+			Range:    lsp.Range{Start: positionOf(w.Start), End: positionOf(w.End)},
+			Severity: 2,
+			Code:     w.Code,
+			Message:  w.Message,
+		}
+		switch w.Code {
+		case symbol.UnusedVariable, symbol.UnusedParameter, symbol.UnusedImport, symbol.Unreachable:
+			diag.Tags = []int{lsp.DiagnosticTagUnnecessary}
+		}
+		diags = append(diags, diag)
+	}
+	for _, l := range d.Lint {
+		diags = append(diags, lintDiagnostic(l))
 	}
 	return diags
 }
@@ -139,34 +214,25 @@ func (d *Document) ReferencesFor(pos lsp.Position) []*Reference {
 }
 
 func (d *Document) CompletionAt(pos lsp.Position) []*Definition {
-	if d == nil || d.Index == nil {
+	if d == nil || d.Index == nil || len(d.Index.Scopes) == 0 {
 		return nil
 	}
-	var best *ScopeInfo
-	for _, sc := range d.Index.Scopes {
-		if sc == nil {
-			continue
-		}
-		if contains(sc.Range, pos) {
-			if best == nil || rangeContains(best.Range, sc.Range) {
-				best = sc
-			}
-		}
-	}
+	// Innermost scopes first, each in name order, so that shadowed names
+	// resolve to the nearest definition.
 	var defs []*Definition
-	if best != nil {
-		seen := map[string]bool{}
-		for curr := best; curr != nil; curr = curr.Parent {
-			for name, def := range curr.Defs {
-				if !seen[name] {
-					seen[name] = true
-					defs = append(defs, def)
-				}
+	seen := map[string]bool{}
+	for curr := d.scopeAt(pos); curr != nil; curr = curr.Parent {
+		names := make([]string, 0, len(curr.Defs))
+		for name := range curr.Defs {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
 			}
 		}
-	}
-	if len(defs) == 0 {
-		defs = d.Index.Definitions
+		sort.Strings(names)
+		for _, name := range names {
+			defs = append(defs, curr.Defs[name])
+		}
 	}
 	return defs
 }
@@ -206,14 +272,16 @@ func (s *scope) resolve(name string) *Definition {
 
 func BuildIndex(uri string, program *ast.Program) *Index {
 	idx := &Index{
-		Definitions: []*Definition{},
-		References:  []*Reference{},
-		Occurrences: []*Occurrence{},
-		DefsByName:  make(map[string][]*Definition),
-		RefsByDef:   make(map[*Definition][]*Reference),
-		Scopes:      []*ScopeInfo{},
-		MemberProps: []*Occurrence{},
-		Imports:     make(map[string]bool),
+		Definitions:   []*Definition{},
+		References:    []*Reference{},
+		Occurrences:   []*Occurrence{},
+		DefsByName:    make(map[string][]*Definition),
+		RefsByDef:     make(map[*Definition][]*Reference),
+		Scopes:        []*ScopeInfo{},
+		MemberProps:   []*Occurrence{},
+		Imports:       make(map[string]string),
+		Enums:         make(map[string][]*Definition),
+		ModuleMembers: []*ModuleMember{},
 	}
 
 	scope := newScope(nil)
@@ -222,11 +290,11 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 	var visitStatement func(stmt ast.Statement)
 	var visitExpression func(expr ast.Expression)
 
-	define := func(name string, kind symbol.SymbolKind, line, col int) *Definition {
-		if name == "" {
+	define := func(ident *ast.Identifier, kind symbol.SymbolKind) *Definition {
+		if ident == nil || ident.Value == "" {
 			return nil
 		}
-		rng := rangeFromLineCol(line, col, runeLen(name))
+		name, rng := ident.Value, nodeRange(ident)
 		def := &Definition{
 			Name:  name,
 			Kind:  kind,
@@ -246,11 +314,11 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 		return def
 	}
 
-	addRef := func(name string, line, col int) {
-		if name == "" {
+	addRef := func(ident *ast.Identifier) {
+		if ident == nil || ident.Value == "" {
 			return
 		}
-		rng := rangeFromLineCol(line, col, runeLen(name))
+		name, rng := ident.Value, nodeRange(ident)
 		def := scope.resolve(name)
 		ref := &Reference{
 			Name:  name,
@@ -271,18 +339,15 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 		})
 	}
 
-	enterScope := func(start lsp.Range) {
+	enterScope := func(node ast.Node) {
 		scope = newScope(scope)
-		scope.info.Range = start
+		scope.info.Range = nodeRange(node)
+		if positionBefore(scope.info.Range.End, scope.info.Range.Start) {
+			scope.info.Range.End = scope.info.Range.Start
+		}
 		idx.Scopes = append(idx.Scopes, scope.info)
 	}
-	exitScope := func(end lsp.Position) {
-		if scope.info != nil {
-			scope.info.Range.End = end
-			if positionBefore(scope.info.Range.End, scope.info.Range.Start) {
-				scope.info.Range.End = scope.info.Range.Start
-			}
-		}
+	exitScope := func() {
 		if scope.parent != nil {
 			scope = scope.parent
 		}
@@ -298,7 +363,7 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 			if _, ok := s.Value.(*ast.FunctionLiteral); ok {
 				kind = symbol.FUNCTION
 			}
-			define(s.Name.Value, kind, s.Name.Line(), s.Name.Column())
+			define(s.Name, kind)
 			if s.Value != nil {
 				visitExpression(s.Value)
 			}
@@ -310,7 +375,7 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 			if _, ok := s.Value.(*ast.FunctionLiteral); ok {
 				kind = symbol.FUNCTION
 			}
-			define(s.Name.Value, kind, s.Name.Line(), s.Name.Column())
+			define(s.Name, kind)
 			if s.Value != nil {
 				visitExpression(s.Value)
 			}
@@ -326,22 +391,60 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 			if s == nil {
 				return
 			}
-			enterScope(rangeFromLineCol(s.Line(), s.Column(), 1))
+			enterScope(s)
 			for _, st := range s.Statements {
 				visitStatement(st)
 			}
-			exitScope(endPositionOfStatements(s.Statements))
+			exitScope()
 		case *ast.StructStatement:
 			if s == nil || s.Name == nil {
 				return
 			}
-			define(s.Name.Value, symbol.STRUCT, s.Name.Line(), s.Name.Column())
+			define(s.Name, symbol.STRUCT)
 			for _, v := range s.Fields {
 				visitExpression(v)
 			}
+		case *ast.EnumStatement:
+			if s == nil || s.Name == nil {
+				return
+			}
+			define(s.Name, symbol.ENUM)
+			for _, v := range s.Variants {
+				if v == nil || v.Name == nil {
+					continue
+				}
+				rng := nodeRange(v.Name)
+				def := &Definition{
+					Name:  v.Name.Value,
+					Kind:  symbol.ENUM_VARIANT,
+					Range: rng,
+					URI:   uri,
+				}
+				idx.Enums[s.Name.Value] = append(idx.Enums[s.Name.Value], def)
+				idx.Occurrences = append(idx.Occurrences, &Occurrence{
+					Name:         def.Name,
+					Range:        rng,
+					IsDefinition: true,
+					Def:          def,
+					Kind:         symbol.ENUM_VARIANT,
+				})
+			}
 		case *ast.ImportStatement:
-			if s != nil && s.Path != "" {
-				idx.Imports[s.Path] = true
+			if s == nil || s.Path == "" {
+				return
+			}
+			if len(s.Names) > 0 {
+				for _, name := range s.Names {
+					if def := define(name, symbol.VARIABLE); def != nil {
+						def.Import = &ImportedName{Path: s.Path, Name: name.Value}
+					}
+				}
+				return
+			}
+			idx.Imports[s.Name()] = s.Path
+		case *ast.ExportStatement:
+			if s != nil {
+				visitStatement(s.Statement)
 			}
 		case *ast.BreakStatement, *ast.ContinueStatement:
 			return
@@ -351,9 +454,7 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 	visitExpression = func(expr ast.Expression) {
 		switch e := expr.(type) {
 		case *ast.Identifier:
-			if e != nil {
-				addRef(e.Value, e.Line(), e.Column())
-			}
+			addRef(e)
 		case *ast.IntegerLiteral, *ast.Boolean, *ast.StringLiteral, *ast.FloatLiteral, *ast.CharLiteral:
 			return
 		case *ast.PrefixExpression:
@@ -390,14 +491,12 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 			if e == nil {
 				return
 			}
-			enterScope(rangeFromLineCol(e.Line(), e.Column(), 1))
+			enterScope(e)
 			for _, p := range e.Parameters {
-				if p != nil {
-					define(p.Value, symbol.PARAMETER, p.Line(), p.Column())
-				}
+				define(p, symbol.PARAMETER)
 			}
 			visitStatement(&e.Body)
-			exitScope(endPositionOfStatement(&e.Body))
+			exitScope()
 		case *ast.CallExpression:
 			if e != nil {
 				visitExpression(e.Function)
@@ -425,17 +524,39 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 			}
 		case *ast.StructLiteral:
 			if e != nil {
-				if e.Name != nil {
-					addRef(e.Name.Value, e.Name.Line(), e.Name.Column())
-				}
+				addRef(e.Name)
 				for _, v := range e.Fields {
 					visitExpression(v)
 				}
 			}
 		case *ast.MemberExpression:
 			if e != nil {
-				if e.Property != nil {
-					rng := rangeFromLineCol(e.Property.Line(), e.Property.Column(), runeLen(e.Property.Value))
+				if variant := enumVariantFor(idx, scope, e); variant != nil {
+					rng := nodeRange(e.Property)
+					ref := &Reference{
+						Name:  e.Property.Value,
+						Range: rng,
+						Def:   variant,
+						URI:   uri,
+					}
+					idx.References = append(idx.References, ref)
+					idx.RefsByDef[variant] = append(idx.RefsByDef[variant], ref)
+					idx.Occurrences = append(idx.Occurrences, &Occurrence{
+						Name:  e.Property.Value,
+						Range: rng,
+						Def:   variant,
+						Kind:  symbol.ENUM_VARIANT,
+					})
+				} else if e.Property != nil {
+					rng := nodeRange(e.Property)
+					if module, path, ok := importedModuleFor(idx, scope, e); ok {
+						idx.ModuleMembers = append(idx.ModuleMembers, &ModuleMember{
+							Module: module,
+							Path:   path,
+							Name:   e.Property.Value,
+							Range:  rng,
+						})
+					}
 					idx.MemberProps = append(idx.MemberProps, &Occurrence{
 						Name:         e.Property.Value,
 						Range:        rng,
@@ -450,7 +571,7 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 			if e == nil {
 				return
 			}
-			enterScope(rangeFromLineCol(e.Line(), e.Column(), 1))
+			enterScope(e)
 			if e.Init != nil {
 				visitStatement(e.Init)
 			}
@@ -463,19 +584,19 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 			if e.Body != nil {
 				visitStatement(e.Body)
 			}
-			exitScope(endPositionOfStatement(e.Body))
+			exitScope()
 		case *ast.WhileExpression:
 			if e == nil {
 				return
 			}
-			enterScope(rangeFromLineCol(e.Line(), e.Column(), 1))
+			enterScope(e)
 			if e.Condition != nil {
 				visitExpression(e.Condition)
 			}
 			if e.Body != nil {
 				visitStatement(e.Body)
 			}
-			exitScope(endPositionOfStatement(e.Body))
+			exitScope()
 		}
 	}
 
@@ -488,63 +609,71 @@ func BuildIndex(uri string, program *ast.Program) *Index {
 	return idx
 }
 
-func defKind(def *Definition) symbol.SymbolKind {
-	if def == nil {
-		return symbol.VARIABLE
+// enumVariantFor resolves `Enum.Variant` member accesses to the variant
+// definition recorded for the enum.
+func enumVariantFor(idx *Index, sc *scope, e *ast.MemberExpression) *Definition {
+	ident, ok := e.Object.(*ast.Identifier)
+	if !ok || ident == nil || e.Property == nil {
+		return nil
 	}
-	return def.Kind
-}
-
-func diagnosticFromMessage(msg, source string) lsp.Diagnostic {
-	line, col, ok := parseLineCol(msg)
-	if !ok {
-		line = 0
-		col = 1
+	def := sc.resolve(ident.Value)
+	if def == nil || def.Kind != symbol.ENUM {
+		return nil
 	}
-	rng := rangeFromLineCol(line, col, 1)
-	return lsp.Diagnostic{
-		Range:    rng,
-		Severity: 1,
-		Source:   "",
-		Message:  cleanMessage(msg),
+	for _, v := range idx.Enums[def.Name] {
+		if v.Name == e.Property.Value {
+			return v
+		}
 	}
+	return nil
 }
 
-func parseLineCol(msg string) (int, int, bool) {
-	if !strings.HasPrefix(msg, "[Line ") {
-		return 0, 0, false
+// importedModuleFor reports whether a member expression accesses a member
+// of a module bound by `import`, returning the binding and import path.
+func importedModuleFor(idx *Index, sc *scope, e *ast.MemberExpression) (string, string, bool) {
+	ident, ok := e.Object.(*ast.Identifier)
+	if !ok || ident == nil {
+		return "", "", false
 	}
-	var line, col int
-	_, err := fmt.Sscanf(msg, "[Line %d, Column %d]", &line, &col)
-	if err != nil {
-		return 0, 0, false
+	if sc.resolve(ident.Value) != nil {
+		return "", "", false
 	}
-	return line, col, true
+	path, ok := idx.Imports[ident.Value]
+	return ident.Value, path, ok
 }
 
-func cleanMessage(msg string) string {
-	if msg == "" {
-		return msg
-	}
-	if i := strings.Index(msg, "]"); i != -1 && i+1 < len(msg) {
-		return strings.TrimSpace(msg[i+1:])
+// URIToPath converts a file:// document URI to a local file path. Other
+// URIs are returned unchanged.
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
 	}
-	return strings.TrimSpace(msg)
+	return u.Path
 }
 
-func rangeFromLineCol(line, col, length int) lsp.Range {
-	startCol := col - 1
-	if startCol < 0 {
-		startCol = 0
-	}
-	if length < 1 {
-		length = 1
-	}
-	endCol := startCol + length
-	return lsp.Range{
-		Start: lsp.Position{Line: line, Character: startCol},
-		End:   lsp.Position{Line: line, Character: endCol},
+// PathToURI converts a local file path to a file:// URI.
+func PathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func defKind(def *Definition) symbol.SymbolKind {
+	if def == nil {
+		return symbol.VARIABLE
 	}
+	return def.Kind
+}
+
+// positionOf converts a lexer position to an LSP position. Columns count
+// bytes, like every position in analysis; the server converts them to the
+// client's encoding.
+func positionOf(p token.Position) lsp.Position {
+	return lsp.Position{Line: max(p.Line-1, 0), Character: max(p.Column-1, 0)}
+}
+
+// nodeRange returns the range node covers in the source.
+func nodeRange(node ast.Node) lsp.Range {
+	return lsp.Range{Start: positionOf(node.Pos()), End: positionOf(node.End())}
 }
 
 func contains(r lsp.Range, pos lsp.Position) bool {
@@ -576,13 +705,6 @@ func rangeContains(a, b lsp.Range) bool {
 	return true
 }
 
-func runeLen(s string) int {
-	if s == "" {
-		return 0
-	}
-	return utf8.RuneCountInString(s)
-}
-
 func positionBefore(a, b lsp.Position) bool {
 	if a.Line < b.Line {
 		return true
@@ -592,56 +714,3 @@ func positionBefore(a, b lsp.Position) bool {
 	}
 	return a.Character < b.Character
 }
-
-func endPositionOfStatements(stmts []ast.Statement) lsp.Position {
-	if len(stmts) == 0 {
-		return lsp.Position{Line: 0, Character: 0}
-	}
-	return endPositionOfStatement(stmts[len(stmts)-1])
-}
-
-func endPositionOfStatement(stmt ast.Statement) lsp.Position {
-	if stmt == nil {
-		return lsp.Position{Line: 0, Character: 0}
-	}
-	switch s := stmt.(type) {
-	case *ast.LetStatement:
-		if s.Name != nil {
-			return lsp.Position{Line: s.Name.Line(), Character: s.Name.Column() - 1 + runeLen(s.Name.Value)}
-		}
-	case *ast.ConstStatement:
-		if s.Name != nil {
-			return lsp.Position{Line: s.Name.Line(), Character: s.Name.Column() - 1 + runeLen(s.Name.Value)}
-		}
-	case *ast.ExpressionStatement:
-		if s.Expression != nil {
-			return endPositionOfExpression(s.Expression)
-		}
-	case *ast.BlockStatement:
-		return endPositionOfStatements(s.Statements)
-	case *ast.StructStatement:
-		if s.Name != nil {
-			return lsp.Position{Line: s.Name.Line(), Character: s.Name.Column() - 1 + runeLen(s.Name.Value)}
-		}
-	}
-	return lsp.Position{Line: stmt.Line(), Character: stmt.Column()}
-}
-
-func endPositionOfExpression(expr ast.Expression) lsp.Position {
-	if expr == nil {
-		return lsp.Position{Line: 0, Character: 0}
-	}
-	switch e := expr.(type) {
-	case *ast.Identifier:
-		return lsp.Position{Line: e.Line(), Character: e.Column() - 1 + runeLen(e.Value)}
-	case *ast.StringLiteral:
-		return lsp.Position{Line: e.Line(), Character: e.Column()}
-	case *ast.IntegerLiteral, *ast.Boolean, *ast.FloatLiteral, *ast.CharLiteral:
-		return lsp.Position{Line: expr.Line(), Character: expr.Column()}
-	case *ast.MemberExpression:
-		if e.Property != nil {
-			return lsp.Position{Line: e.Property.Line(), Character: e.Property.Column() - 1 + runeLen(e.Property.Value)}
-		}
-	}
-	return lsp.Position{Line: expr.Line(), Character: expr.Column()}
-}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func TestIndexRanges(t *testing.T) {
+	input := `let limit = 10;
+let twice = fn(value) {
+  return value * 2;
+};`
+
+	doc := Analyze("file:///ranges.cl", input)
+
+	def := doc.Index.DefsByName["value"][0]
+	expected := lsp.Range{Start: lsp.Position{Line: 1, Character: 15}, End: lsp.Position{Line: 1, Character: 20}}
+	if def.Range != expected {
+		t.Errorf("wrong definition range. expected=%+v, got=%+v", expected, def.Range)
+	}
+
+	occ := doc.FindOccurrenceAt(lsp.Position{Line: 2, Character: 10})
+	if occ == nil || occ.Def != def {
+		t.Fatalf("expected the reference on line 2 to resolve to the parameter, got=%+v", occ)
+	}
+
+	// The function's scope runs from `fn` to its closing brace.
+	scope := doc.Index.Scopes[1]
+	expected = lsp.Range{Start: lsp.Position{Line: 1, Character: 12}, End: lsp.Position{Line: 3, Character: 1}}
+	if scope.Range != expected {
+		t.Errorf("wrong scope range. expected=%+v, got=%+v", expected, scope.Range)
+	}
+}
+
+func TestDiagnosticsUnderlineToken(t *testing.T) {
+	doc := Analyze("file:///diagnostics.cl", "let value = 1;\nlet total = missing + value;")
+
+	diags := doc.Diagnostics()
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got=%+v", diags)
+	}
+
+	expected := lsp.Range{Start: lsp.Position{Line: 1, Character: 12}, End: lsp.Position{Line: 1, Character: 19}}
+	if diags[0].Range != expected {
+		t.Errorf("wrong range. expected=%+v, got=%+v (%s)", expected, diags[0].Range, diags[0].Message)
+	}
+}
+
+func TestWarningDiagnostics(t *testing.T) {
+	doc := Analyze("file:///warnings.cl", "let f = fn(unused) {\n\treturn 1;\n};\nprint(f());")
+
+	diags := doc.Diagnostics()
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got=%+v", diags)
+	}
+
+	diag := diags[0]
+	expected := lsp.Range{Start: lsp.Position{Line: 0, Character: 11}, End: lsp.Position{Line: 0, Character: 17}}
+	if diag.Severity != 2 || diag.Code != "unused-parameter" || diag.Range != expected {
+		t.Errorf("wrong diagnostic. got=%+v", diag)
+	}
+	if len(diag.Tags) != 1 || diag.Tags[0] != lsp.DiagnosticTagUnnecessary {
+		t.Errorf("expected the unnecessary tag, got=%v", diag.Tags)
+	}
+}
+
+func TestLintCodeActions(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"code-lang.json": `{"name": "app", "version": "0.1.0", "lint": {"rules": {"prefer-const": "warning"}}}`,
+	})
+	uri := PathToURI(filepath.Join(dir, "main.cl"))
+	doc := Analyze(uri, "let a = 1;\nlet b = 2;\nprint(a + b);")
+
+	var lintDiags []lsp.Diagnostic
+	for _, diag := range doc.Diagnostics() {
+		if diag.Source == "lint" {
+			lintDiags = append(lintDiags, diag)
+		}
+	}
+	if len(lintDiags) != 2 || lintDiags[0].Code != "prefer-const" || lintDiags[0].Severity != 2 {
+		t.Fatalf("expected 2 prefer-const warnings, got=%+v", lintDiags)
+	}
+
+	actions := doc.lintActions(lsp.Range{Start: lsp.Position{Line: 1, Character: 4}, End: lsp.Position{Line: 1, Character: 4}})
+	if len(actions) != 2 {
+		t.Fatalf("expected a quickfix and a fix-all action, got=%+v", actions)
+	}
+
+	fix := actions[0]
+	expected := lsp.TextEdit{
+		Range:   lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 3}},
+		NewText: "const",
+	}
+	if fix.Kind != "quickfix" || len(fix.Edit.Changes[uri]) != 1 || fix.Edit.Changes[uri][0] != expected {
+		t.Errorf("wrong quickfix. got=%+v", fix)
+	}
+	if all := actions[1]; all.Kind != "source.fixAll" || len(all.Edit.Changes[uri]) != 2 {
+		t.Errorf("wrong fix-all action. got=%+v", all)
+	}
+}
@@ -0,0 +1,230 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// globalModule holds the builtins every file can call without importing.
+const globalModule = "fmt"
+
+// Builtin is a member of a std module, looked up for hover, completion and
+// signature help.
+type Builtin struct {
+	// Name is qualified with its module, e.g. "math.sqrt", except for the
+	// global builtins such as "print".
+	Name  string
+	Value object.Object
+}
+
+// Signature returns the documented signature, or nil for constants and
+// undocumented builtins.
+func (b *Builtin) Signature() *object.Signature {
+	if fn, ok := b.Value.(*object.Builtin); ok {
+		return fn.Signature
+	}
+	return nil
+}
+
+// Detail is the one-line summary shown next to completion items.
+func (b *Builtin) Detail() string {
+	if sig := b.Signature(); sig != nil {
+		return sig.Format(b.Name)
+	}
+	if _, ok := b.Value.(*object.Builtin); ok {
+		return b.Name + "(...)"
+	}
+	return fmt.Sprintf("%s = %s", b.Name, b.Value.Inspect())
+}
+
+// Markdown renders the builtin for hovers.
+func (b *Builtin) Markdown() string {
+	md := "```code-lang\n" + b.Detail() + "\n```"
+	if sig := b.Signature(); sig != nil && sig.Doc != "" {
+		md += "\n\n" + sig.Doc
+	}
+	return md
+}
+
+// LookupBuiltin finds the std builtin a call or reference resolves to.
+// qualifier is the name before the dot of a member access, or "" for a
+// plain name, which may be a global builtin or one brought in with
+// `from "module" import name`.
+func (d *Document) LookupBuiltin(qualifier, name string) (*Builtin, bool) {
+	var imports map[string]string
+	if d != nil && d.Index != nil {
+		imports = d.Index.Imports
+	}
+
+	if qualifier != "" {
+		path, ok := imports[qualifier]
+		if !ok {
+			path = qualifier
+		}
+		return lookupModuleMember(path, name)
+	}
+
+	if d != nil && d.Index != nil {
+		for _, def := range d.Index.Definitions {
+			if def == nil || def.Name != name {
+				continue
+			}
+			if def.Import == nil {
+				// A user definition shadows the global builtins.
+				return nil, false
+			}
+			return lookupModuleMember(def.Import.Path, def.Import.Name)
+		}
+	}
+
+	module, ok := evaluator.BuiltinModule(globalModule)
+	if !ok {
+		return nil, false
+	}
+	value, ok := module.Members[name]
+	if !ok {
+		return nil, false
+	}
+	return &Builtin{Name: name, Value: value}, true
+}
+
+func lookupModuleMember(path, name string) (*Builtin, bool) {
+	module, ok := evaluator.BuiltinModule(path)
+	if !ok {
+		return nil, false
+	}
+	value, ok := module.Members[name]
+	if !ok {
+		return nil, false
+	}
+	return &Builtin{Name: path + "." + name, Value: value}, true
+}
+
+// BuiltinAt returns the std builtin named by the identifier under pos.
+func (d *Document) BuiltinAt(pos lsp.Position) (*Builtin, bool) {
+	line := lineAt(d.Text, pos.Line)
+	offset := OffsetAt(line, lsp.Position{Character: pos.Character})
+
+	start, end := offset, offset
+	for start > 0 && isIdentByte(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdentByte(line[end]) {
+		end++
+	}
+	if start == end {
+		return nil, false
+	}
+
+	qualifier := ""
+	if start > 0 && line[start-1] == '.' {
+		q := start - 1
+		for q > 0 && isIdentByte(line[q-1]) {
+			q--
+		}
+		qualifier = line[q : start-1]
+	}
+
+	return d.LookupBuiltin(qualifier, line[start:end])
+}
+
+// SignatureHelp describes the std builtin call the cursor is in, with the
+// parameter being typed active. It returns nil outside such calls.
+func (d *Document) SignatureHelp(pos lsp.Position) *lsp.SignatureHelp {
+	if d == nil {
+		return nil
+	}
+
+	type frame struct {
+		qualifier, name string
+		call            bool
+		commas          int
+	}
+
+	var stack []frame
+	var prev [3]token.Token
+	l := lexer.New(d.Text[:OffsetAt(d.Text, pos)])
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN:
+			f := frame{}
+			if prev[0].Type == token.IDENT {
+				f.call = true
+				f.name = prev[0].Literal
+				if prev[1].Type == token.DOT && prev[2].Type == token.IDENT {
+					f.qualifier = prev[2].Literal
+				}
+			}
+			stack = append(stack, f)
+		case token.LBRACKET, token.LBRACE:
+			stack = append(stack, frame{})
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case token.COMMA:
+			if len(stack) > 0 {
+				stack[len(stack)-1].commas++
+			}
+		}
+		prev[2], prev[1], prev[0] = prev[1], prev[0], tok
+	}
+
+	// Inside an array, hash or grouping the enclosing call still applies.
+	for len(stack) > 0 && !stack[len(stack)-1].call {
+		stack = stack[:len(stack)-1]
+	}
+	if len(stack) == 0 {
+		return nil
+	}
+	call := stack[len(stack)-1]
+
+	builtin, ok := d.LookupBuiltin(call.qualifier, call.name)
+	if !ok {
+		return nil
+	}
+	sig := builtin.Signature()
+	if sig == nil {
+		return nil
+	}
+
+	info := lsp.SignatureInformation{
+		Label:         sig.Format(builtin.Name),
+		Documentation: &lsp.MarkupContent{Kind: "markdown", Value: sig.Doc},
+		Parameters:    []lsp.ParameterInformation{},
+	}
+	for i := range sig.Params {
+		info.Parameters = append(info.Parameters, lsp.ParameterInformation{Label: sig.ParamLabel(i)})
+	}
+
+	active := call.commas
+	if sig.Variadic && active >= len(sig.Params) && len(sig.Params) > 0 {
+		active = len(sig.Params) - 1
+	}
+
+	return &lsp.SignatureHelp{
+		Signatures:      []lsp.SignatureInformation{info},
+		ActiveSignature: 0,
+		ActiveParameter: active,
+	}
+}
+
+func lineAt(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= utf8.RuneSelf ||
+		('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
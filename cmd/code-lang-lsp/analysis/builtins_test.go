@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func TestSignatureHelp(t *testing.T) {
+	input := `import "math" as m;
+from "strings" import split;
+let len = fn(x) { return 0; };
+m.pow(2, [1, 2], 
+split("a b", 
+print(1, 2, 3, 
+len(`
+
+	doc := Analyze("file:///sig.cl", input)
+
+	tests := []struct {
+		pos    lsp.Position
+		label  string
+		active int
+	}{
+		{lsp.Position{Line: 3, Character: 6}, "math.pow(base: number, exp: number) -> float", 0},
+		{lsp.Position{Line: 3, Character: 13}, "math.pow(base: number, exp: number) -> float", 1},
+		{lsp.Position{Line: 4, Character: 13}, "strings.split(s: string, sep: string) -> array", 1},
+		{lsp.Position{Line: 5, Character: 15}, "print(...values: any) -> null", 0},
+		{lsp.Position{Line: 6, Character: 4}, "", 0},
+		{lsp.Position{Line: 3, Character: 0}, "", 0},
+	}
+
+	for _, tt := range tests {
+		help := doc.SignatureHelp(tt.pos)
+		if tt.label == "" {
+			if help != nil {
+				t.Errorf("expected no signature at %+v, got=%q", tt.pos, help.Signatures[0].Label)
+			}
+			continue
+		}
+		if help == nil {
+			t.Errorf("no signature at %+v", tt.pos)
+			continue
+		}
+		if help.Signatures[0].Label != tt.label || help.ActiveParameter != tt.active {
+			t.Errorf("wrong signature at %+v. expected=%q (%d), got=%q (%d)",
+				tt.pos, tt.label, tt.active, help.Signatures[0].Label, help.ActiveParameter)
+		}
+	}
+}
+
+func TestBuiltinAt(t *testing.T) {
+	input := "import \"math\";\nlet r = math.sqrt(math.PI);\nprint(r);"
+	doc := Analyze("file:///hover.cl", input)
+
+	tests := []struct {
+		pos      lsp.Position
+		expected string
+	}{
+		{lsp.Position{Line: 1, Character: 14}, "math.sqrt(x: number) -> float"},
+		{lsp.Position{Line: 1, Character: 23}, "math.PI = 3.141593"},
+		{lsp.Position{Line: 2, Character: 2}, "print(...values: any) -> null"},
+	}
+
+	for _, tt := range tests {
+		builtin, ok := doc.BuiltinAt(tt.pos)
+		if !ok {
+			t.Errorf("no builtin at %+v", tt.pos)
+			continue
+		}
+		if !strings.Contains(builtin.Markdown(), tt.expected) {
+			t.Errorf("wrong hover at %+v. expected %q in %q", tt.pos, tt.expected, builtin.Markdown())
+		}
+	}
+
+	if _, ok := doc.BuiltinAt(lsp.Position{Line: 1, Character: 4}); ok {
+		t.Errorf("r is not a builtin")
+	}
+}
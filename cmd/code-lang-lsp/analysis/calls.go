@@ -0,0 +1,239 @@
+package analysis
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+)
+
+const (
+	symbolKindFile     = 1
+	symbolKindFunction = 12
+)
+
+// function is a function literal bound to a name by let or const.
+type function struct {
+	def  *Definition
+	lit  *ast.FunctionLiteral
+	stmt ast.Statement
+}
+
+// functions returns the named functions of the document in source order,
+// so enclosing functions come before the functions inside them.
+func (d *Document) functions() []function {
+	if d == nil || d.Program == nil || d.Index == nil {
+		return nil
+	}
+	defs := map[lsp.Range]*Definition{}
+	for _, def := range d.Index.Definitions {
+		defs[def.Range] = def
+	}
+
+	var fns []function
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		var name *ast.Identifier
+		var value ast.Expression
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			name, value = n.Name, n.Value
+		case *ast.ConstStatement:
+			name, value = n.Name, n.Value
+		}
+		if lit, ok := value.(*ast.FunctionLiteral); ok && name != nil && defs[nodeRange(name)] != nil {
+			fns = append(fns, function{def: defs[nodeRange(name)], lit: lit, stmt: node.(ast.Statement)})
+		}
+		return true
+	})
+	return fns
+}
+
+// functionOf returns the named function def declares.
+func (d *Document) functionOf(def *Definition) (function, bool) {
+	for _, fn := range d.functions() {
+		if fn.def.Name == def.Name && fn.def.Range == def.Range {
+			return fn, true
+		}
+	}
+	return function{}, false
+}
+
+// callItem describes fn for the call hierarchy.
+func (fn function) callItem() lsp.CallHierarchyItem {
+	var params []string
+	for _, p := range fn.lit.Parameters {
+		if p != nil {
+			params = append(params, p.Value)
+		}
+	}
+	return lsp.CallHierarchyItem{
+		Name:           fn.def.Name,
+		Kind:           symbolKindFunction,
+		Detail:         "fn(" + strings.Join(params, ", ") + ")",
+		URI:            fn.def.URI,
+		Range:          nodeRange(fn.stmt),
+		SelectionRange: fn.def.Range,
+	}
+}
+
+// fileItem stands for the top level of a document, which makes the calls
+// outside any function.
+func (d *Document) fileItem() lsp.CallHierarchyItem {
+	end := newSpans(d.Text).position(len(d.Text))
+	return lsp.CallHierarchyItem{
+		Name:  filepath.Base(URIToPath(d.URI)),
+		Kind:  symbolKindFile,
+		URI:   d.URI,
+		Range: lsp.Range{End: end},
+	}
+}
+
+// PrepareCallHierarchy returns the function declared or called at pos.
+func (s *State) PrepareCallHierarchy(uri string, pos lsp.Position) []lsp.CallHierarchyItem {
+	if fn, ok := s.functionAt(uri, pos); ok {
+		return []lsp.CallHierarchyItem{fn.callItem()}
+	}
+	return []lsp.CallHierarchyItem{}
+}
+
+// functionAt resolves the name at pos to the named function it refers to.
+func (s *State) functionAt(uri string, pos lsp.Position) (function, bool) {
+	def := s.DefinitionAt(uri, pos)
+	if def == nil {
+		return function{}, false
+	}
+	doc := s.Document(def.URI)
+	if doc == nil {
+		return function{}, false
+	}
+	return doc.functionOf(def)
+}
+
+// IncomingCalls returns the functions, and files at their top level, that
+// call item, with the calls each makes.
+func (s *State) IncomingCalls(item lsp.CallHierarchyItem) []lsp.CallHierarchyIncomingCall {
+	calls := []lsp.CallHierarchyIncomingCall{}
+	if item.Kind != symbolKindFunction {
+		return calls
+	}
+	fn, ok := s.functionAt(item.URI, item.SelectionRange.Start)
+	if !ok {
+		return calls
+	}
+
+	byCaller := map[string]int{}
+	for _, loc := range s.ReferencesTo(fn.def, false) {
+		doc := s.Document(loc.URI)
+		if doc == nil || !doc.callsAt(loc.Range) {
+			continue
+		}
+		caller := doc.fileItem()
+		for _, outer := range doc.functions() {
+			if rangeContains(nodeRange(outer.lit), loc.Range) {
+				caller = outer.callItem()
+			}
+		}
+
+		key := caller.URI + ":" + rangeKey(caller.SelectionRange)
+		if i, ok := byCaller[key]; ok {
+			calls[i].FromRanges = append(calls[i].FromRanges, loc.Range)
+			continue
+		}
+		byCaller[key] = len(calls)
+		calls = append(calls, lsp.CallHierarchyIncomingCall{From: caller, FromRanges: []lsp.Range{loc.Range}})
+	}
+
+	sort.SliceStable(calls, func(i, j int) bool {
+		a, b := calls[i].From, calls[j].From
+		if a.URI != b.URI {
+			return a.URI < b.URI
+		}
+		return positionBefore(a.Range.Start, b.Range.Start)
+	})
+	return calls
+}
+
+// OutgoingCalls returns the named functions item calls, with the calls to
+// each. Calls inside other named functions within item belong to those.
+func (s *State) OutgoingCalls(item lsp.CallHierarchyItem) []lsp.CallHierarchyOutgoingCall {
+	calls := []lsp.CallHierarchyOutgoingCall{}
+	doc := s.Document(item.URI)
+	if doc == nil || doc.Program == nil {
+		return calls
+	}
+
+	var root ast.Node = doc.Program
+	if item.Kind == symbolKindFunction {
+		fn, ok := s.functionAt(item.URI, item.SelectionRange.Start)
+		if !ok {
+			return calls
+		}
+		root = fn.lit
+	} else if item.Kind != symbolKindFile {
+		return calls
+	}
+
+	named := map[*ast.FunctionLiteral]bool{}
+	for _, fn := range doc.functions() {
+		named[fn.lit] = true
+	}
+
+	byCallee := map[string]int{}
+	ast.Inspect(root, func(node ast.Node) bool {
+		if lit, ok := node.(*ast.FunctionLiteral); ok && node != root && named[lit] {
+			return false
+		}
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return true
+		}
+		callee := calleeName(call)
+		if callee == nil {
+			return true
+		}
+		rng := nodeRange(callee)
+		target, ok := s.functionAt(doc.URI, rng.Start)
+		if !ok {
+			return true
+		}
+
+		to := target.callItem()
+		key := to.URI + ":" + rangeKey(to.SelectionRange)
+		if i, ok := byCallee[key]; ok {
+			calls[i].FromRanges = append(calls[i].FromRanges, rng)
+			return true
+		}
+		byCallee[key] = len(calls)
+		calls = append(calls, lsp.CallHierarchyOutgoingCall{To: to, FromRanges: []lsp.Range{rng}})
+		return true
+	})
+	return calls
+}
+
+// calleeName returns the identifier naming the function call calls: the
+// name itself or the member of `module.member`.
+func calleeName(call *ast.CallExpression) *ast.Identifier {
+	switch fn := call.Function.(type) {
+	case *ast.Identifier:
+		return fn
+	case *ast.MemberExpression:
+		return fn.Property
+	}
+	return nil
+}
+
+// callsAt reports whether rng is the name of a called function.
+func (d *Document) callsAt(rng lsp.Range) bool {
+	found := false
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		if call, ok := node.(*ast.CallExpression); ok {
+			if callee := calleeName(call); callee != nil && nodeRange(callee) == rng {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func TestCallHierarchy(t *testing.T) {
+	state, dir := openWorkspace(t, callWorkspace, "main.cl")
+	utilURI := PathToURI(filepath.Join(dir, "lib", "util.cl"))
+	mainURI := PathToURI(filepath.Join(dir, "main.cl"))
+
+	// Preparing at a call in another file finds the declaration.
+	items := state.PrepareCallHierarchy(mainURI, lsp.Position{Line: 2, Character: 7})
+	if len(items) != 1 || items[0].Name != "add" || items[0].Detail != "fn(a, b)" || URIToPath(items[0].URI) != URIToPath(utilURI) {
+		t.Fatalf("wrong items: %+v", items)
+	}
+	add := items[0]
+	if add.SelectionRange != (lsp.Range{Start: lsp.Position{Line: 0, Character: 4}, End: lsp.Position{Line: 0, Character: 7}}) {
+		t.Errorf("wrong selection range: %+v", add.SelectionRange)
+	}
+
+	type call struct {
+		name   string
+		ranges int
+	}
+	var incoming []call
+	for _, c := range state.IncomingCalls(add) {
+		incoming = append(incoming, call{c.From.Name, len(c.FromRanges)})
+	}
+	if expected := []call{{"addAll", 1}, {"run", 2}}; !reflect.DeepEqual(incoming, expected) {
+		t.Errorf("wrong incoming calls. expected %v, got %v", expected, incoming)
+	}
+
+	run := state.PrepareCallHierarchy(mainURI, lsp.Position{Line: 6, Character: 1})
+	if len(run) != 1 {
+		t.Fatalf("expected run, got %+v", run)
+	}
+	var outgoing []call
+	for _, c := range state.OutgoingCalls(run[0]) {
+		outgoing = append(outgoing, call{c.To.Name, len(c.FromRanges)})
+	}
+	if expected := []call{{"add", 2}, {"addAll", 1}}; !reflect.DeepEqual(outgoing, expected) {
+		t.Errorf("wrong outgoing calls. expected %v, got %v", expected, outgoing)
+	}
+
+	// Calls at the top level come from the file.
+	incoming = nil
+	for _, c := range state.IncomingCalls(run[0]) {
+		incoming = append(incoming, call{c.From.Name, len(c.FromRanges)})
+		if c.From.Kind != symbolKindFile {
+			t.Errorf("expected a file item, got %+v", c.From)
+		}
+	}
+	if expected := []call{{"main.cl", 1}}; !reflect.DeepEqual(incoming, expected) {
+		t.Errorf("wrong incoming calls of run. expected %v, got %v", expected, incoming)
+	}
+
+	// Builtins and non-functions have no hierarchy.
+	if items := state.PrepareCallHierarchy(utilURI, lsp.Position{Line: 3, Character: 22}); len(items) != 0 {
+		t.Errorf("expected no items for len, got %+v", items)
+	}
+	if items := state.PrepareCallHierarchy(utilURI, lsp.Position{Line: 2, Character: 5}); len(items) != 0 {
+		t.Errorf("expected no items for a variable, got %+v", items)
+	}
+}
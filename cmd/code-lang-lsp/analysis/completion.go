@@ -0,0 +1,425 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/symbol"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// Completion item kinds.
+const (
+	completionFunction = 3
+	completionField    = 5
+	completionVariable = 6
+	completionModule   = 9
+	completionProperty = 10
+	completionEnum     = 13
+	completionKeyword  = 14
+	completionSnippet  = 15
+	completionConstant = 21
+	completionStruct   = 22
+)
+
+// CompletionKind returns the completion item kind for a definition kind.
+func CompletionKind(kind symbol.SymbolKind) int {
+	switch kind {
+	case symbol.FUNCTION:
+		return completionFunction
+	case symbol.CONSTANT:
+		return completionConstant
+	case symbol.STRUCT:
+		return completionStruct
+	case symbol.ENUM:
+		return completionEnum
+	case symbol.STRUCT_FIELD:
+		return completionField
+	}
+	return completionVariable
+}
+
+// CompletionContext is what the text around a position allows to be
+// written there.
+type CompletionContext struct {
+	// Literal is set inside a string, char literal or comment, where
+	// nothing is completed.
+	Literal bool
+	// Import is set inside the path of an import, which completes to std
+	// module names; Prefix is the part of the path before the position.
+	Import bool
+	Prefix string
+	// AfterIf is set right after the block of an if or elseif, where else
+	// and elseif may follow.
+	AfterIf bool
+	// InLoop is set inside the body of a for or while loop, and not in a
+	// function within it, where break and continue may be written.
+	InLoop bool
+}
+
+// CompletionContext returns the completion context at pos.
+func (d *Document) CompletionContext(pos lsp.Position) CompletionContext {
+	var ctx CompletionContext
+	if d == nil {
+		return ctx
+	}
+	offset := OffsetAt(d.Text, pos)
+	s := newSpans(d.Text)
+
+	for _, c := range s.comments() {
+		if c[0] < offset && offset <= c[1] && inComment(d.Text, c, offset) {
+			ctx.Literal = true
+			return ctx
+		}
+	}
+
+	// The token the position is inside of, if any, and the last one that
+	// ends before it, skipping the name being typed.
+	var open []int
+	last := -1
+	for i, tok := range s.tokens {
+		if tok.start >= offset {
+			break
+		}
+		if tok.end > offset || (tok.end == offset && isUnterminated(d.Text, tok)) {
+			if tok.typ != token.STRING && tok.typ != token.CHAR {
+				break
+			}
+			ctx.Literal = true
+			if tok.typ == token.STRING && i > 0 && (s.tokens[i-1].typ == token.IMPORT || s.tokens[i-1].typ == token.FROM) {
+				ctx.Import = true
+				ctx.Prefix = d.Text[tok.start+1 : offset]
+			}
+			return ctx
+		}
+		if tok.end == offset && isWord(d.Text[tok.start:tok.end]) {
+			break
+		}
+		last = i
+		switch tok.typ {
+		case token.LBRACE:
+			open = append(open, i)
+		case token.RBRACE:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+
+	if last != -1 && s.tokens[last].typ == token.RBRACE {
+		if typ := blockOwner(s.tokens, matchingOpen(s.tokens, last)); typ == token.IF || typ == token.ELSE_IF {
+			ctx.AfterIf = true
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		typ := blockOwner(s.tokens, open[i])
+		if typ == token.FOR || typ == token.WHILE {
+			ctx.InLoop = true
+		}
+		if typ == token.FOR || typ == token.WHILE || typ == token.FUNCTION {
+			break
+		}
+	}
+	return ctx
+}
+
+// ImportCompletions returns the std modules whose names start with prefix.
+func ImportCompletions(prefix string) []lsp.CompletionItem {
+	items := []lsp.CompletionItem{}
+	for _, name := range evaluator.BuiltinModuleNames() {
+		if strings.HasPrefix(name, prefix) {
+			items = append(items, lsp.CompletionItem{Label: name, Kind: completionModule, Detail: "std module"})
+		}
+	}
+	return items
+}
+
+// KeywordCompletions returns the keywords ctx allows.
+func KeywordCompletions(ctx CompletionContext) []lsp.CompletionItem {
+	keywords := []string{"let", "const", "fn", "if", "while", "for", "return", "struct", "import", "true", "false"}
+	if ctx.AfterIf {
+		keywords = append(keywords, "else", "elseif")
+	}
+	if ctx.InLoop {
+		keywords = append(keywords, "break", "continue")
+	}
+	items := []lsp.CompletionItem{}
+	for _, keyword := range keywords {
+		items = append(items, lsp.CompletionItem{Label: keyword, Kind: completionKeyword, Detail: "keyword"})
+	}
+	return items
+}
+
+// MemberCompletions returns what may follow `name.` at pos when name is
+// bound to a struct literal, the fields of its struct, or to a hash
+// literal, the keys that can be written as members.
+func (d *Document) MemberCompletions(name string, pos lsp.Position) ([]lsp.CompletionItem, bool) {
+	if d == nil || d.Program == nil || d.Index == nil || len(d.Index.Scopes) == 0 {
+		return nil, false
+	}
+	def := d.resolveAt(name, pos)
+	if def == nil {
+		return nil, false
+	}
+
+	switch value := d.boundValue(def).(type) {
+	case *ast.StructLiteral:
+		if value.Name == nil {
+			return nil, false
+		}
+		fields := value.Fields
+		if stmt := d.structFor(value.Name.Value, pos); stmt != nil {
+			fields = stmt.Fields
+		}
+		items := []lsp.CompletionItem{}
+		for _, field := range ast.SortedFields(fields) {
+			items = append(items, lsp.CompletionItem{
+				Label:  field,
+				Kind:   completionField,
+				Detail: "field of " + value.Name.Value,
+			})
+		}
+		return items, true
+	case *ast.HashLiteral:
+		var keys []string
+		for key := range value.Pairs {
+			if str, ok := key.(*ast.StringLiteral); ok && isIdentifier(str.Value) {
+				keys = append(keys, str.Value)
+			}
+		}
+		sort.Strings(keys)
+		items := []lsp.CompletionItem{}
+		for _, key := range keys {
+			items = append(items, lsp.CompletionItem{Label: key, Kind: completionProperty, Detail: "hash key"})
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// StructFieldCompletions returns the fields a struct literal around pos
+// has not set yet, when pos is where a field name goes.
+func (d *Document) StructFieldCompletions(pos lsp.Position) ([]lsp.CompletionItem, bool) {
+	if d == nil || d.Index == nil || len(d.Index.Scopes) == 0 {
+		return nil, false
+	}
+	offset := OffsetAt(d.Text, pos)
+
+	var tokens []token.Token
+	l := lexer.New(d.Text)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+
+	// Find the innermost bracket open at offset and the last token before
+	// it, skipping the name being typed.
+	var open []int
+	last := -1
+	for i, tok := range tokens {
+		if tok.End.Offset > offset || (tok.Type == token.IDENT && tok.End.Offset == offset) {
+			break
+		}
+		last = i
+		switch tok.Type {
+		case token.LBRACE, token.LPAREN, token.LBRACKET:
+			open = append(open, i)
+		case token.RBRACE, token.RPAREN, token.RBRACKET:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	if len(open) == 0 || last == -1 {
+		return nil, false
+	}
+	brace := open[len(open)-1]
+	if tokens[brace].Type != token.LBRACE || brace == 0 || tokens[brace-1].Type != token.IDENT {
+		return nil, false
+	}
+	if typ := tokens[last].Type; typ != token.LBRACE && typ != token.COMMA {
+		return nil, false
+	}
+
+	stmt := d.structFor(tokens[brace-1].Literal, pos)
+	if stmt == nil {
+		return nil, false
+	}
+
+	// Fields already set on either side of the cursor.
+	set := map[string]bool{}
+	depth := 0
+	for i := brace + 1; i < len(tokens) && depth >= 0; i++ {
+		switch tokens[i].Type {
+		case token.LBRACE, token.LPAREN, token.LBRACKET:
+			depth++
+		case token.RBRACE, token.RPAREN, token.RBRACKET:
+			depth--
+		case token.COLON:
+			if depth == 0 && tokens[i-1].Type == token.IDENT {
+				set[tokens[i-1].Literal] = true
+			}
+		}
+	}
+
+	items := []lsp.CompletionItem{}
+	for _, field := range ast.SortedFields(stmt.Fields) {
+		if set[field] {
+			continue
+		}
+		items = append(items, lsp.CompletionItem{
+			Label:      field,
+			Kind:       completionField,
+			Detail:     field + ": " + sourceText(stmt.Fields[field]),
+			InsertText: field + ": ",
+		})
+	}
+	return items, true
+}
+
+// ModuleCompletions returns the declarations the user module that module
+// names in uri exports. Std modules are left to the caller.
+func (s *State) ModuleCompletions(uri, module string) ([]lsp.CompletionItem, bool) {
+	doc := s.Document(uri)
+	if doc == nil || doc.Index == nil {
+		return nil, false
+	}
+	path, ok := doc.Index.Imports[module]
+	if !ok || evaluator.IsBuiltinModule(path) {
+		return nil, false
+	}
+	target := s.Document(s.ResolveImport(uri, path))
+	if target == nil || target.Program == nil {
+		return nil, false
+	}
+
+	exports := symbol.ModuleExports(target.Program)
+	names := make([]string, 0, len(exports))
+	for name := range exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := []lsp.CompletionItem{}
+	for _, name := range names {
+		items = append(items, lsp.CompletionItem{
+			Label:  name,
+			Kind:   CompletionKind(exports[name]),
+			Detail: exports[name].String() + " from " + path,
+		})
+	}
+	return items, true
+}
+
+// boundValue returns the value the let or const declaring def binds.
+func (d *Document) boundValue(def *Definition) ast.Expression {
+	var value ast.Expression
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			if n.Name != nil && nodeRange(n.Name) == def.Range {
+				value = n.Value
+			}
+		case *ast.ConstStatement:
+			if n.Name != nil && nodeRange(n.Name) == def.Range {
+				value = n.Value
+			}
+		}
+		return value == nil
+	})
+	return value
+}
+
+// structFor returns the declaration of the struct name refers to at pos.
+func (d *Document) structFor(name string, pos lsp.Position) *ast.StructStatement {
+	if d.Program == nil {
+		return nil
+	}
+	def := d.resolveAt(name, pos)
+	if def == nil || def.Kind != symbol.STRUCT {
+		return nil
+	}
+	var stmt *ast.StructStatement
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		if s, ok := node.(*ast.StructStatement); ok && s.Name != nil && nodeRange(s.Name) == def.Range {
+			stmt = s
+		}
+		return stmt == nil
+	})
+	return stmt
+}
+
+// isIdentifier reports whether s can be written as a member name.
+func isIdentifier(s string) bool {
+	l := lexer.New(s)
+	tok := l.NextToken()
+	return tok.Type == token.IDENT && tok.Literal == s && l.NextToken().Type == token.EOF
+}
+
+// isWord reports whether s, the text of a token, is a name or keyword,
+// which may still be being typed.
+func isWord(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// inComment reports whether offset, inside the span c of comments, is in
+// the text of one rather than in the space between merged `#` lines.
+func inComment(text string, c [2]int, offset int) bool {
+	if strings.HasPrefix(text[c[0]:], "/*") {
+		return offset < c[1] || !strings.HasSuffix(text[c[0]:c[1]], "*/")
+	}
+	start := max(c[0], strings.LastIndexByte(text[:offset], '\n')+1)
+	return strings.Contains(text[start:offset], "#")
+}
+
+// isUnterminated reports whether tok is a string or char literal missing
+// its closing quote, which the lexer runs to the end of the text.
+func isUnterminated(text string, tok spanToken) bool {
+	if tok.typ != token.STRING && tok.typ != token.CHAR {
+		return false
+	}
+	return tok.end-tok.start < 2 || text[tok.end-1] != text[tok.start]
+}
+
+// matchingOpen returns the index of the bracket the one at close closes.
+func matchingOpen(tokens []spanToken, close int) int {
+	depth := 0
+	for i := close; i >= 0; i-- {
+		switch tokens[i].typ {
+		case token.RBRACE, token.RPAREN, token.RBRACKET:
+			depth++
+		case token.LBRACE, token.LPAREN, token.LBRACKET:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// blockOwner returns the keyword that introduces the block opening at
+// brace: if, elseif, else, for, while or fn, or "" for any other brace.
+func blockOwner(tokens []spanToken, brace int) token.TokenType {
+	if brace < 1 {
+		return ""
+	}
+	switch prev := brace - 1; tokens[prev].typ {
+	case token.ELSE:
+		return token.ELSE
+	case token.RPAREN:
+		if open := matchingOpen(tokens, prev); open > 0 {
+			switch typ := tokens[open-1].typ; typ {
+			case token.IF, token.ELSE_IF, token.FOR, token.WHILE, token.FUNCTION:
+				return typ
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,200 @@
+package analysis
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func labels(items []lsp.CompletionItem) string {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Label)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestMemberCompletions(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		expected string
+	}{
+		{"struct Point { x: 0, y: 0, label: \"\" };\nlet p = Point { x: 1 };\np.«»", "p", "x y label"},
+		{"let h = {\"name\": \"walon\", \"age\": 25, \"not a name\": 1, 3: 4};\nh.«»", "h", "age name"},
+		{"let f = fn() { let h = {\"inner\": 1}; h.«» };", "h", "inner"},
+	}
+
+	for _, tt := range tests {
+		input, rng := selection(tt.input)
+		items, ok := Analyze("file:///members.cl", input).MemberCompletions(tt.name, rng.Start)
+		if !ok {
+			t.Errorf("no member completions for %q", tt.input)
+			continue
+		}
+		if got := labels(items); got != tt.expected {
+			t.Errorf("wrong members for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	input, rng := selection("let n = 1;\nn.«»")
+	if _, ok := Analyze("file:///members.cl", input).MemberCompletions("n", rng.Start); ok {
+		t.Errorf("an int has no members to complete")
+	}
+}
+
+func TestStructFieldCompletions(t *testing.T) {
+	decl := "struct Point { x: 0, y: 0, label: \"origin\" };\n"
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"let p = Point { «» };", "x y label", true},
+		{"let p = Point { x: 1, «» };", "y label", true},
+		{"let p = Point { x: 1, l«» };", "y label", true},
+		{"let p = Point { «», y: [1, 2] };", "x label", true},
+		{"let p = Point { x: «» };", "", false},
+		{"let p = Point { x: foo(«») };", "", false},
+		{"let h = { «» };", "", false},
+	}
+
+	for _, tt := range tests {
+		input, rng := selection(decl + tt.input)
+		items, ok := Analyze("file:///fields.cl", input).StructFieldCompletions(rng.Start)
+		if ok != tt.ok {
+			t.Errorf("%q: expected ok=%v, got=%v", tt.input, tt.ok, ok)
+			continue
+		}
+		if got := labels(items); got != tt.expected {
+			t.Errorf("wrong fields for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	input, rng := selection(decl + "let p = Point { x: 1, y: 1, «» };")
+	items, _ := Analyze("file:///fields.cl", input).StructFieldCompletions(rng.Start)
+	if len(items) == 0 || items[0].InsertText != "label: " || items[0].Detail != "label: \"origin\"" {
+		t.Errorf("wrong field item: %+v", items)
+	}
+}
+
+func TestModuleCompletions(t *testing.T) {
+	files := map[string]string{
+		"lib/shapes.cl": "export let area = fn(w, h) { return w * h; };\nexport const UNIT = 1;\nexport struct Box { w: 0 };\nlet helper = 2;",
+		"main.cl":       "import \"lib/shapes\";\nimport \"math\";\nshapes.",
+	}
+	state, dir := openWorkspace(t, files, "main.cl")
+	uri := PathToURI(filepath.Join(dir, "main.cl"))
+
+	items, ok := state.ModuleCompletions(uri, "shapes")
+	if !ok {
+		t.Fatal("no completions for the user module")
+	}
+	if got := labels(items); got != "Box UNIT area" {
+		t.Errorf("wrong module members. got=%q", got)
+	}
+	if items[2].Kind != completionFunction || items[2].Detail != "function from lib/shapes" {
+		t.Errorf("wrong item for area: %+v", items[2])
+	}
+
+	if _, ok := state.ModuleCompletions(uri, "math"); ok {
+		t.Errorf("std modules are completed from their builtins")
+	}
+}
+
+func TestCompletionContext(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected CompletionContext
+	}{
+		{"let s = \"hel«»lo\";", CompletionContext{Literal: true}},
+		{"let s = \"hel«»", CompletionContext{Literal: true}},
+		{"let c = 'a«»';", CompletionContext{Literal: true}},
+		{"let x = 1; # a com«»ment", CompletionContext{Literal: true}},
+		{"# one\n# two«»\nlet x = 1;", CompletionContext{Literal: true}},
+		{"/* a «» */", CompletionContext{Literal: true}},
+		{"/* a */ «»", CompletionContext{}},
+		{"let s = \"done\"«»", CompletionContext{}},
+		{"import \"ma«»", CompletionContext{Literal: true, Import: true, Prefix: "ma"}},
+		{"from \"«»\" import x;", CompletionContext{Literal: true, Import: true}},
+		{"if (x) { 1 } «»", CompletionContext{AfterIf: true}},
+		{"if (x) { 1 } el«»", CompletionContext{AfterIf: true}},
+		{"if (x) { 1 } elseif (y) { 2 } «»", CompletionContext{AfterIf: true}},
+		{"if (x) { 1 } else { 2 } «»", CompletionContext{}},
+		{"while (x) { 1 } «»", CompletionContext{}},
+		{"while (x) { «» }", CompletionContext{InLoop: true}},
+		{"for (let i = 0; i < 3; i += 1) { if (i) { br«» } }", CompletionContext{InLoop: true}},
+		{"while (x) { let f = fn() { «» }; }", CompletionContext{}},
+		{"let f = fn() { while (x) { 1 } «» };", CompletionContext{}},
+	}
+
+	for _, tt := range tests {
+		input, rng := selection(tt.input)
+		if got := Analyze("file:///context.cl", input).CompletionContext(rng.Start); got != tt.expected {
+			t.Errorf("wrong context for %q. expected=%+v, got=%+v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestKeywordCompletions(t *testing.T) {
+	base := "let const fn if while for return struct import true false"
+	tests := []struct {
+		ctx      CompletionContext
+		expected string
+	}{
+		{CompletionContext{}, base},
+		{CompletionContext{AfterIf: true}, base + " else elseif"},
+		{CompletionContext{InLoop: true}, base + " break continue"},
+	}
+
+	for _, tt := range tests {
+		if got := labels(KeywordCompletions(tt.ctx)); got != tt.expected {
+			t.Errorf("wrong keywords for %+v. expected=%q, got=%q", tt.ctx, tt.expected, got)
+		}
+	}
+}
+
+func TestSnippetCompletions(t *testing.T) {
+	doc := Analyze("file:///snippets.cl", "let x = 1;")
+	if got, expected := labels(doc.SnippetCompletions(CompletionContext{})), "fn for while struct if ifelse"; got != expected {
+		t.Errorf("wrong snippets. expected=%q, got=%q", expected, got)
+	}
+	if got, expected := labels(doc.SnippetCompletions(CompletionContext{AfterIf: true})), "fn for while struct if ifelse elseif else"; got != expected {
+		t.Errorf("wrong snippets after an if. expected=%q, got=%q", expected, got)
+	}
+	for _, item := range doc.SnippetCompletions(CompletionContext{}) {
+		if item.InsertTextFormat != lsp.InsertTextFormatSnippet || !strings.Contains(item.InsertText, "$") {
+			t.Errorf("%s is not a snippet: %+v", item.Label, item)
+		}
+	}
+
+	items := Analyze("file:///server.cl", "import \"net\" as web;").SnippetCompletions(CompletionContext{})
+	server := items[len(items)-1]
+	if server.Label != "server" || !strings.HasPrefix(server.InsertText, "let ${1:server} = web.server();") {
+		t.Errorf("wrong server snippet: %+v", server)
+	}
+}
+
+func TestImportCompletions(t *testing.T) {
+	if got := labels(ImportCompletions("ma")); got != "math" {
+		t.Errorf("wrong modules for \"ma\". got=%q", got)
+	}
+	for _, item := range ImportCompletions("") {
+		if item.Kind != completionModule {
+			t.Errorf("%s is not a module", item.Label)
+		}
+	}
+}
+
+func TestCompletionAtSkipsOtherScopes(t *testing.T) {
+	input, rng := selection("let add = fn(a, b) { return a + b; };\nlet total = 1;\n«»")
+	var names []string
+	for _, def := range Analyze("file:///scopes.cl", input).CompletionAt(rng.Start) {
+		names = append(names, def.Name)
+	}
+	if got := strings.Join(names, " "); got != "add total" {
+		t.Errorf("wrong top-level completions. expected=%q, got=%q", "add total", got)
+	}
+}
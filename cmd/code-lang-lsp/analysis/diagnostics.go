@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/evaluator"
+)
+
+// FileDiagnostics are the diagnostics of one document.
+type FileDiagnostics struct {
+	Doc         *Document
+	Diagnostics []lsp.Diagnostic
+}
+
+// Diagnostics returns the problems of doc: its own and those the files it
+// imports cause in it, which change when those files do.
+func (s *State) Diagnostics(doc *Document) []lsp.Diagnostic {
+	if doc == nil {
+		return nil
+	}
+	return append(doc.Diagnostics(), s.importDiagnostics(doc)...)
+}
+
+// WorkspaceDiagnostics returns the diagnostics of every document in the
+// workspace, open or not, ordered by URI.
+func (s *State) WorkspaceDiagnostics() []FileDiagnostics {
+	var files []FileDiagnostics
+	for _, doc := range s.allDocuments() {
+		files = append(files, FileDiagnostics{Doc: doc, Diagnostics: s.Diagnostics(doc)})
+	}
+	return files
+}
+
+// importDiagnostics reports imports that do not resolve, names the
+// imported .cl modules do not export and calls to imported functions with the
+// wrong number of arguments: the evaluator's errors, found before the
+// program runs.
+func (s *State) importDiagnostics(doc *Document) []lsp.Diagnostic {
+	var diags []lsp.Diagnostic
+	if doc.Program == nil || doc.Index == nil {
+		return diags
+	}
+	add := func(rng lsp.Range, format string, args ...any) {
+		diags = append(diags, lsp.Diagnostic{
+			Range:    rng,
+			Severity: 1,
+			Source:   "import",
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	sp := newSpans(doc.Text)
+	ast.Inspect(doc.Program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.ImportStatement:
+			if evaluator.IsBuiltinModule(n.Path) || evaluator.IsPluginModule(URIToPath(doc.URI), n.Path) {
+				return false
+			}
+			target := s.Document(s.ResolveImport(doc.URI, n.Path))
+			if target == nil {
+				rng, ok := sp.pathRange(n)
+				if !ok {
+					rng = nodeRange(n)
+				}
+				add(rng, "could not find module %q", n.Path)
+				return false
+			}
+			for _, name := range n.Names {
+				if name != nil && target.Exported(name.Value) == nil {
+					add(nodeRange(name), "module %q has no member %s", n.Path, name.Value)
+				}
+			}
+			return false
+		case *ast.CallExpression:
+			if name, fn, ok := s.importedCallee(doc, n); ok && len(n.Arguments) != len(fn.lit.Parameters) {
+				add(nodeRange(n.Function), "wrong number of arguments for %s. got=%d, want=%d",
+					name, len(n.Arguments), len(fn.lit.Parameters))
+			}
+		}
+		return true
+	})
+
+	for _, m := range doc.Index.ModuleMembers {
+		if evaluator.IsBuiltinModule(m.Path) {
+			continue
+		}
+		if target := s.Document(s.ResolveImport(doc.URI, m.Path)); target != nil && target.Exported(m.Name) == nil {
+			add(m.Range, "module %q has no member %s", m.Path, m.Name)
+		}
+	}
+	return diags
+}
+
+// importedCallee returns the function declared in another file that call
+// invokes, through a `from ... import` name or a `module.member` access,
+// with the name the call uses.
+func (s *State) importedCallee(doc *Document, call *ast.CallExpression) (string, function, bool) {
+	var def *Definition
+	var name string
+	switch fn := call.Function.(type) {
+	case *ast.Identifier:
+		occ := doc.FindOccurrenceAt(nodeRange(fn).Start)
+		if occ == nil || occ.Def == nil || occ.Def.Import == nil {
+			return "", function{}, false
+		}
+		def, name = s.Origin(occ.Def), fn.Value
+	case *ast.MemberExpression:
+		if fn.Property == nil {
+			return "", function{}, false
+		}
+		m := doc.ModuleMemberAt(nodeRange(fn.Property).Start)
+		if m == nil || evaluator.IsBuiltinModule(m.Path) {
+			return "", function{}, false
+		}
+		def, name = s.Origin(s.Document(s.ResolveImport(doc.URI, m.Path)).Exported(m.Name)), m.Module+"."+m.Name
+	}
+	if def == nil || def.Import != nil {
+		return "", function{}, false
+	}
+	target := s.Document(def.URI)
+	if target == nil {
+		return "", function{}, false
+	}
+	fn, ok := target.functionOf(def)
+	return name, fn, ok
+}
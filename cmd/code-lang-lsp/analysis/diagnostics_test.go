@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func TestImportDiagnostics(t *testing.T) {
+	files := map[string]string{
+		"lib/util.cl": "let add = fn(a, b) { return a + b; };\nlet _hidden = 1;",
+		"main.cl": "import \"lib/util\";\nimport \"lib/gone\";\nimport \"math\";\nfrom \"lib/util\" import add, sub;\n" +
+			"util.add(1);\nadd(1, 2, 3);\nadd(1, 2);\nutil._hidden;\nmath.abs(1);",
+	}
+	state, dir := openWorkspace(t, files, "main.cl")
+	diags := state.Diagnostics(state.Document(PathToURI(filepath.Join(dir, "main.cl"))))
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{1, `could not find module "lib/gone"`},
+		{3, `module "lib/util" has no member sub`},
+		{4, "wrong number of arguments for util.add. got=1, want=2"},
+		{5, "wrong number of arguments for add. got=3, want=2"},
+		{7, `module "lib/util" has no member _hidden`},
+	}
+	var imports []lsp.Diagnostic
+	for _, diag := range diags {
+		if diag.Source == "import" {
+			imports = append(imports, diag)
+		}
+	}
+	if len(imports) != len(expected) {
+		t.Fatalf("expected %d import diagnostics, got=%+v", len(expected), imports)
+	}
+	for i, diag := range imports {
+		if diag.Range.Start.Line != expected[i].line || diag.Message != expected[i].message {
+			t.Errorf("diagnostic %d wrong. expected=%d %q, got=%d %q", i, expected[i].line, expected[i].message, diag.Range.Start.Line, diag.Message)
+		}
+	}
+	if missing := imports[0]; missing.Range.Start.Character != 7 || missing.Range.End.Character != 17 {
+		t.Errorf("missing module not underlined at its path: %+v", missing.Range)
+	}
+}
+
+func TestWorkspaceDiagnostics(t *testing.T) {
+	files := map[string]string{
+		"lib/util.cl": "let add = fn(a, b, c) { return a + b + c; };",
+		"main.cl":     "import \"lib/util\";\nutil.add(1, 2);",
+		"ok.cl":       "let x = 1;\nprint(x);",
+	}
+	state, dir := openWorkspace(t, files)
+
+	var names []string
+	broken := map[string]bool{}
+	for _, file := range state.WorkspaceDiagnostics() {
+		rel, _ := filepath.Rel(dir, URIToPath(file.Doc.URI))
+		names = append(names, filepath.ToSlash(rel))
+		broken[filepath.ToSlash(rel)] = len(file.Diagnostics) > 0
+	}
+	if len(names) != 3 || names[0] != "lib/util.cl" || names[1] != "main.cl" || names[2] != "ok.cl" {
+		t.Fatalf("wrong files. got=%v", names)
+	}
+	if !broken["main.cl"] || broken["ok.cl"] || broken["lib/util.cl"] {
+		t.Errorf("wrong files with problems: %v", broken)
+	}
+}
+
+func TestPluginImportDiagnostics(t *testing.T) {
+	files := map[string]string{
+		"code-lang.json": `{"name": "app", "version": "0.1.0", "plugins": {"mylib": "./mylib-plugin"}}`,
+		"main.cl":        "import \"mylib\";\nfrom \"mylib\" import add;\nmylib.add(1);\nadd(1, 2);",
+	}
+	state, dir := openWorkspace(t, files, "main.cl")
+	uri := PathToURI(filepath.Join(dir, "main.cl"))
+
+	for _, diag := range state.Diagnostics(state.Document(uri)) {
+		if diag.Source == "import" {
+			t.Errorf("unexpected import diagnostic: %s", diag.Message)
+		}
+	}
+	if hover, _ := state.ImportHover(uri, lsp.Position{Line: 0, Character: 9}); hover != "**mylib** (plugin module)" {
+		t.Errorf("wrong hover for a plugin import: %q", hover)
+	}
+}
@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"unicode/utf8"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+// PositionEncoding is the unit the client counts columns in. Analysis
+// always counts bytes; positions are converted at the protocol boundary.
+type PositionEncoding string
+
+const (
+	UTF8  PositionEncoding = "utf-8"
+	UTF16 PositionEncoding = "utf-16"
+)
+
+// NegotiateEncoding picks the encoding for a client that offered the given
+// ones: UTF-8, which needs no conversion, when the client supports it, and
+// otherwise UTF-16, which every client must support.
+func NegotiateEncoding(offered []string) PositionEncoding {
+	for _, enc := range offered {
+		if PositionEncoding(enc) == UTF8 {
+			return UTF8
+		}
+	}
+	return UTF16
+}
+
+// Converter translates the positions of one text between byte columns and
+// the client's encoding. A nil Converter leaves positions unchanged.
+type Converter struct {
+	text   string
+	starts []int
+}
+
+// NewConverter returns the converter for text in enc, or nil when enc
+// already counts bytes.
+func NewConverter(text string, enc PositionEncoding) *Converter {
+	if enc == UTF8 {
+		return nil
+	}
+	return &Converter{text: text, starts: lineStarts(text)}
+}
+
+// Converter returns the converter for the current text of uri in the
+// client's encoding. It is nil, leaving positions unchanged, when the
+// client counts bytes or the document cannot be read.
+func (s *State) Converter(uri string) *Converter {
+	if s.Encoding == UTF8 {
+		return nil
+	}
+	doc := s.Document(uri)
+	if doc == nil {
+		return nil
+	}
+	return NewConverter(doc.Text, s.Encoding)
+}
+
+// line returns the text of line n without its newline.
+func (c *Converter) line(n int) (string, bool) {
+	if n < 0 || n >= len(c.starts) {
+		return "", false
+	}
+	end := len(c.text)
+	if n+1 < len(c.starts) {
+		end = c.starts[n+1] - 1
+	}
+	return c.text[c.starts[n]:end], true
+}
+
+// ToClient converts a position with a byte column to the client's units.
+func (c *Converter) ToClient(pos lsp.Position) lsp.Position {
+	if c == nil {
+		return pos
+	}
+	line, ok := c.line(pos.Line)
+	if !ok {
+		return pos
+	}
+	col := min(max(pos.Character, 0), len(line))
+	return lsp.Position{Line: pos.Line, Character: utf16Len(line[:col])}
+}
+
+// FromClient converts a position in the client's units to a byte column.
+// Columns past the end of the line are clamped to it.
+func (c *Converter) FromClient(pos lsp.Position) lsp.Position {
+	if c == nil {
+		return pos
+	}
+	line, ok := c.line(pos.Line)
+	if !ok {
+		return pos
+	}
+	col, units := 0, 0
+	for col < len(line) && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(line[col:])
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+		col += size
+	}
+	return lsp.Position{Line: pos.Line, Character: col}
+}
+
+func (c *Converter) RangeToClient(r lsp.Range) lsp.Range {
+	return lsp.Range{Start: c.ToClient(r.Start), End: c.ToClient(r.End)}
+}
+
+func (c *Converter) RangeFromClient(r lsp.Range) lsp.Range {
+	return lsp.Range{Start: c.FromClient(r.Start), End: c.FromClient(r.End)}
+}
+
+// SemanticTokens re-encodes semantic token data, whose starts and lengths
+// count bytes, in the client's units.
+func (c *Converter) SemanticTokens(data []int) []int {
+	if c == nil {
+		return data
+	}
+	out := make([]int, len(data))
+	line, char, prevChar := 0, 0, 0
+	for i := 0; i+4 < len(data); i += 5 {
+		if data[i] != 0 {
+			char, prevChar = 0, 0
+		}
+		line += data[i]
+		char += data[i+1]
+
+		start := c.ToClient(lsp.Position{Line: line, Character: char})
+		end := c.ToClient(lsp.Position{Line: line, Character: char + data[i+2]})
+		copy(out[i:i+5], data[i:i+5])
+		out[i+1] = start.Character - prevChar
+		out[i+2] = end.Character - start.Character
+		prevChar = start.Character
+	}
+	return out
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+		s = s[size:]
+	}
+	return n
+}
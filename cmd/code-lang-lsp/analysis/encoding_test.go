@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		offered  []string
+		expected PositionEncoding
+	}{
+		{nil, UTF16},
+		{[]string{"utf-16"}, UTF16},
+		{[]string{"utf-32", "utf-16"}, UTF16},
+		{[]string{"utf-16", "utf-8"}, UTF8},
+	}
+
+	for _, tt := range tests {
+		if got := NegotiateEncoding(tt.offered); got != tt.expected {
+			t.Errorf("NegotiateEncoding(%v) wrong. expected=%q, got=%q", tt.offered, tt.expected, got)
+		}
+	}
+}
+
+func TestConverter(t *testing.T) {
+	text := "let s = \"😀é\";\ns;"
+	conv := NewConverter(text, UTF16)
+
+	tests := []struct {
+		bytes, units lsp.Position
+	}{
+		{lsp.Position{Line: 0, Character: 9}, lsp.Position{Line: 0, Character: 9}},
+		{lsp.Position{Line: 0, Character: 13}, lsp.Position{Line: 0, Character: 11}},
+		{lsp.Position{Line: 0, Character: 15}, lsp.Position{Line: 0, Character: 12}},
+		{lsp.Position{Line: 0, Character: 17}, lsp.Position{Line: 0, Character: 14}},
+		{lsp.Position{Line: 1, Character: 1}, lsp.Position{Line: 1, Character: 1}},
+	}
+
+	for _, tt := range tests {
+		if got := conv.ToClient(tt.bytes); got != tt.units {
+			t.Errorf("ToClient(%+v) wrong. expected=%+v, got=%+v", tt.bytes, tt.units, got)
+		}
+		if got := conv.FromClient(tt.units); got != tt.bytes {
+			t.Errorf("FromClient(%+v) wrong. expected=%+v, got=%+v", tt.units, tt.bytes, got)
+		}
+	}
+
+	if got := conv.FromClient(lsp.Position{Line: 0, Character: 40}); got.Character != 17 {
+		t.Errorf("columns past the end of the line should be clamped, got=%+v", got)
+	}
+	if NewConverter(text, UTF8) != nil {
+		t.Errorf("UTF-8 needs no converter")
+	}
+}
@@ -0,0 +1,349 @@
+package analysis
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/std/net"
+	"github.com/walonCode/code-lang/internal/symbol"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// serverMethods are the members of the value net.server() returns.
+var serverMethods = net.NewServer(nil).(*object.Server).Members
+
+// InlayHints returns, for the lines rng spans, parameter names at call
+// sites, the inferred kind of let and const bindings whose value does not
+// make it obvious, and the field defaults a struct literal leaves out.
+func (d *Document) InlayHints(rng lsp.Range) []lsp.InlayHint {
+	hints := []lsp.InlayHint{}
+	if d == nil || d.Program == nil || d.Index == nil {
+		return hints
+	}
+
+	h := &hinter{
+		doc:         d,
+		spans:       newSpans(d.Text),
+		occurrences: d.occurrencesByPosition(),
+		kinds:       map[*Definition]string{},
+		functions:   map[*Definition]*ast.FunctionLiteral{},
+		structs:     map[*Definition]*ast.StructStatement{},
+	}
+	ast.Inspect(d.Program, h.visit)
+
+	for _, hint := range h.hints {
+		if hint.Position.Line >= rng.Start.Line && hint.Position.Line <= rng.End.Line {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// hinter collects inlay hints in one pass over the program. Bindings are
+// recorded as they are reached, so only earlier definitions are known.
+type hinter struct {
+	doc         *Document
+	spans       *spans
+	occurrences map[occurrenceKey]any
+	kinds       map[*Definition]string
+	functions   map[*Definition]*ast.FunctionLiteral
+	structs     map[*Definition]*ast.StructStatement
+	hints       []lsp.InlayHint
+}
+
+func (h *hinter) visit(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.LetStatement:
+		h.binding(n.Name, n.Value)
+	case *ast.ConstStatement:
+		h.binding(n.Name, n.Value)
+	case *ast.StructStatement:
+		if def := h.definition(n.Name); def != nil {
+			h.structs[def] = n
+		}
+	case *ast.CallExpression:
+		h.parameters(n)
+	case *ast.StructLiteral:
+		h.structDefaults(n)
+	}
+	return true
+}
+
+func (h *hinter) binding(name *ast.Identifier, value ast.Expression) {
+	if name == nil || ast.IsNil(value) {
+		return
+	}
+
+	def := h.definition(name)
+	if fn, ok := value.(*ast.FunctionLiteral); ok && def != nil {
+		h.functions[def] = fn
+	}
+
+	kind := h.inferKind(value)
+	if kind == "" {
+		return
+	}
+	if def != nil {
+		h.kinds[def] = kind
+	}
+
+	switch value.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.CharLiteral, *ast.Boolean,
+		*ast.ArrayLiteral, *ast.HashLiteral, *ast.FunctionLiteral, *ast.StructLiteral:
+		// The literal already shows its kind.
+		return
+	}
+
+	h.hints = append(h.hints, lsp.InlayHint{
+		Position: h.spans.position(name.End().Offset),
+		Label:    ": " + kind,
+		Kind:     lsp.InlayHintKindType,
+	})
+}
+
+func (h *hinter) parameters(call *ast.CallExpression) {
+	names, _ := h.callee(call)
+	for i, arg := range call.Arguments {
+		if i >= len(names) {
+			break
+		}
+		if ast.IsNil(arg) {
+			continue
+		}
+		if ident, ok := arg.(*ast.Identifier); ok && ident.Value == names[i] {
+			continue
+		}
+
+		h.hints = append(h.hints, lsp.InlayHint{
+			Position:     h.spans.position(arg.Pos().Offset),
+			Label:        names[i] + ":",
+			Kind:         lsp.InlayHintKindParameter,
+			PaddingRight: true,
+		})
+	}
+}
+
+func (h *hinter) structDefaults(lit *ast.StructLiteral) {
+	if lit.Name == nil {
+		return
+	}
+	stmt, ok := h.structs[h.definition(lit.Name)]
+	if !ok {
+		return
+	}
+
+	var missing []string
+	for _, field := range ast.SortedFields(stmt.Fields) {
+		if _, ok := lit.Fields[field]; !ok {
+			missing = append(missing, field+": "+sourceText(stmt.Fields[field]))
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	// The hint goes before the closing brace, after whatever precedes it.
+	closing := lit.End().Offset - 1
+	before, ok := h.spans.tokenBefore(closing)
+	if !ok {
+		return
+	}
+	prev := before.typ
+	label := strings.Join(missing, ", ")
+	if prev != token.LBRACE && prev != token.COMMA {
+		label = ", " + label
+	}
+
+	h.hints = append(h.hints, lsp.InlayHint{
+		Position:     h.spans.position(closing),
+		Label:        label,
+		PaddingLeft:  prev != token.LBRACE,
+		PaddingRight: true,
+	})
+}
+
+// callee returns the parameter names of the function a call invokes and
+// its documented result kind, when they are known. Variadic parameters are
+// left out since their name says little at each argument.
+func (h *hinter) callee(call *ast.CallExpression) ([]string, string) {
+	var sig *object.Signature
+
+	switch fn := call.Function.(type) {
+	case *ast.Identifier:
+		def := h.definition(fn)
+		if def != nil && def.Import == nil {
+			lit, ok := h.functions[def]
+			if !ok {
+				return nil, ""
+			}
+			names := make([]string, len(lit.Parameters))
+			for i, p := range lit.Parameters {
+				names[i] = p.Value
+			}
+			return names, ""
+		}
+		if builtin, ok := h.doc.LookupBuiltin("", fn.Value); ok {
+			sig = builtin.Signature()
+		}
+	case *ast.MemberExpression:
+		obj, ok := fn.Object.(*ast.Identifier)
+		if !ok || fn.Property == nil {
+			return nil, ""
+		}
+		def := h.definition(obj)
+		if def == nil {
+			if builtin, ok := h.doc.LookupBuiltin(obj.Value, fn.Property.Value); ok {
+				sig = builtin.Signature()
+			}
+		} else if h.kinds[def] == "server" {
+			if method, ok := serverMethods[fn.Property.Value].(*object.Builtin); ok {
+				sig = method.Signature
+			}
+		}
+	}
+
+	if sig == nil {
+		return nil, ""
+	}
+	params := sig.Params
+	if sig.Variadic && len(params) > 0 {
+		params = params[:len(params)-1]
+	}
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Name
+	}
+	return names, sig.Returns
+}
+
+// inferKind returns the kind of value expr evaluates to, or "" when the
+// analyzer cannot tell.
+func (h *hinter) inferKind(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteral:
+		return "int"
+	case *ast.FloatLiteral:
+		return "float"
+	case *ast.StringLiteral:
+		return "string"
+	case *ast.CharLiteral:
+		return "char"
+	case *ast.Boolean:
+		return "bool"
+	case *ast.ArrayLiteral:
+		return "array"
+	case *ast.HashLiteral:
+		return "hash"
+	case *ast.FunctionLiteral:
+		return "fn"
+	case *ast.StructLiteral:
+		if e.Name != nil {
+			return e.Name.Value
+		}
+	case *ast.PrefixExpression:
+		if e.Operator == "!" {
+			return "bool"
+		}
+		if kind := h.inferKind(e.Right); e.Operator == "-" && (kind == "int" || kind == "float") {
+			return kind
+		}
+	case *ast.InfixExpression:
+		return infixKind(e.Operator, h.inferKind(e.Left), h.inferKind(e.Right))
+	case *ast.Identifier:
+		if def := h.definition(e); def != nil {
+			return h.kinds[def]
+		}
+	case *ast.CallExpression:
+		if _, returns := h.callee(e); returns != "any" {
+			return returns
+		}
+	case *ast.MemberExpression:
+		return h.memberKind(e)
+	}
+	return ""
+}
+
+func (h *hinter) memberKind(e *ast.MemberExpression) string {
+	obj, ok := e.Object.(*ast.Identifier)
+	if !ok || e.Property == nil {
+		return ""
+	}
+
+	if def := h.definition(obj); def != nil {
+		if def.Kind != symbol.ENUM {
+			return ""
+		}
+		for _, variant := range h.doc.Index.Enums[def.Name] {
+			if variant.Name == e.Property.Value {
+				return def.Name
+			}
+		}
+		return ""
+	}
+
+	builtin, ok := h.doc.LookupBuiltin(obj.Value, e.Property.Value)
+	if !ok {
+		return ""
+	}
+	switch builtin.Value.(type) {
+	case *object.Integer:
+		return "int"
+	case *object.Float:
+		return "float"
+	case *object.String:
+		return "string"
+	case *object.Array:
+		return "array"
+	}
+	return ""
+}
+
+func infixKind(operator, left, right string) string {
+	switch operator {
+	case "==", "!=", "<", ">", "<=", ">=", "&&", "||":
+		return "bool"
+	case "+":
+		if left == "string" && right == "string" {
+			return "string"
+		}
+		fallthrough
+	case "-", "*", "/", "%", "**", "//":
+		if left == "int" && right == "int" {
+			return "int"
+		}
+		if (left == "int" || left == "float") && (right == "int" || right == "float") {
+			return "float"
+		}
+	}
+	return ""
+}
+
+// definition resolves an identifier to the definition the analyzer bound
+// it to.
+func (h *hinter) definition(ident *ast.Identifier) *Definition {
+	if ident == nil {
+		return nil
+	}
+	occ, ok := h.occurrences[keyAt(ident.Pos())].(*Occurrence)
+	if !ok {
+		return nil
+	}
+	return occ.Def
+}
+
+// sourceText renders an expression the way it would be written.
+func sourceText(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.StringLiteral:
+		return strconv.Quote(e.Value)
+	case *ast.CharLiteral:
+		return "'" + string(e.Value) + "'"
+	}
+	if ast.IsNil(expr) {
+		return ""
+	}
+	return expr.String()
+}
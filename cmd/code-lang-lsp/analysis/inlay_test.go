@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func TestInlayHints(t *testing.T) {
+	input := `import "strings";
+import "net";
+struct Point { x: 0, y: 0, label: "origin" };
+let scale = fn(value, factor) { return value * factor; };
+let factor = 2;
+let big = scale(10, factor);
+let parts = strings.split("a b", " ");
+let ok = big > 3;
+let p = Point{x: 1};
+let server = net.server();
+server.on("GET", "/x", scale);
+print(1, 2);`
+
+	doc := Analyze("file:///inlay.cl", input)
+	hints := doc.InlayHints(lsp.Range{End: lsp.Position{Line: 100}})
+
+	expected := []lsp.InlayHint{
+		{Position: lsp.Position{Line: 5, Character: 16}, Label: "value:", Kind: lsp.InlayHintKindParameter, PaddingRight: true},
+		{Position: lsp.Position{Line: 6, Character: 9}, Label: ": array", Kind: lsp.InlayHintKindType},
+		{Position: lsp.Position{Line: 6, Character: 26}, Label: "s:", Kind: lsp.InlayHintKindParameter, PaddingRight: true},
+		{Position: lsp.Position{Line: 6, Character: 33}, Label: "sep:", Kind: lsp.InlayHintKindParameter, PaddingRight: true},
+		{Position: lsp.Position{Line: 7, Character: 6}, Label: ": bool", Kind: lsp.InlayHintKindType},
+		{Position: lsp.Position{Line: 8, Character: 18}, Label: `, y: 0, label: "origin"`, PaddingLeft: true, PaddingRight: true},
+		{Position: lsp.Position{Line: 9, Character: 10}, Label: ": server", Kind: lsp.InlayHintKindType},
+		{Position: lsp.Position{Line: 10, Character: 10}, Label: "method:", Kind: lsp.InlayHintKindParameter, PaddingRight: true},
+		{Position: lsp.Position{Line: 10, Character: 17}, Label: "path:", Kind: lsp.InlayHintKindParameter, PaddingRight: true},
+		{Position: lsp.Position{Line: 10, Character: 23}, Label: "handler:", Kind: lsp.InlayHintKindParameter, PaddingRight: true},
+	}
+
+	if len(hints) != len(expected) {
+		t.Fatalf("wrong number of hints. expected=%d, got=%d: %+v", len(expected), len(hints), hints)
+	}
+	for i, want := range expected {
+		if hints[i] != want {
+			t.Errorf("hints[%d] wrong. expected=%+v, got=%+v", i, want, hints[i])
+		}
+	}
+
+	onlyLine := doc.InlayHints(lsp.Range{Start: lsp.Position{Line: 7}, End: lsp.Position{Line: 7, Character: 20}})
+	if len(onlyLine) != 1 || onlyLine[0].Label != ": bool" {
+		t.Errorf("range should limit hints to line 7. got=%+v", onlyLine)
+	}
+}
@@ -0,0 +1,79 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+)
+
+// The commands code lenses run through workspace/executeCommand. RunFile
+// takes the document URI; RunTest takes the URI and the test's name.
+const (
+	RunFileCommand = "code-lang.runFile"
+	RunTestCommand = "code-lang.runTest"
+)
+
+// Commands lists every command the server executes.
+var Commands = []string{RunFileCommand, RunTestCommand}
+
+// IsTestName reports whether a function named name is a test: tests are
+// top-level functions whose names start with test_.
+func IsTestName(name string) bool {
+	return strings.HasPrefix(name, "test_")
+}
+
+// CodeLenses returns a "Run file" lens at the top of scripts, files that
+// run code at their top level rather than only declare things, and a "Run
+// test" lens above each test function.
+func (d *Document) CodeLenses() []lsp.CodeLens {
+	lenses := []lsp.CodeLens{}
+	if d == nil || d.Program == nil {
+		return lenses
+	}
+
+	for _, stmt := range d.Program.Statements {
+		if _, ok := stmt.(*ast.ExpressionStatement); ok {
+			lenses = append(lenses, lsp.CodeLens{
+				Range:   lsp.Range{},
+				Command: &lsp.Command{Title: "Run file", Command: RunFileCommand, Arguments: []any{d.URI}},
+			})
+			break
+		}
+	}
+
+	for _, fn := range d.functions() {
+		if !IsTestName(fn.def.Name) || !d.isTopLevel(fn.stmt) {
+			continue
+		}
+		start := nodeRange(fn.stmt).Start
+		lenses = append(lenses, lsp.CodeLens{
+			Range:   lsp.Range{Start: start, End: start},
+			Command: &lsp.Command{Title: "Run test", Command: RunTestCommand, Arguments: []any{d.URI, fn.def.Name}},
+		})
+	}
+	return lenses
+}
+
+// Tests returns the names of the document's test functions in source order.
+func (d *Document) Tests() []string {
+	var names []string
+	for _, fn := range d.functions() {
+		if IsTestName(fn.def.Name) && d.isTopLevel(fn.stmt) {
+			names = append(names, fn.def.Name)
+		}
+	}
+	return names
+}
+
+func (d *Document) isTopLevel(stmt ast.Statement) bool {
+	for _, top := range d.Program.Statements {
+		if top == stmt {
+			return true
+		}
+		if export, ok := top.(*ast.ExportStatement); ok && export.Statement == stmt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package analysis
+
+import "testing"
+
+func TestCodeLenses(t *testing.T) {
+	input := `let test_sum = fn() { 1 + 1; };
+let helper = fn() {
+	let test_inner = fn() { 2; };
+	test_inner();
+};
+export let test_exported = fn() { 3; };
+print(helper());`
+	doc := Analyze("file:///lens.cl", input)
+
+	var titles []string
+	for _, lens := range doc.CodeLenses() {
+		title := lens.Command.Title
+		if lens.Command.Command == RunTestCommand {
+			title += " " + lens.Command.Arguments[1].(string)
+		}
+		titles = append(titles, title)
+	}
+	expected := []string{"Run file", "Run test test_sum", "Run test test_exported"}
+	if len(titles) != len(expected) {
+		t.Fatalf("wrong lenses. expected=%v, got=%v", expected, titles)
+	}
+	for i := range expected {
+		if titles[i] != expected[i] {
+			t.Errorf("lens %d wrong. expected=%q, got=%q", i, expected[i], titles[i])
+		}
+	}
+
+	library := Analyze("file:///lib.cl", "let add = fn(a, b) { return a + b; };")
+	if lenses := library.CodeLenses(); len(lenses) != 0 {
+		t.Errorf("a file that only declares things has nothing to run, got=%+v", lenses)
+	}
+}
@@ -0,0 +1,167 @@
+package analysis
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/symbol"
+)
+
+// DocumentLinks returns a link on the path of each import to the file it
+// loads or, for a std module, to a generated file documenting it. Imports
+// that do not resolve have no link.
+func (s *State) DocumentLinks(uri string) []lsp.DocumentLink {
+	links := []lsp.DocumentLink{}
+	doc := s.Document(uri)
+	if doc == nil || doc.Program == nil {
+		return links
+	}
+
+	sp := newSpans(doc.Text)
+	for _, imp := range doc.imports() {
+		rng, ok := sp.pathRange(imp)
+		if !ok {
+			continue
+		}
+		target := s.ResolveImport(uri, imp.Path)
+		if evaluator.IsBuiltinModule(imp.Path) {
+			target = s.stdModuleURI(imp.Path)
+		}
+		if target == "" {
+			continue
+		}
+		links = append(links, lsp.DocumentLink{Range: rng, Target: target})
+	}
+	return links
+}
+
+// ImportHover describes the module an import at pos loads with its
+// exported members: their signatures for std modules, and for user
+// modules the parameters of functions and the kind of everything else.
+// Hovering a name a `from ... import` binds is left to the name itself.
+func (s *State) ImportHover(uri string, pos lsp.Position) (string, bool) {
+	doc := s.Document(uri)
+	if doc == nil || doc.Program == nil {
+		return "", false
+	}
+	var imp *ast.ImportStatement
+	for _, candidate := range doc.imports() {
+		if contains(nodeRange(candidate), pos) {
+			imp = candidate
+		}
+	}
+	if imp == nil {
+		return "", false
+	}
+	for _, name := range imp.Names {
+		if name != nil && contains(nodeRange(name), pos) {
+			return "", false
+		}
+	}
+
+	if module, ok := evaluator.BuiltinModule(imp.Path); ok {
+		var lines []string
+		for _, name := range sortedNames(module.Members) {
+			lines = append(lines, (&Builtin{Name: imp.Path + "." + name, Value: module.Members[name]}).Detail())
+		}
+		return "**" + imp.Path + "** (std module)\n\n```code-lang\n" + strings.Join(lines, "\n") + "\n```", true
+	}
+
+	if evaluator.IsPluginModule(URIToPath(uri), imp.Path) {
+		return "**" + imp.Path + "** (plugin module)", true
+	}
+	target := s.Document(s.ResolveImport(uri, imp.Path))
+	if target == nil || target.Program == nil {
+		return "could not find module `" + imp.Path + "`", true
+	}
+	exports := symbol.ModuleExports(target.Program)
+	names := make([]string, 0, len(exports))
+	for name := range exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		line := name + " (" + exports[name].String() + ")"
+		if def := target.Exported(name); def != nil {
+			if fn, ok := target.functionOf(def); ok {
+				line = name + strings.TrimPrefix(fn.callItem().Detail, "fn")
+			}
+		}
+		lines = append(lines, line)
+	}
+	header := "**" + imp.Path + "** (" + filepath.Base(URIToPath(target.URI)) + ")"
+	if len(lines) == 0 {
+		return header + "\n\nexports nothing", true
+	}
+	return header + "\n\n```code-lang\n" + strings.Join(lines, "\n") + "\n```", true
+}
+
+// StdModuleSource is the generated document for the std module name: its
+// members as comments, since their code is Go.
+func StdModuleSource(name string) (string, bool) {
+	module, ok := evaluator.BuiltinModule(name)
+	if !ok {
+		return "", false
+	}
+	var out strings.Builder
+	out.WriteString("# " + name + " is a std module implemented in Go. This file is generated\n")
+	out.WriteString("# to document it; imports do not load it.\n")
+	for _, member := range sortedNames(module.Members) {
+		builtin := &Builtin{Name: name + "." + member, Value: module.Members[member]}
+		out.WriteString("\n# " + builtin.Detail() + "\n")
+		if sig := builtin.Signature(); sig != nil && sig.Doc != "" {
+			out.WriteString("#     " + strings.ReplaceAll(sig.Doc, "\n", "\n#     ") + "\n")
+		}
+	}
+	return out.String(), true
+}
+
+// stdModuleURI writes the generated document for the std module name
+// under StdDir, when it is missing or stale, and returns its URI.
+func (s *State) stdModuleURI(name string) string {
+	source, ok := StdModuleSource(name)
+	if !ok || s.StdDir == "" {
+		return ""
+	}
+	path := filepath.Join(s.StdDir, name+".cl")
+	if current, err := os.ReadFile(path); err != nil || !bytes.Equal(current, []byte(source)) {
+		if err := os.MkdirAll(s.StdDir, 0o755); err != nil {
+			return ""
+		}
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			return ""
+		}
+	}
+	return PathToURI(path)
+}
+
+func sortedNames(members map[string]object.Object) []string {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// imports returns the import statements of the document in source order.
+func (d *Document) imports() []*ast.ImportStatement {
+	var imports []*ast.ImportStatement
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		if imp, ok := node.(*ast.ImportStatement); ok && imp != nil {
+			imports = append(imports, imp)
+			return false
+		}
+		return true
+	})
+	return imports
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+var importWorkspace = map[string]string{
+	"lib/util.cl": "let add = fn(a, b) { return a + b; };\nconst LIMIT = 3;\nlet _hidden = 1;",
+	"main.cl":     "import \"lib/util\";\nimport \"hash\";\nimport \"lib/gone\";\nfrom \"lib/util\" import add;\n",
+}
+
+func TestDocumentLinks(t *testing.T) {
+	state, dir := openWorkspace(t, importWorkspace, "main.cl")
+	state.StdDir = t.TempDir()
+
+	links := state.DocumentLinks(PathToURI(filepath.Join(dir, "main.cl")))
+	expected := []struct {
+		line   int
+		target string
+	}{
+		{0, filepath.Join(dir, "lib", "util.cl")},
+		{1, filepath.Join(state.StdDir, "hash.cl")},
+		{3, filepath.Join(dir, "lib", "util.cl")},
+	}
+	if len(links) != len(expected) {
+		t.Fatalf("expected %d links, got=%+v", len(expected), links)
+	}
+	for i, link := range links {
+		if link.Range.Start.Line != expected[i].line || URIToPath(link.Target) != expected[i].target {
+			t.Errorf("link %d wrong. expected=%d %s, got=%d %s", i, expected[i].line, expected[i].target, link.Range.Start.Line, URIToPath(link.Target))
+		}
+	}
+	if rng := links[0].Range; rng.Start.Character != 7 || rng.End.Character != 17 {
+		t.Errorf("link does not cover the path: %+v", rng)
+	}
+
+	source, err := os.ReadFile(filepath.Join(state.StdDir, "hash.cl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(source), "# hash.keys(h: hash) -> array\n#     Returns the keys of h.\n") {
+		t.Errorf("generated hash.cl does not document hash.keys:\n%s", source)
+	}
+}
+
+func TestImportHover(t *testing.T) {
+	state, dir := openWorkspace(t, importWorkspace, "main.cl")
+	uri := PathToURI(filepath.Join(dir, "main.cl"))
+
+	tests := []struct {
+		pos      lsp.Position
+		expected string
+		ok       bool
+	}{
+		{lsp.Position{Line: 0, Character: 10}, "**lib/util** (util.cl)\n\n```code-lang\nLIMIT (constant)\nadd(a, b)\n```", true},
+		{lsp.Position{Line: 1, Character: 2}, "hash.keys(h: hash) -> array", true},
+		{lsp.Position{Line: 2, Character: 10}, "could not find module `lib/gone`", true},
+		{lsp.Position{Line: 3, Character: 7}, "**lib/util**", true},
+		{lsp.Position{Line: 3, Character: 25}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := state.ImportHover(uri, tt.pos)
+		if ok != tt.ok || !strings.Contains(got, tt.expected) {
+			t.Errorf("wrong hover at %+v. expected=%q (%t), got=%q (%t)", tt.pos, tt.expected, tt.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/lint"
+)
+
+// lintDiagnostic converts a lint problem to an LSP diagnostic, with the
+// rule as its code.
+func lintDiagnostic(d lint.Diagnostic) lsp.Diagnostic {
+	severity := 3
+	switch d.Severity {
+	case lint.Error:
+		severity = 1
+	case lint.Warning:
+		severity = 2
+	}
+	return lsp.Diagnostic{
+		Range:    lsp.Range{Start: positionOf(d.Start), End: positionOf(d.End)},
+		Severity: severity,
+		Code:     d.Rule,
+		Source:   "lint",
+		Message:  d.Message,
+	}
+}
+
+// lintActions returns the fixes lint rules offer for the problems rng
+// touches, and an action applying every fix in the document when there is
+// more than one.
+func (d *Document) lintActions(rng lsp.Range) []lsp.CodeAction {
+	var actions []lsp.CodeAction
+
+	var all []lint.Fix
+	for _, l := range d.Lint {
+		if len(l.Fixes) == 0 {
+			continue
+		}
+		all = append(all, l.Fixes[0])
+
+		diag := lintDiagnostic(l)
+		if !overlaps(diag.Range, rng) {
+			continue
+		}
+		for i, fix := range l.Fixes {
+			actions = append(actions, lsp.CodeAction{
+				Title:       fix.Title,
+				Kind:        "quickfix",
+				Diagnostics: []lsp.Diagnostic{diag},
+				IsPreferred: i == 0,
+				Edit:        d.workspaceEdit(fix.Edits),
+			})
+		}
+	}
+
+	if len(all) > 1 {
+		var edits []lint.Edit
+		for _, fix := range all {
+			edits = append(edits, fix.Edits...)
+		}
+		actions = append(actions, lsp.CodeAction{
+			Title: "Fix all lint problems",
+			Kind:  "source.fixAll",
+			Edit:  d.workspaceEdit(nonOverlapping(edits)),
+		})
+	}
+	return actions
+}
+
+func (d *Document) workspaceEdit(edits []lint.Edit) *lsp.WorkspaceEdit {
+	textEdits := make([]lsp.TextEdit, len(edits))
+	for i, edit := range edits {
+		textEdits[i] = lsp.TextEdit{
+			Range:   lsp.Range{Start: positionOf(edit.Start), End: positionOf(edit.End)},
+			NewText: edit.NewText,
+		}
+	}
+	return &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{d.URI: textEdits}}
+}
+
+// nonOverlapping drops edits that overlap an earlier one, which clients
+// reject, the way lint.Apply skips them.
+func nonOverlapping(edits []lint.Edit) []lint.Edit {
+	var out []lint.Edit
+	for _, edit := range edits {
+		clash := false
+		for _, kept := range out {
+			if edit.Start.Offset < kept.End.Offset && kept.Start.Offset < edit.End.Offset {
+				clash = true
+				break
+			}
+		}
+		if !clash {
+			out = append(out, edit)
+		}
+	}
+	return out
+}
+
+func overlaps(a, b lsp.Range) bool {
+	return !positionBefore(a.End, b.Start) && !positionBefore(b.End, a.Start)
+}
@@ -0,0 +1,229 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/std/general"
+	"github.com/walonCode/code-lang/internal/symbol"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// SemanticTokenTypes and SemanticTokenModifiers form the legend the server
+// advertises; token data refers to them by index and bit.
+var SemanticTokenTypes = []string{
+	"keyword",
+	"string",
+	"number",
+	"operator",
+	"variable",
+	"parameter",
+	"function",
+	"struct",
+	"enum",
+	"enumMember",
+	"namespace",
+	"property",
+}
+
+var SemanticTokenModifiers = []string{
+	"declaration",
+	"readonly",
+	"defaultLibrary",
+}
+
+const (
+	tokKeyword = iota
+	tokString
+	tokNumber
+	tokOperator
+	tokVariable
+	tokParameter
+	tokFunction
+	tokStruct
+	tokEnum
+	tokEnumMember
+	tokNamespace
+	tokProperty
+)
+
+const (
+	modDeclaration = 1 << iota
+	modReadonly
+	modDefaultLibrary
+)
+
+var builtinFunctions = general.Module().Members
+
+type semanticToken struct {
+	line, char, length int
+	kind, modifiers    int
+}
+
+// SemanticTokens encodes the document's tokens in the LSP relative format,
+// counting columns in bytes. When rng is not nil only tokens on the lines
+// it spans are returned.
+func (d *Document) SemanticTokens(rng *lsp.Range) []int {
+	data := []int{}
+	if d == nil || d.Index == nil {
+		return data
+	}
+
+	lines := strings.Split(d.Text, "\n")
+	occurrences := d.occurrencesByPosition()
+
+	var tokens []semanticToken
+	l := lexer.New(d.Text)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		st, ok := d.classify(tok, occurrences)
+		if !ok || tok.Line < 1 || tok.Line > len(lines) {
+			continue
+		}
+
+		if tok.End.Line != tok.Line {
+			// Multi-line strings cannot be expressed as one token.
+			continue
+		}
+
+		st.line = tok.Line - 1
+		st.char = tok.Column - 1
+		st.length = tok.End.Column - tok.Column
+		if rng != nil && (st.line < rng.Start.Line || st.line > rng.End.Line) {
+			continue
+		}
+		tokens = append(tokens, st)
+	}
+
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].line != tokens[j].line {
+			return tokens[i].line < tokens[j].line
+		}
+		return tokens[i].char < tokens[j].char
+	})
+
+	prevLine, prevChar := 0, 0
+	for _, t := range tokens {
+		deltaChar := t.char
+		if t.line == prevLine {
+			deltaChar = t.char - prevChar
+		}
+		data = append(data, t.line-prevLine, deltaChar, t.length, t.kind, t.modifiers)
+		prevLine, prevChar = t.line, t.char
+	}
+
+	return data
+}
+
+type occurrenceKey struct {
+	line, char int
+}
+
+// keyAt returns the key of the identifier starting at a lexer position.
+func keyAt(p token.Position) occurrenceKey {
+	pos := positionOf(p)
+	return occurrenceKey{pos.Line, pos.Character}
+}
+
+// occurrencesByPosition indexes everything the analyzer knows about
+// identifiers by their start position.
+func (d *Document) occurrencesByPosition() map[occurrenceKey]any {
+	byPos := map[occurrenceKey]any{}
+	for _, m := range d.Index.MemberProps {
+		byPos[occurrenceKey{m.Range.Start.Line, m.Range.Start.Character}] = m
+	}
+	for _, m := range d.Index.ModuleMembers {
+		byPos[occurrenceKey{m.Range.Start.Line, m.Range.Start.Character}] = m
+	}
+	for _, occ := range d.Index.Occurrences {
+		byPos[occurrenceKey{occ.Range.Start.Line, occ.Range.Start.Character}] = occ
+	}
+	return byPos
+}
+
+func (d *Document) classify(tok token.Token, occurrences map[occurrenceKey]any) (semanticToken, bool) {
+	switch tok.Type {
+	case token.ILLEGAL:
+		return semanticToken{}, false
+	case token.STRING, token.CHAR:
+		return semanticToken{kind: tokString}, true
+	case token.INT, token.FLOAT:
+		return semanticToken{kind: tokNumber}, true
+	case token.IDENT:
+		return d.classifyIdentifier(tok, occurrences), true
+	case token.LPAREN, token.RPAREN, token.LBRACE, token.RBRACE, token.LBRACKET, token.RBRACKET,
+		token.COMMA, token.SEMICOLON, token.COLON, token.DOT:
+		return semanticToken{}, false
+	}
+
+	if token.LookUpIdent(tok.Literal) == tok.Type {
+		return semanticToken{kind: tokKeyword}, true
+	}
+	return semanticToken{kind: tokOperator}, true
+}
+
+func (d *Document) classifyIdentifier(tok token.Token, occurrences map[occurrenceKey]any) semanticToken {
+	switch occ := occurrences[keyAt(tok.Pos())].(type) {
+	case *ModuleMember:
+		st := semanticToken{kind: tokFunction}
+		if evaluator.IsBuiltinModule(occ.Path) {
+			st.modifiers |= modDefaultLibrary
+		}
+		return st
+	case *Occurrence:
+		if occ.Def != nil && occ.Def.Import != nil && evaluator.IsBuiltinModule(occ.Def.Import.Path) {
+			st := semanticToken{kind: tokFunction, modifiers: modDefaultLibrary}
+			if occ.IsDefinition {
+				st.modifiers |= modDeclaration
+			}
+			return st
+		}
+		if occ.Def != nil {
+			st := semanticToken{kind: semanticKind(occ.Def.Kind)}
+			if occ.IsDefinition {
+				st.modifiers |= modDeclaration
+			}
+			if occ.Def.Kind == symbol.CONSTANT {
+				st.modifiers |= modReadonly
+			}
+			return st
+		}
+		if occ.Kind == symbol.STRUCT_FIELD {
+			return semanticToken{kind: tokProperty}
+		}
+	}
+
+	if path, ok := d.Index.Imports[tok.Literal]; ok {
+		st := semanticToken{kind: tokNamespace}
+		if evaluator.IsBuiltinModule(path) {
+			st.modifiers |= modDefaultLibrary
+		}
+		return st
+	}
+	if _, ok := builtinFunctions[tok.Literal]; ok {
+		return semanticToken{kind: tokFunction, modifiers: modDefaultLibrary}
+	}
+	return semanticToken{kind: tokVariable}
+}
+
+func semanticKind(kind symbol.SymbolKind) int {
+	switch kind {
+	case symbol.FUNCTION:
+		return tokFunction
+	case symbol.PARAMETER:
+		return tokParameter
+	case symbol.STRUCT:
+		return tokStruct
+	case symbol.ENUM:
+		return tokEnum
+	case symbol.ENUM_VARIANT:
+		return tokEnumMember
+	case symbol.MODULE:
+		return tokNamespace
+	case symbol.STRUCT_FIELD:
+		return tokProperty
+	}
+	return tokVariable
+}
@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+type decodedToken struct {
+	line, char, length int
+	kind, modifiers    int
+}
+
+func decodeTokens(data []int) []decodedToken {
+	var tokens []decodedToken
+	line, char := 0, 0
+	for i := 0; i+4 < len(data); i += 5 {
+		if data[i] != 0 {
+			char = 0
+		}
+		line += data[i]
+		char += data[i+1]
+		tokens = append(tokens, decodedToken{line, char, data[i+2], data[i+3], data[i+4]})
+	}
+	return tokens
+}
+
+func TestSemanticTokens(t *testing.T) {
+	input := `import "math";
+const limit = 10;
+let square = fn(x) { return x * x; };
+print(math.sqrt(square(limit)));`
+
+	doc := Analyze("file:///semantic.cl", input)
+	tokens := decodeTokens(doc.SemanticTokens(nil))
+
+	tests := []struct {
+		line, char, length int
+		kind, modifiers    int
+	}{
+		{0, 0, 6, tokKeyword, 0},
+		{0, 7, 6, tokString, 0},
+		{1, 6, 5, tokVariable, modDeclaration | modReadonly},
+		{1, 14, 2, tokNumber, 0},
+		{2, 4, 6, tokFunction, modDeclaration},
+		{2, 16, 1, tokParameter, modDeclaration},
+		{2, 30, 1, tokOperator, 0},
+		{3, 0, 5, tokFunction, modDefaultLibrary},
+		{3, 6, 4, tokNamespace, modDefaultLibrary},
+		{3, 11, 4, tokFunction, modDefaultLibrary},
+		{3, 16, 6, tokFunction, 0},
+		{3, 23, 5, tokVariable, modReadonly},
+	}
+
+	for _, tt := range tests {
+		found := false
+		for _, tok := range tokens {
+			if tok.line == tt.line && tok.char == tt.char {
+				found = true
+				if tok.length != tt.length || tok.kind != tt.kind || tok.modifiers != tt.modifiers {
+					t.Errorf("wrong token at %d:%d. expected=%+v, got=%+v", tt.line, tt.char, tt, tok)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no token at %d:%d", tt.line, tt.char)
+		}
+	}
+}
+
+func TestSemanticTokensRange(t *testing.T) {
+	input := "let a = 1;\nlet b = \"é\";\nlet c = 3;"
+	doc := Analyze("file:///range.cl", input)
+
+	rng := lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 1, Character: 12}}
+	tokens := decodeTokens(doc.SemanticTokens(&rng))
+	if len(tokens) != 4 {
+		t.Fatalf("expected 4 tokens on line 1, got=%+v", tokens)
+	}
+	for _, tok := range tokens {
+		if tok.line != 1 {
+			t.Errorf("token outside range: %+v", tok)
+		}
+	}
+	if str := tokens[3]; str.kind != tokString || str.char != 8 || str.length != 4 {
+		t.Errorf("string token should be measured in bytes. got=%+v", str)
+	}
+	utf16 := decodeTokens(NewConverter(input, UTF16).SemanticTokens(doc.SemanticTokens(&rng)))
+	if str := utf16[3]; str.char != 8 || str.length != 3 {
+		t.Errorf("string token should be measured in UTF-16 units. got=%+v", str)
+	}
+}
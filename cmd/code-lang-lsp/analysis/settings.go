@@ -0,0 +1,144 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/lint"
+)
+
+// SettingsSection is the section of the client's configuration the server
+// reads, e.g. "codeLang.inlayHints" in VS Code's settings.json.
+const SettingsSection = "codeLang"
+
+// Settings is the server's configuration as the client sends it:
+//
+//	"codeLang": {
+//	    "log": {"file": "/tmp/code-lang.log", "level": "debug"},
+//	    "lint": {"rules": {"no-magic-numbers": "off"}},
+//	    "format": {"tabSize": 2, "insertSpaces": true},
+//	    "modulePaths": ["../shared"],
+//	    "inlayHints": false
+//	}
+//
+// Every field is optional; what is left out keeps its default.
+type Settings struct {
+	Log LogSettings `json:"log"`
+	// Lint overrides, rule by rule, the lint config of each document's
+	// code-lang.json.
+	Lint json.RawMessage `json:"lint,omitempty"`
+	// Format sets how inserted code is indented.
+	Format FormatSettings `json:"format"`
+	// ModulePaths are searched for imports after CODELANG_PATH. Relative
+	// paths are relative to the workspace folder, as is Log.File.
+	ModulePaths []string `json:"modulePaths,omitempty"`
+	// InlayHints turns inlay hints off when false.
+	InlayHints *bool `json:"inlayHints,omitempty"`
+}
+
+type LogSettings struct {
+	// File is where the server logs; it keeps its log when empty.
+	File string `json:"file,omitempty"`
+	// Level is one of off, error, info and debug.
+	Level string `json:"level,omitempty"`
+}
+
+type FormatSettings struct {
+	TabSize      int  `json:"tabSize,omitempty"`
+	InsertSpaces bool `json:"insertSpaces,omitempty"`
+}
+
+// Indent replaces the tabs that indent the lines of text with spaces when
+// the settings ask for them.
+func (f FormatSettings) Indent(text string) string {
+	if !f.InsertSpaces {
+		return text
+	}
+	width := f.TabSize
+	if width <= 0 {
+		width = 4
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, "\t")
+		lines[i] = strings.Repeat(" ", width*(len(line)-len(trimmed))) + trimmed
+	}
+	return strings.Join(lines, "\n")
+}
+
+// InlayHintsEnabled reports whether inlay hints are shown, which they are
+// unless turned off.
+func (s Settings) InlayHintsEnabled() bool {
+	return s.InlayHints == nil || *s.InlayHints
+}
+
+// Settings returns the settings in effect.
+func (s *State) Settings() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings
+}
+
+// ResolvePath makes a path of the settings absolute. Relative paths are
+// relative to the workspace folder, never to where the server was started,
+// so they need one.
+func (s *State) ResolvePath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	if s.Root == "" {
+		return "", fmt.Errorf("relative path %q needs a workspace folder", path)
+	}
+	return filepath.Join(URIToPath(s.Root), path), nil
+}
+
+// Configure applies new settings and re-analyzes the documents they
+// affect. It returns the open documents, whose diagnostics may have
+// changed. Invalid lint settings or module paths are reported and leave
+// the previous settings in place.
+func (s *State) Configure(settings Settings) ([]*Document, error) {
+	var config *lint.Config
+	if len(settings.Lint) != 0 && string(settings.Lint) != "null" {
+		parsed, err := lint.ParseConfig(settings.Lint)
+		if err != nil {
+			return nil, err
+		}
+		config = parsed
+	}
+
+	var paths []string
+	for _, dir := range settings.ModulePaths {
+		path, err := s.ResolvePath(dir)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	s.mu.Lock()
+	s.modulePaths = paths
+	s.settings = settings
+	s.lint = config
+	// Imports may resolve differently and lint rules differ: analyze
+	// everything again.
+	s.files = make(map[string]*diskFile)
+	s.scanned = false
+	var open []string
+	for uri := range s.texts {
+		s.versions[uri]++
+		open = append(open, uri)
+	}
+	s.mu.Unlock()
+	sort.Strings(open)
+
+	var docs []*Document
+	for _, uri := range open {
+		if doc := s.analyze(uri); doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
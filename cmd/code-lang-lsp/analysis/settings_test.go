@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/walonCode/code-lang/internal/evaluator"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Setenv(evaluator.SearchPathEnv, "")
+	files := map[string]string{
+		"shared/util.cl": "let twice = fn(x) { return x * 2; };",
+		"app/main.cl":    "import \"util\";\nlet BadName = util.twice(1);\nprint(BadName);",
+	}
+	state, dir := openWorkspace(t, files, "app/main.cl")
+	uri := PathToURI(filepath.Join(dir, "app", "main.cl"))
+
+	sources := func() map[string]bool {
+		seen := map[string]bool{}
+		for _, diag := range state.Diagnostics(state.GetDocument(uri)) {
+			seen[diag.Source+" "+diag.Message] = true
+		}
+		return seen
+	}
+	if !sources()[`import could not find module "util"`] {
+		t.Fatalf("util resolved before its directory was configured: %v", sources())
+	}
+
+	var settings Settings
+	if err := json.Unmarshal([]byte(`{"modulePaths": ["shared"], "lint": {"rules": {"naming": "off"}}, "inlayHints": false}`), &settings); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := state.Configure(settings)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(docs) != 1 || docs[0].URI != uri {
+		t.Errorf("expected main.cl to be analyzed again, got=%d documents", len(docs))
+	}
+	if path := os.Getenv(evaluator.SearchPathEnv); path != "" {
+		t.Errorf("module paths leaked into %s: %q", evaluator.SearchPathEnv, path)
+	}
+	if len(state.Diagnostics(state.GetDocument(uri))) != 0 {
+		t.Errorf("expected no diagnostics with util found and naming off, got=%v", sources())
+	}
+	if state.Settings().InlayHintsEnabled() {
+		t.Errorf("inlay hints are still enabled")
+	}
+
+	settings.Lint = json.RawMessage(`{"rules": {"no-such-rule": "error"}}`)
+	if _, err := state.Configure(settings); err == nil {
+		t.Errorf("expected an error for an unknown lint rule")
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	state := NewState()
+	if _, err := state.ResolvePath("code-lang.log"); err == nil {
+		t.Errorf("expected a relative path without a workspace folder to be rejected")
+	}
+
+	dir := t.TempDir()
+	state.Root = PathToURI(dir)
+	if got, _ := state.ResolvePath("logs/code-lang.log"); got != filepath.Join(dir, "logs", "code-lang.log") {
+		t.Errorf("relative path not resolved against the workspace folder. got=%q", got)
+	}
+	abs := filepath.Join(dir, "elsewhere", "..", "code-lang.log")
+	if got, _ := state.ResolvePath(abs); got != filepath.Join(dir, "code-lang.log") {
+		t.Errorf("wrong absolute path. got=%q", got)
+	}
+}
+
+func TestFormatIndent(t *testing.T) {
+	tests := []struct {
+		format   FormatSettings
+		expected string
+	}{
+		{FormatSettings{}, "if (x) {\n\t\ty\n}"},
+		{FormatSettings{InsertSpaces: true}, "if (x) {\n        y\n}"},
+		{FormatSettings{InsertSpaces: true, TabSize: 2}, "if (x) {\n    y\n}"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.Indent("if (x) {\n\t\ty\n}"); got != tt.expected {
+			t.Errorf("wrong indent for %+v. expected=%q, got=%q", tt.format, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+// snippet is a completion whose insert text has tab stops: $1, $2, ...
+// in order, ${1:default} with a placeholder, and $0 where the cursor ends.
+type snippet struct {
+	label, detail, body string
+}
+
+var snippets = []snippet{
+	{"fn", "function literal", "fn(${1:params}) {\n\t$0\n}"},
+	{"for", "for loop", "for (let ${1:i} = 0; ${1:i} < ${2:n}; ${1:i} += 1) {\n\t$0\n};"},
+	{"while", "while loop", "while (${1:condition}) {\n\t$0\n};"},
+	{"struct", "struct declaration", "struct ${1:Name} {\n\t${2:field}: ${3:null},\n}"},
+	{"if", "if block", "if (${1:condition}) {\n\t$0\n}"},
+	{"ifelse", "if/else block", "if (${1:condition}) {\n\t$2\n} else {\n\t$0\n}"},
+}
+
+// snippetsAfterIf continue the if block they follow.
+var snippetsAfterIf = []snippet{
+	{"elseif", "elseif block", "elseif (${1:condition}) {\n\t$0\n}"},
+	{"else", "else block", "else {\n\t$0\n}"},
+}
+
+// serverSnippet sets up a net.server with one route. NET stands for the
+// name the document imports net under.
+var serverSnippet = snippet{
+	"server", "net.server with a route",
+	"let ${1:server} = NET.server();\n${1:server}.on(\"${2:GET}\", \"${3:/}\", fn() {\n\t$0\n});\n${1:server}.listen(${4:8080});",
+}
+
+// SnippetCompletions returns the snippets ctx allows: the control flow and
+// declaration templates, else and elseif right after an if block, and a
+// net.server route when the document imports net.
+func (d *Document) SnippetCompletions(ctx CompletionContext) []lsp.CompletionItem {
+	items := []lsp.CompletionItem{}
+	add := func(s snippet) {
+		items = append(items, lsp.CompletionItem{
+			Label:            s.label,
+			Kind:             completionSnippet,
+			Detail:           s.detail,
+			InsertText:       s.body,
+			InsertTextFormat: lsp.InsertTextFormatSnippet,
+		})
+	}
+
+	for _, s := range snippets {
+		add(s)
+	}
+	if ctx.AfterIf {
+		for _, s := range snippetsAfterIf {
+			add(s)
+		}
+	}
+	if d != nil && d.Index != nil {
+		var names []string
+		for name, path := range d.Index.Imports {
+			if path == "net" {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			s := serverSnippet
+			s.body = strings.ReplaceAll(s.body, "NET", names[0])
+			add(s)
+		}
+	}
+	return items
+}
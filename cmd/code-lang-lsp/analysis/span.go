@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// spanToken is a token with the byte offsets it covers.
+type spanToken struct {
+	typ        token.TokenType
+	start, end int
+}
+
+// spans converts the byte offsets the lexer and parser record into LSP
+// positions, and keeps the token stream for what lies between tokens.
+type spans struct {
+	text   string
+	starts []int
+	tokens []spanToken
+}
+
+func newSpans(text string) *spans {
+	s := &spans{text: text, starts: lineStarts(text)}
+
+	l := lexer.New(text)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		s.tokens = append(s.tokens, spanToken{typ: tok.Type, start: tok.Offset, end: tok.End.Offset})
+	}
+
+	return s
+}
+
+// tokenBefore returns the last token that ends at or before offset.
+func (s *spans) tokenBefore(offset int) (spanToken, bool) {
+	i := sort.Search(len(s.tokens), func(i int) bool { return s.tokens[i].end > offset })
+	if i == 0 {
+		return spanToken{}, false
+	}
+	return s.tokens[i-1], true
+}
+
+// rangeOf returns the LSP range node covers.
+func (s *spans) rangeOf(node ast.Node) (lsp.Range, bool) {
+	start, end, ok := s.offsets(node)
+	if !ok {
+		return lsp.Range{}, false
+	}
+	return lsp.Range{Start: s.position(start), End: s.position(end)}, true
+}
+
+// offsets returns the byte offsets node begins and ends at. Nodes the
+// parser gave up on have no span.
+func (s *spans) offsets(node ast.Node) (int, int, bool) {
+	if ast.IsNil(node) {
+		return 0, 0, false
+	}
+	if _, ok := node.(*ast.Program); ok {
+		return 0, len(s.text), true
+	}
+	start, end := node.Pos().Offset, node.End().Offset
+	if node.End().Line == 0 || end < start || end > len(s.text) {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+func (s *spans) position(offset int) lsp.Position {
+	return positionAt(s.text, s.starts, offset)
+}
+
+// pathRange returns the range of the quoted path of an import.
+func (s *spans) pathRange(imp *ast.ImportStatement) (lsp.Range, bool) {
+	start, end, ok := s.offsets(imp)
+	if !ok {
+		return lsp.Range{}, false
+	}
+	for _, tok := range s.tokens {
+		if tok.start >= start && tok.end <= end && tok.typ == token.STRING {
+			return lsp.Range{Start: s.position(tok.start), End: s.position(tok.end)}, true
+		}
+	}
+	return lsp.Range{}, false
+}
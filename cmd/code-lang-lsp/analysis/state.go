@@ -1,27 +1,359 @@
 package analysis
 
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/lint"
+)
+
+// State is safe for concurrent use: analysis of changed documents runs on
+// timer goroutines while requests are served.
 type State struct {
-	Documents map[string]*Document
+	// Root is the workspace folder. Its .cl files are indexed on demand so
+	// references from files that are not open are found too.
+	Root string
+	// Encoding is the unit the client counts columns in, negotiated at
+	// initialization.
+	Encoding PositionEncoding
+	// StdDir is where the documents that std module imports link to are
+	// generated.
+	StdDir string
+
+	mu sync.Mutex
+	// documents holds the latest analysis of the documents open in the
+	// editor; texts holds their current text, which may be newer.
+	documents map[string]*Document
+	texts     map[string]string
+	versions  map[string]int
+	analyzed  map[string]int
+	timers    map[string]*time.Timer
+	// scheduled counts the analyses whose timer is set or running; closed
+	// is set by Close, after which none are scheduled.
+	scheduled sync.WaitGroup
+	closed    bool
+
+	files   map[string]*diskFile
+	imports map[string][]string
+	scanned bool
+
+	settings Settings
+	// lint overrides the lint config of code-lang.json files.
+	lint *lint.Config
+	// modulePaths are the absolute module paths of the settings.
+	modulePaths []string
+}
+
+// diskFile is a document that is not open, analyzed from its file on disk.
+type diskFile struct {
+	doc     *Document
+	modTime time.Time
+}
+
+func NewState() *State {
+	return &State{
+		Encoding:  UTF16,
+		StdDir:    filepath.Join(os.TempDir(), "code-lang-std"),
+		documents: make(map[string]*Document),
+		texts:     make(map[string]string),
+		versions:  make(map[string]int),
+		analyzed:  make(map[string]int),
+		timers:    make(map[string]*time.Timer),
+		files:     make(map[string]*diskFile),
+		imports:   make(map[string][]string),
+	}
+}
+
+// OpenDocument stores and analyzes a newly opened document.
+func (s *State) OpenDocument(uri, text string) {
+	s.mu.Lock()
+	s.forgetDiskFile(uri)
+	s.texts[uri] = text
+	s.versions[uri]++
+	s.mu.Unlock()
+
+	s.analyze(uri)
+}
+
+// UpdateDocument replaces the whole text of a document and analyzes it.
+func (s *State) UpdateDocument(uri, text string) {
+	s.ChangeDocument(uri, []lsp.TextDocumentContentChange{{Text: text}})
+	s.analyze(uri)
+}
+
+// ChangeDocument applies content changes to the stored text without
+// analyzing it; see ScheduleAnalysis.
+func (s *State) ChangeDocument(uri string, changes []lsp.TextDocumentContentChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text := s.texts[uri]
+	for _, change := range changes {
+		text = ApplyChange(text, change, s.Encoding)
+	}
+	s.texts[uri] = text
+	s.versions[uri]++
+}
+
+// ScheduleAnalysis analyzes uri once no change has arrived for delay, then
+// calls done with the result. Each call restarts the document's timer.
+func (s *State) ScheduleAnalysis(uri string, delay time.Duration, done func(*Document)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	if t, ok := s.timers[uri]; ok && t.Stop() {
+		s.scheduled.Done()
+	}
+	s.scheduled.Add(1)
+	s.timers[uri] = time.AfterFunc(delay, func() {
+		defer s.scheduled.Done()
+		doc := s.analyze(uri)
+
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if doc != nil && done != nil && !closed {
+			done(doc)
+		}
+	})
+}
+
+// Close cancels the scheduled analyses and waits for those already
+// running, so none calls back once it returns.
+func (s *State) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for uri, t := range s.timers {
+		if t.Stop() {
+			s.scheduled.Done()
+		}
+		delete(s.timers, uri)
+	}
+	s.mu.Unlock()
+
+	s.scheduled.Wait()
+}
+
+// analyze brings the analysis of an open document up to date with its
+// text and returns it. Results of an older text never replace newer ones.
+func (s *State) analyze(uri string) *Document {
+	s.mu.Lock()
+	text, ok := s.texts[uri]
+	version := s.versions[uri]
+	if !ok || s.analyzed[uri] == version {
+		doc := s.documents[uri]
+		s.mu.Unlock()
+		return doc
+	}
+	s.mu.Unlock()
+
+	config, paths := s.configured()
+	doc := analyzeWith(uri, text, config, paths)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, open := s.texts[uri]; open && version > s.analyzed[uri] {
+		s.documents[uri] = doc
+		s.analyzed[uri] = version
+		s.track(doc)
+	}
+	return s.documents[uri]
+}
+
+// configured returns the lint overrides and module paths of the settings.
+func (s *State) configured() (*lint.Config, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lint, s.modulePaths
+}
+
+// ModulePaths returns the directories the settings add to the search for
+// imports.
+func (s *State) ModulePaths() []string {
+	_, paths := s.configured()
+	return paths
+}
+
+// Text returns the current text of an open document.
+func (s *State) Text(uri string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	text, ok := s.texts[uri]
+	return text, ok
+}
+
+// CloseDocument forgets the editor's copy of uri. The file on disk stays
+// part of the import graph.
+func (s *State) CloseDocument(uri string) {
+	s.mu.Lock()
+	if t, ok := s.timers[uri]; ok {
+		if t.Stop() {
+			s.scheduled.Done()
+		}
+		delete(s.timers, uri)
+	}
+	delete(s.documents, uri)
+	delete(s.texts, uri)
+	delete(s.versions, uri)
+	delete(s.analyzed, uri)
+	delete(s.imports, uri)
+	s.mu.Unlock()
+
+	s.Document(uri)
+}
+
+// GetDocument returns the analysis of an open document, running any
+// pending analysis first so the result matches the latest text.
+func (s *State) GetDocument(uri string) *Document {
+	return s.analyze(uri)
+}
+
+// Document returns the open document for uri, or analyzes the file on disk
+// when it is not open. It returns nil if the file cannot be read.
+func (s *State) Document(uri string) *Document {
+	if openURI, ok := s.openURI(uri); ok {
+		return s.analyze(openURI)
+	}
+
+	path := URIToPath(uri)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	f, ok := s.files[uri]
+	s.mu.Unlock()
+	if ok && f.modTime.Equal(info.ModTime()) {
+		return f.doc
+	}
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	config, paths := s.configured()
+	doc := analyzeWith(uri, string(text), config, paths)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[uri] = &diskFile{doc: doc, modTime: info.ModTime()}
+	s.track(doc)
+	return doc
+}
+
+// FileChanged brings the import graph up to date after a file was
+// created, changed or deleted outside the editor. Open documents keep the
+// editor's text.
+func (s *State) FileChanged(uri string, deleted bool) {
+	if _, open := s.openURI(uri); open {
+		return
+	}
+	if deleted {
+		s.mu.Lock()
+		s.forgetDiskFile(uri)
+		s.mu.Unlock()
+		return
+	}
+	s.Document(uri)
 }
 
-func NewState()State{
-	return State{
-		make(map[string]*Document),
+// forgetDiskFile drops the on-disk analysis of a file that is now open.
+// The caller holds s.mu.
+func (s *State) forgetDiskFile(uri string) {
+	path := URIToPath(uri)
+	for diskURI := range s.files {
+		if URIToPath(diskURI) == path {
+			delete(s.files, diskURI)
+			delete(s.imports, diskURI)
+		}
 	}
 }
 
-func(s *State)OpenDocument(uri, text string){
-	s.Documents[uri] = Analyze(uri, text)
+// openURI finds the URI under which a file is open, comparing file paths
+// since clients and PathToURI may encode the same file differently.
+func (s *State) openURI(uri string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.texts[uri]; ok {
+		return uri, true
+	}
+	path := URIToPath(uri)
+	for openURI := range s.texts {
+		if URIToPath(openURI) == path {
+			return openURI, true
+		}
+	}
+	return "", false
 }
 
-func (s *State)UpdateDocument(uri, text string){
-	s.Documents[uri] = Analyze(uri, text)
+// ResolveImport returns the URI of the file an import of path made from
+// uri loads, or "" for std modules and unresolved imports.
+func (s *State) ResolveImport(uri, path string) string {
+	return resolveImport(uri, path, s.ModulePaths())
 }
 
-func (s *State)CloseDocument(uri string){
-	delete(s.Documents, uri)
+func resolveImport(uri, path string, modulePaths []string) string {
+	file := evaluator.ResolveModule(URIToPath(uri), path, modulePaths...)
+	if file == "" {
+		return ""
+	}
+	return PathToURI(file)
 }
 
-func (s *State)GetDocument(uri string)*Document{
-	return s.Documents[uri]
+// Imports returns the URIs of the files uri imports.
+func (s *State) Imports(uri string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.imports[uri]
+}
+
+// Dependents returns the URIs of every known file importing uri, scanning
+// the workspace first so unopened files are included.
+func (s *State) Dependents(uri string) []string {
+	s.scanWorkspace()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := URIToPath(uri)
+	var dependents []string
+	for from, targets := range s.imports {
+		for _, target := range targets {
+			if URIToPath(target) == path {
+				dependents = append(dependents, from)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// track records which files doc imports. The caller holds s.mu.
+func (s *State) track(doc *Document) {
+	if doc == nil || doc.Index == nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	var targets []string
+	for _, path := range doc.Index.ImportPaths() {
+		target := resolveImport(doc.URI, path, s.modulePaths)
+		if target != "" && !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	s.imports[doc.URI] = targets
 }
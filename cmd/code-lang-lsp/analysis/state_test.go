@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func TestScheduleAnalysisDebounces(t *testing.T) {
+	state := NewState()
+	uri := "file:///tmp/debounce.cl"
+	state.OpenDocument(uri, "let x = 1;")
+
+	done := make(chan *Document, 4)
+	for _, text := range []string{"let x = 12;", "let x = 123;", "let x = y;"} {
+		state.ChangeDocument(uri, []lsp.TextDocumentContentChange{{Text: text}})
+		state.ScheduleAnalysis(uri, 20*time.Millisecond, func(doc *Document) { done <- doc })
+	}
+
+	select {
+	case doc := <-done:
+		if doc.Text != "let x = y;" {
+			t.Errorf("analyzed stale text %q", doc.Text)
+		}
+		if len(doc.SymbolErrors) == 0 {
+			t.Errorf("expected an undefined identifier error")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("analysis never ran")
+	}
+
+	select {
+	case doc := <-done:
+		t.Errorf("expected a single analysis, got another for %q", doc.Text)
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestGetDocumentRunsPendingAnalysis(t *testing.T) {
+	state := NewState()
+	uri := "file:///tmp/pending.cl"
+	state.OpenDocument(uri, "let x = 1;")
+
+	state.ChangeDocument(uri, []lsp.TextDocumentContentChange{change(0, 4, 0, 5, "total")})
+	state.ScheduleAnalysis(uri, time.Hour, nil)
+
+	doc := state.GetDocument(uri)
+	if doc.Text != "let total = 1;" {
+		t.Fatalf("expected the pending change to be analyzed, got %q", doc.Text)
+	}
+	if len(doc.Index.DefsByName["total"]) != 1 {
+		t.Errorf("expected total to be defined")
+	}
+
+	state.CloseDocument(uri)
+	if state.GetDocument(uri) != nil {
+		t.Errorf("closed document should be gone")
+	}
+}
+
+func TestCloseCancelsScheduledAnalysis(t *testing.T) {
+	state := NewState()
+	uri := "file:///tmp/close.cl"
+	state.OpenDocument(uri, "let x = 1;")
+
+	done := make(chan *Document, 2)
+	state.ChangeDocument(uri, []lsp.TextDocumentContentChange{{Text: "let x = 2;"}})
+	state.ScheduleAnalysis(uri, 20*time.Millisecond, func(doc *Document) { done <- doc })
+	state.Close()
+	state.ScheduleAnalysis(uri, time.Millisecond, func(doc *Document) { done <- doc })
+
+	select {
+	case doc := <-done:
+		t.Errorf("analysis of %q called back after Close", doc.Text)
+	case <-time.After(60 * time.Millisecond):
+	}
+}
@@ -0,0 +1,205 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// FoldingRanges returns the foldable regions of the document: blocks,
+// hash, array and struct literals, struct and enum bodies, call arguments
+// spanning lines, runs of imports and multi-line comments.
+func (d *Document) FoldingRanges() []lsp.FoldingRange {
+	ranges := []lsp.FoldingRange{}
+	if d == nil || d.Program == nil {
+		return ranges
+	}
+
+	s := newSpans(d.Text)
+	seen := map[int]bool{}
+	add := func(startLine, endLine int, kind string) {
+		if endLine <= startLine || seen[startLine] {
+			return
+		}
+		seen[startLine] = true
+		ranges = append(ranges, lsp.FoldingRange{StartLine: startLine, EndLine: endLine, Kind: kind})
+	}
+
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.BlockStatement, *ast.HashLiteral, *ast.ArrayLiteral, *ast.StructLiteral,
+			*ast.StructStatement, *ast.EnumStatement, *ast.CallExpression:
+			_, end, ok := s.offsets(node)
+			if !ok || end == 0 {
+				return true
+			}
+			// The closing delimiter stays visible when the region is folded.
+			add(node.Line()-1, s.position(end-1).Line-1, "")
+		}
+		return true
+	})
+
+	var imports []ast.Statement
+	flush := func() {
+		if len(imports) > 1 {
+			last := imports[len(imports)-1]
+			add(imports[0].Line()-1, last.Line()-1, lsp.FoldingRangeKindImports)
+		}
+		imports = nil
+	}
+	for _, stmt := range d.Program.Statements {
+		if ast.IsNil(stmt) {
+			continue
+		}
+		if _, ok := stmt.(*ast.ImportStatement); ok {
+			imports = append(imports, stmt)
+			continue
+		}
+		flush()
+	}
+	flush()
+
+	for _, c := range s.comments() {
+		add(s.position(c[0]).Line, s.position(c[1]).Line, lsp.FoldingRangeKindComment)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].StartLine < ranges[j].StartLine })
+	return ranges
+}
+
+// comments returns the byte offsets of each comment in the source, with
+// consecutive `#` lines merged into one.
+func (s *spans) comments() [][2]int {
+	var out [][2]int
+	prev := 0
+	gaps := append(append([]spanToken{}, s.tokens...), spanToken{start: len(s.text)})
+	for _, tok := range gaps {
+		gap := s.text[prev:tok.start]
+		for i := 0; i < len(gap); i++ {
+			switch {
+			case gap[i] == '#':
+				end := strings.IndexByte(gap[i:], '\n')
+				if end == -1 {
+					end = len(gap) - i
+				}
+				start := prev + i
+				if n := len(out); n > 0 && s.text[out[n-1][0]] == '#' &&
+					strings.TrimSpace(s.text[out[n-1][1]:start]) == "" &&
+					strings.Count(s.text[out[n-1][1]:start], "\n") == 1 {
+					out[n-1][1] = start + end
+				} else {
+					out = append(out, [2]int{start, start + end})
+				}
+				i += end
+			case strings.HasPrefix(gap[i:], "/*"):
+				end := strings.Index(gap[i+2:], "*/")
+				if end == -1 {
+					end = len(gap) - i
+				} else {
+					end += 4
+				}
+				out = append(out, [2]int{prev + i, prev + i + end})
+				i += end - 1
+			}
+		}
+		prev = tok.end
+	}
+	return out
+}
+
+// SelectionRanges returns, for each position, the ranges of the syntax
+// nodes around it from the innermost out to the whole document.
+func (d *Document) SelectionRanges(positions []lsp.Position) []lsp.SelectionRange {
+	result := []lsp.SelectionRange{}
+	if d == nil || d.Program == nil {
+		return result
+	}
+
+	s := newSpans(d.Text)
+	for _, pos := range positions {
+		offset := OffsetAt(d.Text, pos)
+
+		var chain []lsp.Range
+		ast.Inspect(d.Program, func(node ast.Node) bool {
+			start, end, ok := s.offsets(node)
+			if !ok {
+				return true
+			}
+			if offset < start || offset > end {
+				return false
+			}
+			rng := lsp.Range{Start: s.position(start), End: s.position(end)}
+			if len(chain) == 0 || chain[len(chain)-1] != rng {
+				chain = append(chain, rng)
+			}
+			return true
+		})
+
+		var sel *lsp.SelectionRange
+		for _, rng := range chain {
+			sel = &lsp.SelectionRange{Range: rng, Parent: sel}
+		}
+		if sel == nil {
+			sel = &lsp.SelectionRange{Range: lsp.Range{Start: pos, End: pos}}
+		}
+		result = append(result, *sel)
+	}
+	return result
+}
+
+// DocumentHighlights returns every occurrence in the document of the
+// symbol under pos. Its definition and the targets of assignments are
+// writes, everything else is a read.
+func (d *Document) DocumentHighlights(pos lsp.Position) []lsp.DocumentHighlight {
+	highlights := []lsp.DocumentHighlight{}
+	occ := d.FindOccurrenceAt(pos)
+	if occ == nil || occ.Def == nil {
+		return highlights
+	}
+
+	if occ.Def.URI == d.URI {
+		highlights = append(highlights, lsp.DocumentHighlight{
+			Range: occ.Def.Range,
+			Kind:  lsp.DocumentHighlightKindWrite,
+		})
+	}
+
+	writes := d.assignmentTargets()
+	for _, ref := range d.Index.RefsByDef[occ.Def] {
+		if ref.URI != d.URI {
+			continue
+		}
+		kind := lsp.DocumentHighlightKindRead
+		if writes[occurrenceKey{ref.Range.Start.Line, ref.Range.Start.Character}] {
+			kind = lsp.DocumentHighlightKindWrite
+		}
+		highlights = append(highlights, lsp.DocumentHighlight{Range: ref.Range, Kind: kind})
+	}
+	return highlights
+}
+
+// assignmentTargets returns the positions of identifiers assigned to with
+// = or a compound assignment, keyed like the analyzer's ranges.
+func (d *Document) assignmentTargets() map[occurrenceKey]bool {
+	targets := map[occurrenceKey]bool{}
+	if d.Program == nil {
+		return targets
+	}
+	ast.Inspect(d.Program, func(node ast.Node) bool {
+		infix, ok := node.(*ast.InfixExpression)
+		if !ok {
+			return true
+		}
+		switch token.TokenType(infix.Operator) {
+		case token.ASSIGN, token.ADD_ASSIGN, token.SUB_ASSIGN, token.MUL_ASSIGN, token.QUO_ASSIGN, token.REM_ASSIGN:
+			if ident, ok := infix.Left.(*ast.Identifier); ok {
+				targets[keyAt(ident.Pos())] = true
+			}
+		}
+		return true
+	})
+	return targets
+}
@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+const structureInput = `import "math";
+import "strings";
+/* a long
+   comment */
+let point = {
+  "x": 1,
+  "y": [
+    1,
+    2
+  ]
+};
+# one
+# two
+let total = fn(a, b) {
+  let sum = a + b;
+  sum += 1;
+  return sum;
+};
+struct Point {
+  x: 0,
+  y: 0
+};`
+
+func TestFoldingRanges(t *testing.T) {
+	doc := Analyze("file:///structure.cl", structureInput)
+	if len(doc.ParserErrors) > 0 {
+		t.Fatalf("parser errors: %v", doc.ParserErrors)
+	}
+
+	expected := []lsp.FoldingRange{
+		{StartLine: 0, EndLine: 1, Kind: lsp.FoldingRangeKindImports},
+		{StartLine: 2, EndLine: 3, Kind: lsp.FoldingRangeKindComment},
+		{StartLine: 4, EndLine: 9},
+		{StartLine: 6, EndLine: 8},
+		{StartLine: 11, EndLine: 12, Kind: lsp.FoldingRangeKindComment},
+		{StartLine: 13, EndLine: 16},
+		{StartLine: 18, EndLine: 20},
+	}
+	if got := doc.FoldingRanges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("wrong folding ranges.\nexpected=%+v\ngot=%+v", expected, got)
+	}
+}
+
+func TestFoldingRangesBrokenImport(t *testing.T) {
+	// The unfinished import leaves a nil statement in the program.
+	doc := Analyze("file:///structure.cl", "import \"math\";\nimport \"strings\";\nimport \"s")
+	if len(doc.ParserErrors) == 0 {
+		t.Fatal("expected parser errors")
+	}
+
+	expected := []lsp.FoldingRange{
+		{StartLine: 0, EndLine: 1, Kind: lsp.FoldingRangeKindImports},
+	}
+	if got := doc.FoldingRanges(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("wrong folding ranges.\nexpected=%+v\ngot=%+v", expected, got)
+	}
+}
+
+func TestSelectionRanges(t *testing.T) {
+	doc := Analyze("file:///structure.cl", structureInput)
+
+	// The cursor is on the b of `a + b`.
+	got := doc.SelectionRanges([]lsp.Position{{Line: 14, Character: 16}})
+	if len(got) != 1 {
+		t.Fatalf("expected 1 selection range, got %d", len(got))
+	}
+
+	expected := []lsp.Range{
+		{Start: lsp.Position{Line: 14, Character: 16}, End: lsp.Position{Line: 14, Character: 17}},
+		{Start: lsp.Position{Line: 14, Character: 12}, End: lsp.Position{Line: 14, Character: 17}},
+		{Start: lsp.Position{Line: 14, Character: 2}, End: lsp.Position{Line: 14, Character: 18}},
+		{Start: lsp.Position{Line: 13, Character: 21}, End: lsp.Position{Line: 17, Character: 1}},
+		{Start: lsp.Position{Line: 13, Character: 12}, End: lsp.Position{Line: 17, Character: 1}},
+		{Start: lsp.Position{Line: 13, Character: 0}, End: lsp.Position{Line: 17, Character: 2}},
+		{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 21, Character: 2}},
+	}
+	var ranges []lsp.Range
+	for sel := &got[0]; sel != nil; sel = sel.Parent {
+		ranges = append(ranges, sel.Range)
+	}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Fatalf("wrong selection ranges.\nexpected=%+v\ngot=%+v", expected, ranges)
+	}
+}
+
+func TestDocumentHighlights(t *testing.T) {
+	doc := Analyze("file:///structure.cl", structureInput)
+
+	highlights := doc.DocumentHighlights(lsp.Position{Line: 14, Character: 6})
+	kinds := map[int]int{}
+	for _, h := range highlights {
+		kinds[h.Range.Start.Line] = h.Kind
+	}
+
+	expected := map[int]int{
+		14: lsp.DocumentHighlightKindWrite, // let sum
+		15: lsp.DocumentHighlightKindWrite, // sum += 1
+		16: lsp.DocumentHighlightKindRead,  // return sum
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Fatalf("wrong highlights. expected=%v, got=%v", expected, kinds)
+	}
+}
@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/symbol"
+)
+
+// maxWorkspaceSymbols caps a workspace symbol search; clients ask again as
+// the query grows.
+const maxWorkspaceSymbols = 256
+
+// SymbolKind returns the LSP symbol kind for a definition kind.
+func SymbolKind(kind symbol.SymbolKind) int {
+	switch kind {
+	case symbol.FUNCTION:
+		return 12
+	case symbol.VARIABLE:
+		return 13
+	case symbol.CONSTANT:
+		return 14
+	case symbol.STRUCT:
+		return 23
+	case symbol.ENUM:
+		return 10
+	case symbol.ENUM_VARIANT:
+		return 22
+	case symbol.PARAMETER:
+		return 26
+	default:
+		return 13
+	}
+}
+
+// WorkspaceSymbols returns the top-level declarations and enum variants of
+// every .cl file in the workspace whose names fuzzily match query, best
+// matches first. Names bound by imports are left out in favor of the
+// declarations they import.
+func (s *State) WorkspaceSymbols(query string) []lsp.SymbolInformation {
+	type match struct {
+		info  lsp.SymbolInformation
+		score int
+	}
+	var matches []match
+	add := func(def *Definition, container string) {
+		score, ok := fuzzyScore(query, def.Name)
+		if !ok {
+			return
+		}
+		matches = append(matches, match{
+			info: lsp.SymbolInformation{
+				Name:          def.Name,
+				Kind:          SymbolKind(def.Kind),
+				Location:      lsp.Location{URI: def.URI, Range: def.Range},
+				ContainerName: container,
+			},
+			score: score,
+		})
+	}
+
+	for _, doc := range s.allDocuments() {
+		if doc.Index == nil || len(doc.Index.Scopes) == 0 {
+			continue
+		}
+		for _, def := range doc.Index.Scopes[0].Defs {
+			if def.Import == nil {
+				add(def, "")
+			}
+		}
+		for enum, variants := range doc.Index.Enums {
+			for _, def := range variants {
+				add(def, enum)
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score < b.score
+		}
+		if a.info.Name != b.info.Name {
+			return a.info.Name < b.info.Name
+		}
+		if a.info.Location.URI != b.info.Location.URI {
+			return a.info.Location.URI < b.info.Location.URI
+		}
+		return positionBefore(a.info.Location.Range.Start, b.info.Location.Range.Start)
+	})
+
+	symbols := []lsp.SymbolInformation{}
+	for i := 0; i < len(matches) && i < maxWorkspaceSymbols; i++ {
+		symbols = append(symbols, matches[i].info)
+	}
+	return symbols
+}
+
+// fuzzyScore reports whether the letters of query appear in name in order,
+// ignoring case. Lower scores are better matches: an exact name scores 0,
+// a prefix 1, and other matches more the later they start and the more
+// letters they skip.
+func fuzzyScore(query, name string) (int, bool) {
+	q, n := []rune(strings.ToLower(query)), []rune(strings.ToLower(name))
+	if len(q) == 0 {
+		return 0, true
+	}
+	if string(q) == string(n) {
+		return 0, true
+	}
+
+	first, last, j := -1, -1, 0
+	for i, r := range n {
+		if j < len(q) && r == q[j] {
+			if first == -1 {
+				first = i
+			}
+			last = i
+			j++
+		}
+	}
+	if j < len(q) {
+		return 0, false
+	}
+	if first == 0 && last == len(q)-1 {
+		return 1, true
+	}
+	gaps := last - first + 1 - len(q)
+	return 2 + first + gaps, true
+}
+
+// allDocuments returns every document the state knows after scanning the
+// workspace: the open ones and the files analyzed from disk.
+func (s *State) allDocuments() []*Document {
+	s.scanWorkspace()
+
+	s.mu.Lock()
+	var open []string
+	for uri := range s.texts {
+		open = append(open, uri)
+	}
+	var docs []*Document
+	for _, f := range s.files {
+		docs = append(docs, f.doc)
+	}
+	s.mu.Unlock()
+
+	for _, uri := range open {
+		if doc := s.analyze(uri); doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].URI < docs[j].URI })
+	return docs
+}
@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var callWorkspace = map[string]string{
+	"lib/util.cl": `let add = fn(a, b) { return a + b; };
+let addAll = fn(xs) {
+	let total = 0;
+	for (let i = 0; i < len(xs); i += 1) { total = add(total, xs[i]); };
+	return total;
+};
+enum Color { Red, Green };`,
+	"main.cl": `import "lib/util";
+let run = fn() {
+	util.add(1, 2);
+	util.addAll([1]);
+	util.add(3, 4);
+};
+run();`,
+}
+
+func TestWorkspaceSymbols(t *testing.T) {
+	state, dir := openWorkspace(t, callWorkspace, "main.cl")
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"Color", "Green", "Red", "add", "addAll", "run"}},
+		{"add", []string{"add", "addAll"}},
+		{"aa", []string{"addAll"}},
+		{"GR", []string{"Green"}},
+		{"zz", nil},
+	}
+
+	for _, tt := range tests {
+		symbols := state.WorkspaceSymbols(tt.query)
+		var names []string
+		for _, sym := range symbols {
+			names = append(names, sym.Name)
+		}
+		if !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("query %q: expected %v, got %v", tt.query, tt.expected, names)
+		}
+	}
+
+	green := state.WorkspaceSymbols("Green")[0]
+	if green.ContainerName != "Color" || green.Kind != 22 || URIToPath(green.Location.URI) != filepath.Join(dir, "lib", "util.cl") {
+		t.Errorf("wrong symbol for Green: %+v", green)
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, name string
+		score       int
+		ok          bool
+	}{
+		{"add", "add", 0, true},
+		{"ADD", "addAll", 1, true},
+		{"all", "addAll", 5, true},
+		{"aa", "addAll", 4, true},
+		{"xa", "addAll", 0, false},
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyScore(tt.query, tt.name)
+		if ok != tt.ok || (ok && score != tt.score) {
+			t.Errorf("fuzzyScore(%q, %q) = %d, %t; want %d, %t", tt.query, tt.name, score, ok, tt.score, tt.ok)
+		}
+	}
+}
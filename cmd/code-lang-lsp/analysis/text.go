@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+// ApplyChange applies one incremental change, whose range is in enc, to
+// text. A change without a range replaces the whole text.
+func ApplyChange(text string, change lsp.TextDocumentContentChange, enc PositionEncoding) string {
+	if change.Range == nil {
+		return change.Text
+	}
+
+	rng := NewConverter(text, enc).RangeFromClient(*change.Range)
+	start := OffsetAt(text, rng.Start)
+	end := OffsetAt(text, rng.End)
+	if end < start {
+		start, end = end, start
+	}
+
+	return text[:start] + change.Text + text[end:]
+}
+
+// OffsetAt converts an LSP position (0-based line, byte column) to a byte
+// offset in text. Positions past the end of a line or of the text are
+// clamped.
+func OffsetAt(text string, pos lsp.Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i == -1 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+
+	end := strings.IndexByte(text[offset:], '\n')
+	if end == -1 {
+		end = len(text) - offset
+	}
+	return offset + min(max(pos.Character, 0), end)
+}
+
+// lineStarts returns the byte offset at which each line of text begins.
+func lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// positionAt converts a byte offset in text to an LSP position with a byte
+// column.
+func positionAt(text string, starts []int, offset int) lsp.Position {
+	line := sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+	return lsp.Position{Line: line, Character: offset - starts[line]}
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func change(startLine, startChar, endLine, endChar int, text string) lsp.TextDocumentContentChange {
+	return lsp.TextDocumentContentChange{
+		Range: &lsp.Range{
+			Start: lsp.Position{Line: startLine, Character: startChar},
+			End:   lsp.Position{Line: endLine, Character: endChar},
+		},
+		Text: text,
+	}
+}
+
+func TestApplyChange(t *testing.T) {
+	tests := []struct {
+		text     string
+		change   lsp.TextDocumentContentChange
+		enc      PositionEncoding
+		expected string
+	}{
+		{"let x = 1;", lsp.TextDocumentContentChange{Text: "let y = 2;"}, UTF16, "let y = 2;"},
+		{"let x = 1;", change(0, 4, 0, 5, "count"), UTF16, "let count = 1;"},
+		{"let x = 1;\nx;", change(1, 0, 1, 0, "print("), UTF16, "let x = 1;\nprint(x;"},
+		{"a\nb\nc", change(0, 1, 2, 0, ""), UTF16, "ac"},
+		{"let s = \"😀\"; s;", change(0, 14, 0, 15, "t"), UTF16, "let s = \"😀\"; t;"},
+		{"let s = \"😀\"; s;", change(0, 16, 0, 17, "t"), UTF8, "let s = \"😀\"; t;"},
+		{"short", change(0, 2, 5, 0, "!"), UTF16, "sh!"},
+	}
+
+	for _, tt := range tests {
+		got := ApplyChange(tt.text, tt.change, tt.enc)
+		if got != tt.expected {
+			t.Errorf("ApplyChange(%q) wrong. expected=%q, got=%q", tt.text, tt.expected, got)
+		}
+	}
+}
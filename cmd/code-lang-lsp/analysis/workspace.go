@@ -0,0 +1,205 @@
+package analysis
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/symbol"
+)
+
+// ImportPaths returns every path the document imports, in source order.
+func (idx *Index) ImportPaths() []string {
+	seen := map[string]bool{}
+	var paths []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, def := range idx.Definitions {
+		if def.Import != nil {
+			add(def.Import.Path)
+		}
+	}
+	var bound []string
+	for _, path := range idx.Imports {
+		bound = append(bound, path)
+	}
+	sort.Strings(bound)
+	for _, path := range bound {
+		add(path)
+	}
+	return paths
+}
+
+// ModuleMemberAt returns the `module.member` access under pos, if any.
+func (d *Document) ModuleMemberAt(pos lsp.Position) *ModuleMember {
+	if d == nil || d.Index == nil {
+		return nil
+	}
+	for _, m := range d.Index.ModuleMembers {
+		if contains(m.Range, pos) {
+			return m
+		}
+	}
+	return nil
+}
+
+// Exported returns the top-level definition of name if the module exports
+// it to importers.
+func (d *Document) Exported(name string) *Definition {
+	if d == nil || d.Index == nil || len(d.Index.Scopes) == 0 {
+		return nil
+	}
+	if _, ok := symbol.ModuleExports(d.Program)[name]; !ok {
+		return nil
+	}
+	return d.Index.Scopes[0].Defs[name]
+}
+
+// scanWorkspace analyzes every .cl file under Root once, so the import
+// graph covers files that have never been opened.
+func (s *State) scanWorkspace() {
+	s.mu.Lock()
+	if s.scanned || s.Root == "" {
+		s.mu.Unlock()
+		return
+	}
+	s.scanned = true
+	root := URIToPath(s.Root)
+	s.mu.Unlock()
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".cl" {
+			s.Document(PathToURI(path))
+		}
+		return nil
+	})
+}
+
+// Origin follows a definition to the declaration it stands for: names
+// bound by `from ... import` lead to the exporting module.
+func (s *State) Origin(def *Definition) *Definition {
+	for i := 0; def != nil && def.Import != nil && i < 16; i++ {
+		target := s.Document(s.ResolveImport(def.URI, def.Import.Path))
+		exported := target.Exported(def.Import.Name)
+		if exported == nil {
+			break
+		}
+		def = exported
+	}
+	return def
+}
+
+// DefinitionAt returns the declaration of the symbol under pos, looking
+// into imported files when needed.
+func (s *State) DefinitionAt(uri string, pos lsp.Position) *Definition {
+	doc := s.Document(uri)
+	if doc == nil {
+		return nil
+	}
+
+	if occ := doc.FindOccurrenceAt(pos); occ != nil && occ.Def != nil {
+		return s.Origin(occ.Def)
+	}
+
+	if m := doc.ModuleMemberAt(pos); m != nil {
+		target := s.Document(s.ResolveImport(doc.URI, m.Path))
+		return s.Origin(target.Exported(m.Name))
+	}
+
+	return nil
+}
+
+// ReferencesTo returns every location that refers to def across the
+// workspace: its local references and, when def is exported, the member
+// accesses and `from ... import` bindings in importing files.
+func (s *State) ReferencesTo(def *Definition, includeDeclaration bool) []lsp.Location {
+	if def == nil {
+		return nil
+	}
+
+	var locs []lsp.Location
+	seen := map[string]bool{}
+	add := func(uri string, rng lsp.Range) {
+		key := URIToPath(uri) + ":" + rangeKey(rng)
+		if !seen[key] {
+			seen[key] = true
+			locs = append(locs, lsp.Location{URI: uri, Range: rng})
+		}
+	}
+
+	doc := s.Document(def.URI)
+	if includeDeclaration {
+		add(def.URI, def.Range)
+	}
+	if doc != nil {
+		for _, ref := range doc.Index.RefsByDef[def] {
+			add(ref.URI, ref.Range)
+		}
+	}
+
+	if doc == nil || doc.Exported(def.Name) != def {
+		return locs
+	}
+
+	path := URIToPath(doc.URI)
+	for _, depURI := range s.Dependents(doc.URI) {
+		dep := s.Document(depURI)
+		if dep == nil {
+			continue
+		}
+
+		importsDef := func(importPath string) bool {
+			return URIToPath(s.ResolveImport(dep.URI, importPath)) == path
+		}
+
+		for _, m := range dep.Index.ModuleMembers {
+			if m.Name == def.Name && importsDef(m.Path) {
+				add(dep.URI, m.Range)
+			}
+		}
+		for _, d := range dep.Index.Definitions {
+			if d.Import == nil || d.Import.Name != def.Name || !importsDef(d.Import.Path) {
+				continue
+			}
+			add(d.URI, d.Range)
+			for _, ref := range dep.Index.RefsByDef[d] {
+				add(ref.URI, ref.Range)
+			}
+		}
+	}
+
+	return locs
+}
+
+// Rename returns the edits renaming def everywhere it is referenced.
+func (s *State) Rename(def *Definition, newName string) lsp.WorkspaceEdit {
+	changes := map[string][]lsp.TextEdit{}
+	for _, loc := range s.ReferencesTo(def, true) {
+		changes[loc.URI] = append(changes[loc.URI], lsp.TextEdit{
+			Range:   loc.Range,
+			NewText: newName,
+		})
+	}
+	return lsp.WorkspaceEdit{Changes: changes}
+}
+
+func rangeKey(r lsp.Range) string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+}
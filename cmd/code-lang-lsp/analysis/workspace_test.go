@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func openWorkspace(t *testing.T, files map[string]string, open ...string) (*State, string) {
+	t.Helper()
+	dir := writeWorkspace(t, files)
+	state := NewState()
+	state.Root = PathToURI(dir)
+	for _, name := range open {
+		state.OpenDocument(PathToURI(filepath.Join(dir, name)), files[name])
+	}
+	return state, dir
+}
+
+func uriNames(dir string, locs []lsp.Location) []string {
+	var names []string
+	for _, loc := range locs {
+		rel, _ := filepath.Rel(dir, URIToPath(loc.URI))
+		names = append(names, filepath.ToSlash(rel))
+	}
+	sort.Strings(names)
+	return names
+}
+
+var crossFileWorkspace = map[string]string{
+	"lib/util.cl": "let add = fn(a, b) { return a + b; };\nlet _hidden = 1;\nadd(1, 2);",
+	"main.cl":     "import \"lib/util\";\nutil.add(1, 2);",
+	"other.cl":    "from \"lib/util\" import add;\nadd(3, 4);",
+}
+
+func TestDefinitionAcrossFiles(t *testing.T) {
+	state, dir := openWorkspace(t, crossFileWorkspace, "main.cl")
+	mainURI := PathToURI(filepath.Join(dir, "main.cl"))
+
+	member := state.Document(mainURI).Index.ModuleMembers
+	if len(member) != 1 {
+		t.Fatalf("expected 1 module member access, got=%d", len(member))
+	}
+
+	def := state.DefinitionAt(mainURI, member[0].Range.Start)
+	if def == nil {
+		t.Fatalf("no definition found for util.add")
+	}
+	if def.Name != "add" || URIToPath(def.URI) != filepath.Join(dir, "lib", "util.cl") {
+		t.Errorf("wrong definition. got=%s in %s", def.Name, def.URI)
+	}
+
+	otherURI := PathToURI(filepath.Join(dir, "other.cl"))
+	other := state.Document(otherURI)
+	call := other.Index.References[len(other.Index.References)-1]
+	def = state.DefinitionAt(otherURI, call.Range.Start)
+	if def == nil || URIToPath(def.URI) != filepath.Join(dir, "lib", "util.cl") {
+		t.Errorf("from-import binding should lead to lib/util.cl. got=%+v", def)
+	}
+}
+
+func TestReferencesAndRenameAcrossFiles(t *testing.T) {
+	state, dir := openWorkspace(t, crossFileWorkspace, "main.cl")
+	utilURI := PathToURI(filepath.Join(dir, "lib", "util.cl"))
+
+	def := state.Document(utilURI).Exported("add")
+	if def == nil {
+		t.Fatalf("add should be exported")
+	}
+
+	refs := uriNames(dir, state.ReferencesTo(def, true))
+	expected := []string{"lib/util.cl", "lib/util.cl", "main.cl", "other.cl", "other.cl"}
+	if len(refs) != len(expected) {
+		t.Fatalf("wrong references. expected=%v, got=%v", expected, refs)
+	}
+	for i := range expected {
+		if refs[i] != expected[i] {
+			t.Errorf("wrong references. expected=%v, got=%v", expected, refs)
+			break
+		}
+	}
+
+	edit := state.Rename(def, "sum")
+	if len(edit.Changes) != 3 {
+		t.Fatalf("rename should touch 3 files, got=%d", len(edit.Changes))
+	}
+	for uri, edits := range edit.Changes {
+		for _, e := range edits {
+			if e.NewText != "sum" {
+				t.Errorf("wrong new text in %s: %q", uri, e.NewText)
+			}
+		}
+	}
+}
+
+func TestPrivateNamesStayLocal(t *testing.T) {
+	state, dir := openWorkspace(t, crossFileWorkspace)
+	utilURI := PathToURI(filepath.Join(dir, "lib", "util.cl"))
+
+	doc := state.Document(utilURI)
+	if doc.Exported("_hidden") != nil {
+		t.Errorf("_hidden should not be exported")
+	}
+	hidden := doc.Index.Scopes[0].Defs["_hidden"]
+	if refs := state.ReferencesTo(hidden, true); len(refs) != 1 {
+		t.Errorf("expected only the declaration, got=%v", refs)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	state, dir := openWorkspace(t, crossFileWorkspace)
+	utilURI := PathToURI(filepath.Join(dir, "lib", "util.cl"))
+
+	var deps []lsp.Location
+	for _, uri := range state.Dependents(utilURI) {
+		deps = append(deps, lsp.Location{URI: uri})
+	}
+	got := uriNames(dir, deps)
+	if len(got) != 2 || got[0] != "main.cl" || got[1] != "other.cl" {
+		t.Errorf("wrong dependents. got=%v", got)
+	}
+}
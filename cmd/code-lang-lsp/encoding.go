@@ -0,0 +1,202 @@
+package main
+
+import (
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/analysis"
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+)
+
+// converters hands out the position converter of each document a request
+// or its result mentions, reading every document once. All of them are
+// nil, and every conversion free, when the client counts bytes.
+type converters struct {
+	state *analysis.State
+	byURI map[string]*analysis.Converter
+}
+
+func (s *Server) converters() *converters {
+	return &converters{state: s.state, byURI: map[string]*analysis.Converter{}}
+}
+
+func (c *converters) of(uri string) *analysis.Converter {
+	if c.state.Encoding == analysis.UTF8 {
+		return nil
+	}
+	conv, ok := c.byURI[uri]
+	if !ok {
+		conv = c.state.Converter(uri)
+		c.byURI[uri] = conv
+	}
+	return conv
+}
+
+func (c *converters) locations(locs []lsp.Location) []lsp.Location {
+	if locs == nil {
+		return nil
+	}
+	out := make([]lsp.Location, len(locs))
+	for i, loc := range locs {
+		out[i] = lsp.Location{URI: loc.URI, Range: c.of(loc.URI).RangeToClient(loc.Range)}
+	}
+	return out
+}
+
+func (c *converters) edit(edit lsp.WorkspaceEdit) lsp.WorkspaceEdit {
+	if edit.Changes == nil {
+		return edit
+	}
+	out := lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{}}
+	for uri, edits := range edit.Changes {
+		conv := c.of(uri)
+		converted := make([]lsp.TextEdit, len(edits))
+		for i, e := range edits {
+			converted[i] = lsp.TextEdit{Range: conv.RangeToClient(e.Range), NewText: e.NewText}
+		}
+		out.Changes[uri] = converted
+	}
+	return out
+}
+
+func (c *converters) codeActions(uri string, actions []lsp.CodeAction) []lsp.CodeAction {
+	out := make([]lsp.CodeAction, len(actions))
+	for i, action := range actions {
+		action.Diagnostics = diagnosticsToClient(c.of(uri), action.Diagnostics)
+		if action.Edit != nil {
+			edit := c.edit(*action.Edit)
+			action.Edit = &edit
+		}
+		out[i] = action
+	}
+	return out
+}
+
+func (c *converters) symbols(symbols []lsp.SymbolInformation) []lsp.SymbolInformation {
+	out := make([]lsp.SymbolInformation, len(symbols))
+	for i, sym := range symbols {
+		sym.Location.Range = c.of(sym.Location.URI).RangeToClient(sym.Location.Range)
+		out[i] = sym
+	}
+	return out
+}
+
+func (c *converters) callItemToClient(item lsp.CallHierarchyItem) lsp.CallHierarchyItem {
+	conv := c.of(item.URI)
+	item.Range = conv.RangeToClient(item.Range)
+	item.SelectionRange = conv.RangeToClient(item.SelectionRange)
+	return item
+}
+
+func (c *converters) callItemFromClient(item lsp.CallHierarchyItem) lsp.CallHierarchyItem {
+	conv := c.of(item.URI)
+	item.Range = conv.RangeFromClient(item.Range)
+	item.SelectionRange = conv.RangeFromClient(item.SelectionRange)
+	return item
+}
+
+func (c *converters) callItems(items []lsp.CallHierarchyItem) []lsp.CallHierarchyItem {
+	out := make([]lsp.CallHierarchyItem, len(items))
+	for i, item := range items {
+		out[i] = c.callItemToClient(item)
+	}
+	return out
+}
+
+// incomingCalls converts incoming calls, whose ranges lie in the caller.
+func (c *converters) incomingCalls(calls []lsp.CallHierarchyIncomingCall) []lsp.CallHierarchyIncomingCall {
+	out := make([]lsp.CallHierarchyIncomingCall, len(calls))
+	for i, call := range calls {
+		out[i] = lsp.CallHierarchyIncomingCall{
+			From:       c.callItemToClient(call.From),
+			FromRanges: rangesToClient(c.of(call.From.URI), call.FromRanges),
+		}
+	}
+	return out
+}
+
+// outgoingCalls converts the calls made from the document at uri.
+func (c *converters) outgoingCalls(uri string, calls []lsp.CallHierarchyOutgoingCall) []lsp.CallHierarchyOutgoingCall {
+	out := make([]lsp.CallHierarchyOutgoingCall, len(calls))
+	for i, call := range calls {
+		out[i] = lsp.CallHierarchyOutgoingCall{
+			To:         c.callItemToClient(call.To),
+			FromRanges: rangesToClient(c.of(uri), call.FromRanges),
+		}
+	}
+	return out
+}
+
+func rangesToClient(conv *analysis.Converter, ranges []lsp.Range) []lsp.Range {
+	out := make([]lsp.Range, len(ranges))
+	for i, r := range ranges {
+		out[i] = conv.RangeToClient(r)
+	}
+	return out
+}
+
+func diagnosticsToClient(conv *analysis.Converter, diags []lsp.Diagnostic) []lsp.Diagnostic {
+	if diags == nil {
+		return nil
+	}
+	out := make([]lsp.Diagnostic, len(diags))
+	for i, diag := range diags {
+		diag.Range = conv.RangeToClient(diag.Range)
+		out[i] = diag
+	}
+	return out
+}
+
+func documentSymbolsToClient(conv *analysis.Converter, symbols []lsp.DocumentSymbol) []lsp.DocumentSymbol {
+	if symbols == nil {
+		return nil
+	}
+	out := make([]lsp.DocumentSymbol, len(symbols))
+	for i, sym := range symbols {
+		sym.Range = conv.RangeToClient(sym.Range)
+		sym.SelectionRange = conv.RangeToClient(sym.SelectionRange)
+		out[i] = sym
+	}
+	return out
+}
+
+func highlightsToClient(conv *analysis.Converter, highlights []lsp.DocumentHighlight) []lsp.DocumentHighlight {
+	out := make([]lsp.DocumentHighlight, len(highlights))
+	for i, h := range highlights {
+		h.Range = conv.RangeToClient(h.Range)
+		out[i] = h
+	}
+	return out
+}
+
+func inlayHintsToClient(conv *analysis.Converter, hints []lsp.InlayHint) []lsp.InlayHint {
+	out := make([]lsp.InlayHint, len(hints))
+	for i, hint := range hints {
+		hint.Position = conv.ToClient(hint.Position)
+		out[i] = hint
+	}
+	return out
+}
+
+func selectionRangesToClient(conv *analysis.Converter, ranges []lsp.SelectionRange) []lsp.SelectionRange {
+	out := make([]lsp.SelectionRange, len(ranges))
+	for i, r := range ranges {
+		out[i] = *selectionRangeToClient(conv, &r)
+	}
+	return out
+}
+
+func selectionRangeToClient(conv *analysis.Converter, r *lsp.SelectionRange) *lsp.SelectionRange {
+	if r == nil {
+		return nil
+	}
+	return &lsp.SelectionRange{
+		Range:  conv.RangeToClient(r.Range),
+		Parent: selectionRangeToClient(conv, r.Parent),
+	}
+}
+
+func positionsFromClient(conv *analysis.Converter, positions []lsp.Position) []lsp.Position {
+	out := make([]lsp.Position, len(positions))
+	for i, pos := range positions {
+		out[i] = conv.FromClient(pos)
+	}
+	return out
+}
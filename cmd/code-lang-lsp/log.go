@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// Log levels, from the quietest.
+const (
+	levelOff = iota
+	levelError
+	levelInfo
+	levelDebug
+)
+
+var levels = map[string]int{"off": levelOff, "error": levelError, "info": levelInfo, "debug": levelDebug}
+
+// serverLog writes the messages at or below its level. The settings can
+// move it to another file and change the level while the server runs.
+type serverLog struct {
+	mu     sync.Mutex
+	logger *log.Logger
+	file   *os.File
+	path   string
+	level  int
+}
+
+func newServerLog(logger *log.Logger) *serverLog {
+	return &serverLog{logger: logger, level: levelInfo}
+}
+
+// openLog logs to the file at path, or to stderr when it cannot be
+// created, so the server doesn't crash over its log.
+func openLog(path string) (*log.Logger, *os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
+	if err != nil {
+		return newLogger(os.Stderr), nil, err
+	}
+	return newLogger(file), file, nil
+}
+
+func newLogger(w io.Writer) *log.Logger {
+	return log.New(w, "[code-lang-ls]", log.Ldate|log.Ltime|log.Lshortfile)
+}
+
+// SetFile moves the log to the file at path, unless it is there already.
+func (l *serverLog) SetFile(path string) error {
+	l.mu.Lock()
+	current := l.path
+	l.mu.Unlock()
+	if path == current {
+		return nil
+	}
+
+	logger, file, err := openLog(path)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.logger, l.file, l.path = logger, file, path
+	return nil
+}
+
+// SetLevel sets the level by name: off, error, info or debug.
+func (l *serverLog) SetLevel(name string) error {
+	level, ok := levels[name]
+	if !ok {
+		return fmt.Errorf("unknown log level %q, expected off, error, info or debug", name)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+	return nil
+}
+
+func (l *serverLog) output(level int, format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level <= l.level {
+		l.logger.Output(3, fmt.Sprintf(format, args...))
+	}
+}
+
+// Errorf logs what went wrong handling a message.
+func (l *serverLog) Errorf(format string, args ...any) { l.output(levelError, format, args...) }
+
+// Printf logs what the server does.
+func (l *serverLog) Printf(format string, args ...any) { l.output(levelInfo, format, args...) }
+
+// Debugf logs the traffic with the client.
+func (l *serverLog) Debugf(format string, args ...any) { l.output(levelDebug, format, args...) }
@@ -1,159 +1,263 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
-	"io"
-	"log"
+	"flag"
+	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/walonCode/code-lang/cmd/code-lang-lsp/analysis"
 	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
-	"github.com/walonCode/code-lang/cmd/code-lang-lsp/rpc"
-	"github.com/walonCode/code-lang/internal/symbol"
-	"github.com/walonCode/code-lang/internal/std/arrays"
-	"github.com/walonCode/code-lang/internal/std/fs"
-	"github.com/walonCode/code-lang/internal/std/general"
-	"github.com/walonCode/code-lang/internal/std/hash"
-	"github.com/walonCode/code-lang/internal/std/math"
-	"github.com/walonCode/code-lang/internal/std/net"
-	stdstrings "github.com/walonCode/code-lang/internal/std/strings"
-	"github.com/walonCode/code-lang/internal/std/time"
-	osModule"github.com/walonCode/code-lang/internal/std/os"	
-	JsonModule"github.com/walonCode/code-lang/internal/std/json"
+	"github.com/walonCode/code-lang/internal/evaluator"
 	"github.com/walonCode/code-lang/internal/object"
 )
 
-func logger(filename string) *log.Logger {
-	logfile, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
-	if err != nil {
-		// Fall back to stderr so the server doesn't crash if the log file
-		// can't be created in the current working directory.
-		return log.New(os.Stderr, "[code-lang-ls]", log.Ldate|log.Ltime|log.Lshortfile)
-	}
-
-	return log.New(logfile, "[code-lang-ls]", log.Ldate|log.Ltime|log.Lshortfile)
-}
+// Set at build time with -ldflags "-X main.Version=...".
+var (
+	Version = "dev"
+	Commit  = "none"
+)
 
 func main() {
-	logger := logger("log.txt")
+	flags := flag.NewFlagSet("code-lang-lsp", flag.ExitOnError)
+	// stdio is the only transport; the flag is accepted since editors pass it.
+	flags.Bool("stdio", true, "speak the protocol over stdin and stdout")
+	logFile := flags.String("log", "", "log to `file` instead of stderr")
+	version := flags.Bool("version", false, "print the version and exit")
+	flags.Parse(os.Args[1:])
+
+	if *version {
+		fmt.Printf("code-lang-lsp %s %s\n", Version, Commit)
+		return
+	}
+
+	logger, file := newLogger(os.Stderr), (*os.File)(nil)
+	if *logFile != "" {
+		var err error
+		if logger, file, err = openLog(*logFile); err != nil {
+			logger.Printf("cannot log to %s: %v", *logFile, err)
+		}
+	}
 	logger.Println("started lsp")
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Printf("panic: %v", r)
 		}
 	}()
-	scanner := bufio.NewScanner(os.Stdin)
-	// Allow larger LSP messages than the default 64K token limit.
-	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
-	scanner.Split(rpc.Spilt)
-	
-	state := analysis.NewState()
-	writer := os.Stdout
-	
-	for scanner.Scan(){
-		msg := scanner.Bytes()
-		method, content, err := rpc.DecodeMessage(msg)
-		if err != nil {
-			logger.Printf("Got an error: %s ",err)
-		}
-		handleMessage(logger, writer, state, method, content)
-	}
-
-	if err := scanner.Err(); err != nil {
-		logger.Printf("scanner error: %v", err)
-	}
+	server := NewServer(logger)
+	server.logger.file, server.logger.path = file, *logFile
+	os.Exit(server.Serve(os.Stdin, os.Stdout))
 }
 
-func writeResponse(writer io.Writer, msg any){
-	reply,_ := rpc.EncodeMessage(msg)
-	writer.Write([]byte(reply))
-}
-
-func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, method string, content []byte){
-	logger.Printf("Recieved msg with method: %s", method)
+func (s *Server) handleMessage(method string, content []byte) {
+	s.logger.Debugf("Recieved msg with method: %s", method)
 	
 	switch method{
 		case "initialize":
 			var request lsp.InitializeRequest
 			if err := json.Unmarshal(content, &request);err != nil {
-				logger.Printf("Unable to parse the initialize request with err: %s", err)
+				s.logger.Errorf("Unable to parse the initialize request with err: %s", err)
 			}
 
 			clientName := "unknown"
 			if request.Params.ClientInfo != nil && request.Params.ClientInfo.Name != "" {
 				clientName = request.Params.ClientInfo.Name
 			}
-			logger.Printf("The client name is: %s", clientName)
+			s.logger.Printf("The client name is: %s", clientName)
+			s.state.Root = request.Params.RootUri
+			if general := request.Params.Capabilities.General; general != nil {
+				s.state.Encoding = analysis.NegotiateEncoding(general.PositionEncodings)
+			}
+			s.initialized = true
+			if workspace := request.Params.Capabilities.Workspace; workspace != nil {
+				if workspace.DidChangeWatchedFiles != nil {
+					s.watchFiles = workspace.DidChangeWatchedFiles.DynamicRegistration
+				}
+				if workspace.DidChangeConfiguration != nil {
+					s.watchConfiguration = workspace.DidChangeConfiguration.DynamicRegistration
+				}
+				s.pullConfiguration = workspace.Configuration
+			}
+			if textDocument := request.Params.Capabilities.TextDocument; textDocument != nil {
+				s.pullDiagnostics = textDocument.Diagnostic != nil
+			}
 			
 			//reply
 			msg := lsp.NewInitializeResponse(request.ID)
-			writeResponse(writer,msg)
+			msg.Result.Capabilities.PositionEncoding = string(s.state.Encoding)
+			msg.Result.Capabilities.SemanticTokensProvider = &lsp.SemanticTokensOptions{
+				Legend: lsp.SemanticTokensLegend{
+					TokenTypes:     analysis.SemanticTokenTypes,
+					TokenModifiers: analysis.SemanticTokenModifiers,
+				},
+				Range: true,
+				Full:  true,
+			}
+			msg.Result.Capabilities.CodeLensProvider = &lsp.CodeLensOptions{}
+			msg.Result.Capabilities.ExecuteCommandProvider = &lsp.ExecuteCommandOptions{Commands: analysis.Commands}
+			msg.Result.Capabilities.DiagnosticProvider = &lsp.DiagnosticOptions{
+				Identifier:            "code-lang",
+				InterFileDependencies: true,
+				WorkspaceDiagnostics:  true,
+			}
+			msg.Result.Capabilities.DocumentLinkProvider = &lsp.DocumentLinkOptions{}
+			writeResponse(s.writer,msg)
 			
+		case "initialized":
+			if s.watchFiles {
+				s.registerFileWatchers()
+			}
+			if s.watchConfiguration {
+				s.registerConfigurationChanges()
+			}
+			if s.pullConfiguration {
+				s.fetchSettings()
+			}
+		case "workspace/didChangeConfiguration":
+			var request lsp.DidChangeConfigurationNotification
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("unable to parse the did change configuration notification: %s", err)
+			}
+			
+			// Clients either send the settings along or leave the server
+			// to ask for them.
+			var sections map[string]json.RawMessage
+			if json.Unmarshal(request.Params.Settings, &sections) == nil && sections[analysis.SettingsSection] != nil {
+				s.configure(sections[analysis.SettingsSection])
+			} else if s.pullConfiguration {
+				s.fetchSettings()
+			}
+		case "shutdown":
+			var request lsp.Request
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the shutdown request with err: %s", err)
+			}
+			
+			s.shutdown = true
+			writeResponse(s.writer, lsp.NullResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+			})
+		case "workspace/didChangeWatchedFiles":
+			var request lsp.DidChangeWatchedFilesNotification
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("unable to parse the did change watched files notification: %s", err)
+			}
+			
+			for _, change := range request.Params.Changes {
+				s.state.FileChanged(change.URI, change.Type == lsp.FileDeleted)
+			}
 		case "textDocument/didOpen":
 			var request lsp.DidOpenTextDocumentNotification
 			if err := json.Unmarshal(content, &request);err != nil {
-				logger.Printf("unable to parse the text document did open notificaton: %s",err)
-			}
-			
-			logger.Printf("page uri: %s", request.Params.TextDocument.URI)
-			state.OpenDocument(request.Params.TextDocument.URI, request.Params.TextDocument.Text)
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
-				writeResponse(writer, lsp.PublishDiagnosticsNotification{
-					Notification: lsp.Notification{
-						RPC:    "2.0",
-						Method: "textDocument/publishDiagnostics",
-					},
-					Params: lsp.PublishDiagnosticsParams{
-						URI:         request.Params.TextDocument.URI,
-						Diagnostics: doc.Diagnostics(),
-					},
-				})
+				s.logger.Errorf("unable to parse the text document did open notificaton: %s",err)
+			}
+			
+			s.logger.Debugf("page uri: %s", request.Params.TextDocument.URI)
+			s.state.OpenDocument(request.Params.TextDocument.URI, request.Params.TextDocument.Text)
+			if doc := s.state.GetDocument(request.Params.TextDocument.URI); doc != nil {
+				s.publishDiagnostics(doc)
 			}
 		case "textDocument/didChange":
 			var request lsp.DidChangeTextDocumentNotification
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("unable to parse the text document did change notification: %s", err)
-			}
-			
-			logger.Printf("changed uri: %s", request.Params.TextDocument.URI,)
-			for _, change := range request.Params.ContentChanges {
-				state.UpdateDocument(request.Params.TextDocument.URI, change.Text)
-			}
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
-				writeResponse(writer, lsp.PublishDiagnosticsNotification{
-					Notification: lsp.Notification{
-						RPC:    "2.0",
-						Method: "textDocument/publishDiagnostics",
-					},
-					Params: lsp.PublishDiagnosticsParams{
-						URI:         request.Params.TextDocument.URI,
-						Diagnostics: doc.Diagnostics(),
-					},
-				})
+				s.logger.Errorf("unable to parse the text document did change notification: %s", err)
 			}
+			
+			s.logger.Debugf("changed uri: %s", request.Params.TextDocument.URI,)
+			s.state.ChangeDocument(request.Params.TextDocument.URI, request.Params.ContentChanges)
+			s.state.ScheduleAnalysis(request.Params.TextDocument.URI, analysisDelay, func(doc *analysis.Document) {
+				s.publishDiagnostics(doc)
+			})
 		case "textDocument/didClose":
 			var request lsp.DidCloseTextDocumentNotification
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("unable to parse the text document did close notification: %s", err)
+				s.logger.Errorf("unable to parse the text document did close notification: %s", err)
+			}
+			
+			s.logger.Debugf("closed uri: %s", request.Params.TextDocument.URI)
+			s.state.CloseDocument(request.Params.TextDocument.URI)
+		case "textDocument/diagnostic":
+			var request lsp.DocumentDiagnosticRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the diagnostic request with err: %s", err)
+			}
+			
+			report := lsp.DocumentDiagnosticReport{Kind: lsp.DiagnosticReportFull}
+			if doc := s.state.Document(request.Params.TextDocument.URI); doc != nil {
+				report = s.diagnosticReport(doc, s.state.Diagnostics(doc), request.Params.PreviousResultID)
+			}
+			writeResponse(s.writer, lsp.DocumentDiagnosticResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: report,
+			})
+		case "workspace/diagnostic":
+			var request lsp.WorkspaceDiagnosticRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the workspace diagnostic request with err: %s", err)
+			}
+			
+			previous := map[string]string{}
+			for _, id := range request.Params.PreviousResultIDs {
+				previous[id.URI] = id.Value
+			}
+			items := []lsp.WorkspaceDocumentDiagnosticReport{}
+			for _, file := range s.state.WorkspaceDiagnostics() {
+				items = append(items, lsp.WorkspaceDocumentDiagnosticReport{
+					URI:                      file.Doc.URI,
+					DocumentDiagnosticReport: s.diagnosticReport(file.Doc, file.Diagnostics, previous[file.Doc.URI]),
+				})
+			}
+			writeResponse(s.writer, lsp.WorkspaceDiagnosticResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: lsp.WorkspaceDiagnosticReport{Items: items},
+			})
+		case "textDocument/documentLink":
+			var request lsp.DocumentLinkRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the document link request with err: %s", err)
 			}
 			
-			logger.Printf("closed uri: %s", request.Params.TextDocument.URI)
-			state.CloseDocument(request.Params.TextDocument.URI)
+			links := s.state.DocumentLinks(request.Params.TextDocument.URI)
+			conv := s.state.Converter(request.Params.TextDocument.URI)
+			for i := range links {
+				links[i].Range = conv.RangeToClient(links[i].Range)
+			}
+			writeResponse(s.writer, lsp.DocumentLinkResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: links,
+			})
 		case "textDocument/hover":
 			var request lsp.HoverRequest
 			if err := json.Unmarshal(content, &request);err != nil {
-				logger.Printf("Unable to parse the hover request with err: %s", err)
+				s.logger.Errorf("Unable to parse the hover request with err: %s", err)
 			}
 			
-			logger.Printf("The client name is: %s",request.Params.TextDocument.URI)
+			s.logger.Debugf("The client name is: %s",request.Params.TextDocument.URI)
 			
 			contents := ""
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
+			request.Params.Position = s.state.Converter(request.Params.TextDocument.URI).FromClient(request.Params.Position)
+			if doc := s.state.GetDocument(request.Params.TextDocument.URI); doc != nil {
 				occ := doc.FindOccurrenceAt(request.Params.Position)
-				if occ != nil {
+				if module, ok := s.state.ImportHover(request.Params.TextDocument.URI, request.Params.Position); ok {
+					contents = module
+				} else if builtin, ok := doc.BuiltinAt(request.Params.Position); ok {
+					contents = builtin.Markdown()
+				} else if occ != nil {
 					kind := occ.Kind.String()
 					if occ.IsDefinition {
 						contents = occ.Name + " (" + kind + ")"
@@ -164,12 +268,16 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 					}
 				} else if doc.Index != nil {
 					if modName, member, ok := memberCompletionContext(doc.Text, request.Params.Position); ok {
-						if mems, ok := moduleMembersFor(doc, modName); ok {
-							for _, m := range mems {
-								if m == member {
-									contents = modName + "." + member
-									break
-								}
+						mems, _ := moduleMembersFor(doc, modName)
+						if members, ok := s.state.ModuleCompletions(request.Params.TextDocument.URI, modName); ok {
+							for _, item := range members {
+								mems = append(mems, item.Label)
+							}
+						}
+						for _, m := range mems {
+							if m == member {
+								contents = modName + "." + member
+								break
 							}
 						}
 					}
@@ -191,46 +299,63 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 					RPC: "2.0",
 					ID:  &request.ID,
 				},
-				Result: lsp.Hover{Contents: contents},
+				Result: lsp.Hover{Contents: lsp.MarkupContent{Kind: "markdown", Value: contents}},
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
 		case "textDocument/completion":
 			var request lsp.CompletionRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the completion request with err: %s", err)
-			}
-			
-			items := []lsp.CompletionItem{
-				{Label: "let", Detail: "keyword"},
-				{Label: "const", Detail: "keyword"},
-				{Label: "fn", Detail: "keyword"},
-				{Label: "if", Detail: "keyword"},
-				{Label: "else", Detail: "keyword"},
-				{Label: "elseif", Detail: "keyword"},
-				{Label: "while", Detail: "keyword"},
-				{Label: "for", Detail: "keyword"},
-				{Label: "return", Detail: "keyword"},
-				{Label: "break", Detail: "keyword"},
-				{Label: "continue", Detail: "keyword"},
-				{Label: "struct", Detail: "keyword"},
-				{Label: "import", Detail: "keyword"},
-				{Label: "true", Detail: "keyword"},
-				{Label: "false", Detail: "keyword"},
-			}
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil && doc.Index != nil {
-				if modName, prefix, ok := memberCompletionContext(doc.Text, request.Params.Position); ok {
-					if mems, ok := moduleMembersFor(doc, modName); ok {
+				s.logger.Errorf("Unable to parse the completion request with err: %s", err)
+			}
+			
+			items := analysis.KeywordCompletions(analysis.CompletionContext{})
+			request.Params.Position = s.state.Converter(request.Params.TextDocument.URI).FromClient(request.Params.Position)
+			if doc := s.state.GetDocument(request.Params.TextDocument.URI); doc != nil && doc.Index != nil {
+				ctx := doc.CompletionContext(request.Params.Position)
+				if ctx.Import {
+					items = analysis.ImportCompletions(ctx.Prefix)
+				} else if ctx.Literal {
+					items = []lsp.CompletionItem{}
+				} else if modName, prefix, ok := memberCompletionContext(doc.Text, request.Params.Position); ok {
+					if variants, ok := doc.Index.Enums[modName]; ok {
+						items = []lsp.CompletionItem{}
+						for _, v := range variants {
+							if prefix == "" || strings.HasPrefix(v.Name, prefix) {
+								items = append(items, lsp.CompletionItem{
+									Label:  v.Name,
+									Kind:   20,
+									Detail: "enum variant",
+								})
+							}
+						}
+					} else if members, ok := s.state.ModuleCompletions(request.Params.TextDocument.URI, modName); ok {
+						items = withPrefix(members, prefix)
+					} else if mems, ok := moduleMembersFor(doc, modName); ok {
 						items = []lsp.CompletionItem{}
 						for _, m := range mems {
 							if prefix == "" || strings.HasPrefix(m, prefix) {
-								items = append(items, lsp.CompletionItem{
+								item := lsp.CompletionItem{
 									Label:  m,
 									Detail: "module member",
-								})
+								}
+								if builtin, ok := doc.LookupBuiltin(modName, m); ok {
+									builtinCompletion(&item, builtin)
+								}
+								items = append(items, item)
 							}
 						}
+					} else if members, ok := doc.MemberCompletions(modName, request.Params.Position); ok {
+						items = withPrefix(members, prefix)
 					}
+				} else if fields, ok := doc.StructFieldCompletions(request.Params.Position); ok {
+					items = fields
 				} else {
+					snippets := doc.SnippetCompletions(ctx)
+					format := s.state.Settings().Format
+					for i := range snippets {
+						snippets[i].InsertText = format.Indent(snippets[i].InsertText)
+					}
+					items = append(analysis.KeywordCompletions(ctx), snippets...)
 					seen := map[string]bool{}
 					for _, def := range doc.CompletionAt(request.Params.Position) {
 						if def == nil {
@@ -245,6 +370,16 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 							Detail: def.Kind.String(),
 						})
 					}
+					for _, name := range globalBuiltins() {
+						if seen[name] {
+							continue
+						}
+						item := lsp.CompletionItem{Label: name}
+						if builtin, ok := doc.LookupBuiltin("", name); ok {
+							builtinCompletion(&item, builtin)
+						}
+						items = append(items, item)
+					}
 				}
 			}
 			msg := lsp.CompletionResponse{
@@ -257,18 +392,96 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 					Items:        items,
 				},
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
+		case "textDocument/signatureHelp":
+			var request lsp.SignatureHelpRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the signatureHelp request with err: %s", err)
+			}
+			
+			msg := lsp.SignatureHelpResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: s.state.GetDocument(request.Params.TextDocument.URI).SignatureHelp(s.state.Converter(request.Params.TextDocument.URI).FromClient(request.Params.Position)),
+			}
+			writeResponse(s.writer, msg)
+		case "textDocument/inlayHint":
+			var request lsp.InlayHintRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the inlayHint request with err: %s", err)
+			}
+			
+			conv := s.state.Converter(request.Params.TextDocument.URI)
+			hints := []lsp.InlayHint{}
+			if s.state.Settings().InlayHintsEnabled() {
+				hints = s.state.GetDocument(request.Params.TextDocument.URI).InlayHints(conv.RangeFromClient(request.Params.Range))
+			}
+			msg := lsp.InlayHintResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: inlayHintsToClient(conv, hints),
+			}
+			writeResponse(s.writer, msg)
+		case "textDocument/foldingRange":
+			var request lsp.FoldingRangeRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the foldingRange request with err: %s", err)
+			}
+			
+			msg := lsp.FoldingRangeResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: s.state.GetDocument(request.Params.TextDocument.URI).FoldingRanges(),
+			}
+			writeResponse(s.writer, msg)
+		case "textDocument/selectionRange":
+			var request lsp.SelectionRangeRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the selectionRange request with err: %s", err)
+			}
+			
+			conv := s.state.Converter(request.Params.TextDocument.URI)
+			ranges := s.state.GetDocument(request.Params.TextDocument.URI).SelectionRanges(positionsFromClient(conv, request.Params.Positions))
+			msg := lsp.SelectionRangeResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: selectionRangesToClient(conv, ranges),
+			}
+			writeResponse(s.writer, msg)
+		case "textDocument/documentHighlight":
+			var request lsp.DocumentHighlightRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the documentHighlight request with err: %s", err)
+			}
+			
+			conv := s.state.Converter(request.Params.TextDocument.URI)
+			highlights := s.state.GetDocument(request.Params.TextDocument.URI).DocumentHighlights(conv.FromClient(request.Params.Position))
+			msg := lsp.DocumentHighlightResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: highlightsToClient(conv, highlights),
+			}
+			writeResponse(s.writer, msg)
 		case "textDocument/definition":
 			var request lsp.DefinitionRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the definition request with err: %s", err)
+				s.logger.Errorf("Unable to parse the definition request with err: %s", err)
 			}
 			
+			convs := s.converters()
 			var locs []lsp.Location
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
-				for _, def := range doc.DefinitionsFor(request.Params.Position) {
-					locs = append(locs, lsp.Location{URI: def.URI, Range: def.Range})
-				}
+			if def := s.state.DefinitionAt(request.Params.TextDocument.URI, convs.of(request.Params.TextDocument.URI).FromClient(request.Params.Position)); def != nil {
+				locs = convs.locations([]lsp.Location{{URI: def.URI, Range: def.Range}})
 			}
 			msg := lsp.DefinitionResponse{
 				Response: lsp.Response{
@@ -277,18 +490,17 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 				},
 				Result: locs,
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
 		case "textDocument/declaration":
 			var request lsp.DeclarationRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the declaration request with err: %s", err)
+				s.logger.Errorf("Unable to parse the declaration request with err: %s", err)
 			}
 			
+			convs := s.converters()
 			var locs []lsp.Location
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
-				for _, def := range doc.DefinitionsFor(request.Params.Position) {
-					locs = append(locs, lsp.Location{URI: def.URI, Range: def.Range})
-				}
+			if def := s.state.DefinitionAt(request.Params.TextDocument.URI, convs.of(request.Params.TextDocument.URI).FromClient(request.Params.Position)); def != nil {
+				locs = convs.locations([]lsp.Location{{URI: def.URI, Range: def.Range}})
 			}
 			msg := lsp.DeclarationResponse{
 				Response: lsp.Response{
@@ -297,18 +509,17 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 				},
 				Result: locs,
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
 		case "textDocument/implementation":
 			var request lsp.ImplementationRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the implementation request with err: %s", err)
+				s.logger.Errorf("Unable to parse the implementation request with err: %s", err)
 			}
 			
+			convs := s.converters()
 			var locs []lsp.Location
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
-				for _, def := range doc.DefinitionsFor(request.Params.Position) {
-					locs = append(locs, lsp.Location{URI: def.URI, Range: def.Range})
-				}
+			if def := s.state.DefinitionAt(request.Params.TextDocument.URI, convs.of(request.Params.TextDocument.URI).FromClient(request.Params.Position)); def != nil {
+				locs = convs.locations([]lsp.Location{{URI: def.URI, Range: def.Range}})
 			}
 			msg := lsp.ImplementationResponse{
 				Response: lsp.Response{
@@ -317,22 +528,22 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 				},
 				Result: locs,
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
 		case "textDocument/documentSymbol":
 			var request lsp.DocumentSymbolRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the documentSymbol request with err: %s", err)
+				s.logger.Errorf("Unable to parse the documentSymbol request with err: %s", err)
 			}
 			
 			var symbols []lsp.DocumentSymbol
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil && doc.Index != nil {
+			if doc := s.state.GetDocument(request.Params.TextDocument.URI); doc != nil && doc.Index != nil {
 				for _, def := range doc.Index.Definitions {
 					if def == nil {
 						continue
 					}
 					symbols = append(symbols, lsp.DocumentSymbol{
 						Name:           def.Name,
-						Kind:           lspSymbolKind(def.Kind),
+						Kind:           analysis.SymbolKind(def.Kind),
 						Range:          def.Range,
 						SelectionRange: def.Range,
 					})
@@ -343,27 +554,18 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 					RPC: "2.0",
 					ID:  &request.ID,
 				},
-				Result: symbols,
+				Result: documentSymbolsToClient(s.state.Converter(request.Params.TextDocument.URI), symbols),
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
 		case "textDocument/references":
 			var request lsp.ReferenceRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the references request with err: %s", err)
+				s.logger.Errorf("Unable to parse the references request with err: %s", err)
 			}
 			
-			var locs []lsp.Location
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
-				refs := doc.ReferencesFor(request.Params.Position)
-				for _, ref := range refs {
-					locs = append(locs, lsp.Location{URI: ref.URI, Range: ref.Range})
-				}
-				if defs := doc.DefinitionsFor(request.Params.Position); len(defs) > 0 {
-					for _, def := range defs {
-						locs = append(locs, lsp.Location{URI: def.URI, Range: def.Range})
-					}
-				}
-			}
+			convs := s.converters()
+			def := s.state.DefinitionAt(request.Params.TextDocument.URI, convs.of(request.Params.TextDocument.URI).FromClient(request.Params.Position))
+			locs := convs.locations(s.state.ReferencesTo(def, request.Params.Context.IncludeDeclaration))
 			msg := lsp.ReferenceResponse{
 				Response: lsp.Response{
 					RPC: "2.0",
@@ -371,119 +573,173 @@ func handleMessage(logger *log.Logger,writer io.Writer, state analysis.State, me
 				},
 				Result: locs,
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
 		case "textDocument/rename":
 			var request lsp.RenameRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the rename request with err: %s", err)
+				s.logger.Errorf("Unable to parse the rename request with err: %s", err)
 			}
 			
-			changes := map[string][]lsp.TextEdit{}
-			if doc := state.GetDocument(request.Params.TextDocument.URI); doc != nil {
-				refs := doc.ReferencesFor(request.Params.Position)
-				defs := doc.DefinitionsFor(request.Params.Position)
-				for _, ref := range refs {
-					changes[ref.URI] = append(changes[ref.URI], lsp.TextEdit{
-						Range:   ref.Range,
-						NewText: request.Params.NewName,
-					})
-				}
-				for _, def := range defs {
-					changes[def.URI] = append(changes[def.URI], lsp.TextEdit{
-						Range:   def.Range,
-						NewText: request.Params.NewName,
-					})
-				}
-			}
+			convs := s.converters()
+			def := s.state.DefinitionAt(request.Params.TextDocument.URI, convs.of(request.Params.TextDocument.URI).FromClient(request.Params.Position))
 			msg := lsp.RenameResponse{
 				Response: lsp.Response{
 					RPC: "2.0",
 					ID:  &request.ID,
 				},
-				Result: lsp.WorkspaceEdit{Changes: changes},
+				Result: convs.edit(s.state.Rename(def, request.Params.NewName)),
 			}
-			writeResponse(writer, msg)
+			writeResponse(s.writer, msg)
 		case "textDocument/codeAction":
 			var request lsp.CodeActionRequest
 			if err := json.Unmarshal(content, &request); err != nil {
-				logger.Printf("Unable to parse the codeAction request with err: %s", err)
+				s.logger.Errorf("Unable to parse the codeAction request with err: %s", err)
 			}
 			
+			convs := s.converters()
+			uri := request.Params.TextDocument.URI
+			actions := s.state.GetDocument(uri).CodeActions(convs.of(uri).RangeFromClient(request.Params.Range))
 			msg := lsp.CodeActionResponse{
 				Response: lsp.Response{
 					RPC: "2.0",
 					ID:  &request.ID,
 				},
-				Result: codeActionsFromDiagnostics(request.Params.TextDocument.URI, request.Params.Context.Diagnostics),
+				Result: convs.codeActions(uri, actions),
 			}
-			writeResponse(writer, msg)
-		default:
-			logger.Printf("new unknown method: %s", method)
-	}
-}
-
-func lspSymbolKind(kind symbol.SymbolKind) int {
-	switch kind {
-	case symbol.FUNCTION:
-		return 12
-	case symbol.VARIABLE:
-		return 13
-	case symbol.CONSTANT:
-		return 14
-	case symbol.STRUCT:
-		return 23
-	case symbol.PARAMETER:
-		return 26
-	default:
-		return 13
-	}
-}
-
-func codeActionsFromDiagnostics(uri string, diags []lsp.Diagnostic) []lsp.CodeAction {
-	var actions []lsp.CodeAction
-	for _, d := range diags {
-		name, ok := extractUndefinedName(d.Message)
-		if ok && name != "" {
-			edit := lsp.WorkspaceEdit{
-				Changes: map[string][]lsp.TextEdit{
-					uri: {
-						{
-							Range: lsp.Range{
-								Start: lsp.Position{Line: 0, Character: 0},
-								End:   lsp.Position{Line: 0, Character: 0},
-							},
-							NewText: "let " + name + " = null;\n",
-						},
-					},
+			writeResponse(s.writer, msg)
+		case "textDocument/semanticTokens/full":
+			var request lsp.SemanticTokensRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the semanticTokens request with err: %s", err)
+			}
+			
+			msg := lsp.SemanticTokensResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: lsp.SemanticTokens{
+					Data: s.state.Converter(request.Params.TextDocument.URI).SemanticTokens(s.state.GetDocument(request.Params.TextDocument.URI).SemanticTokens(nil)),
+				},
+			}
+			writeResponse(s.writer, msg)
+		case "textDocument/semanticTokens/range":
+			var request lsp.SemanticTokensRangeRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the semanticTokens range request with err: %s", err)
+			}
+			
+			conv := s.state.Converter(request.Params.TextDocument.URI)
+			rng := conv.RangeFromClient(request.Params.Range)
+			msg := lsp.SemanticTokensResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: lsp.SemanticTokens{
+					Data: conv.SemanticTokens(s.state.GetDocument(request.Params.TextDocument.URI).SemanticTokens(&rng)),
+				},
+			}
+			writeResponse(s.writer, msg)
+		case "workspace/symbol":
+			var request lsp.WorkspaceSymbolRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the workspace symbol request with err: %s", err)
+			}
+			
+			msg := lsp.WorkspaceSymbolResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: s.converters().symbols(s.state.WorkspaceSymbols(request.Params.Query)),
+			}
+			writeResponse(s.writer, msg)
+		case "textDocument/prepareCallHierarchy":
+			var request lsp.CallHierarchyPrepareRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the prepareCallHierarchy request with err: %s", err)
+			}
+			
+			convs := s.converters()
+			uri := request.Params.TextDocument.URI
+			items := s.state.PrepareCallHierarchy(uri, convs.of(uri).FromClient(request.Params.Position))
+			msg := lsp.CallHierarchyPrepareResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: convs.callItems(items),
+			}
+			writeResponse(s.writer, msg)
+		case "callHierarchy/incomingCalls":
+			var request lsp.CallHierarchyCallsRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the incomingCalls request with err: %s", err)
+			}
+			
+			convs := s.converters()
+			msg := lsp.CallHierarchyIncomingCallsResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: convs.incomingCalls(s.state.IncomingCalls(convs.callItemFromClient(request.Params.Item))),
+			}
+			writeResponse(s.writer, msg)
+		case "callHierarchy/outgoingCalls":
+			var request lsp.CallHierarchyCallsRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the outgoingCalls request with err: %s", err)
+			}
+			
+			convs := s.converters()
+			msg := lsp.CallHierarchyOutgoingCallsResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
+				Result: convs.outgoingCalls(request.Params.Item.URI, s.state.OutgoingCalls(convs.callItemFromClient(request.Params.Item))),
+			}
+			writeResponse(s.writer, msg)
+		case "textDocument/codeLens":
+			var request lsp.CodeLensRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the codeLens request with err: %s", err)
+			}
+			
+			conv := s.state.Converter(request.Params.TextDocument.URI)
+			lenses := s.state.GetDocument(request.Params.TextDocument.URI).CodeLenses()
+			for i := range lenses {
+				lenses[i].Range = conv.RangeToClient(lenses[i].Range)
+			}
+			msg := lsp.CodeLensResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
 				},
+				Result: lenses,
 			}
-			actions = append(actions, lsp.CodeAction{
-				Title: "Create variable '" + name + "'",
-				Kind:  "quickfix",
-				Edit:  &edit,
+			writeResponse(s.writer, msg)
+		case "workspace/executeCommand":
+			var request lsp.ExecuteCommandRequest
+			if err := json.Unmarshal(content, &request); err != nil {
+				s.logger.Errorf("Unable to parse the executeCommand request with err: %s", err)
+			}
+			
+			if err := s.executeCommand(request.Params); err != nil {
+				writeResponse(s.writer, lsp.NewErrorResponse(request.ID, lsp.InvalidParams, err.Error()))
+				break
+			}
+			writeResponse(s.writer, lsp.NullResponse{
+				Response: lsp.Response{
+					RPC: "2.0",
+					ID:  &request.ID,
+				},
 			})
-		}
-	}
-	if len(actions) == 0 {
-		actions = append(actions, lsp.CodeAction{
-			Title: "No fixes available",
-			Kind:  "quickfix",
-		})
-	}
-	return actions
-}
-
-func extractUndefinedName(msg string) (string, bool) {
-	const needle = "undefined identifier: "
-	idx := strings.Index(msg, needle)
-	if idx == -1 {
-		return "", false
-	}
-	name := strings.TrimSpace(msg[idx+len(needle):])
-	if name == "" {
-		return "", false
+		default:
+			s.logger.Printf("new unknown method: %s", method)
 	}
-	return name, true
 }
 
 func contains(r lsp.Range, pos lsp.Position) bool {
@@ -504,11 +760,10 @@ func memberCompletionContext(text string, pos lsp.Position) (string, string, boo
 	if line == "" {
 		return "", "", false
 	}
-	runes := []rune(line)
-	if pos.Character > len(runes) {
-		pos.Character = len(runes)
+	if pos.Character > len(line) {
+		pos.Character = len(line)
 	}
-	prefix := string(runes[:pos.Character])
+	prefix := line[:pos.Character]
 	re := regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z0-9_]*)$`)
 	m := re.FindStringSubmatch(prefix)
 	if len(m) != 3 {
@@ -517,6 +772,17 @@ func memberCompletionContext(text string, pos lsp.Position) (string, string, boo
 	return m[1], m[2], true
 }
 
+// withPrefix returns the items whose labels start with prefix.
+func withPrefix(items []lsp.CompletionItem, prefix string) []lsp.CompletionItem {
+	matching := []lsp.CompletionItem{}
+	for _, item := range items {
+		if strings.HasPrefix(item.Label, prefix) {
+			matching = append(matching, item)
+		}
+	}
+	return matching
+}
+
 func getLine(text string, line int) string {
 	if line < 0 {
 		return ""
@@ -528,45 +794,48 @@ func getLine(text string, line int) string {
 	return lines[line]
 }
 
+// moduleMembersFor returns the members of the std module name refers to
+// in doc. Members of user modules come from State.ModuleCompletions.
 func moduleMembersFor(doc *analysis.Document, name string) ([]string, bool) {
+	path, imported := "", false
 	if doc != nil && doc.Index != nil {
-		if !doc.Index.Imports[name] {
-			if _, ok := stdModuleMembers()[name]; !ok {
-				return nil, false
-			}
-		}
+		path, imported = doc.Index.Imports[name]
+	}
+	if !imported {
+		path = name
+	}
+	if module, ok := evaluator.BuiltinModule(path); ok {
+		return sortedMembers(module.Members), true
 	}
-	mems, ok := stdModuleMembers()[name]
-	return mems, ok
+	return nil, false
 }
 
-var cachedStdMembers = buildStdModuleMembers()
-
-func stdModuleMembers() map[string][]string {
-	return cachedStdMembers
+// globalBuiltins returns the builtins callable without an import.
+func globalBuiltins() []string {
+	module, ok := evaluator.BuiltinModule("fmt")
+	if !ok {
+		return nil
+	}
+	return sortedMembers(module.Members)
 }
 
-func buildStdModuleMembers() map[string][]string {
-	m := map[string][]string{}
-	add := func(name string, members map[string]object.Object) {
-		var keys []string
-		for k := range members {
-			keys = append(keys, k)
-		}
-		m[name] = keys
+func sortedMembers(members map[string]object.Object) []string {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	add("fmt", general.Module().Members)
-	add("arrays", arrays.Module().Members)
-	add("fs", fs.Module().Members)
-	add("hash", hash.Module().Members)
-	add("json", JsonModule.JsonModule().Members)
-	add("math", math.Module().Members)
-	add("strings", stdstrings.Module().Members)
-	add("time", time.Module().Members)
-	add("os", osModule.Module().Members)
-	add("http", net.HttpModule().Members)
-	add("net", net.NetModule(nil).Members)
-
-	return m
+func builtinCompletion(item *lsp.CompletionItem, builtin *analysis.Builtin) {
+	item.Detail = builtin.Detail()
+	if _, ok := builtin.Value.(*object.Builtin); ok {
+		item.Kind = 3
+	} else {
+		item.Kind = 21
+	}
+	if sig := builtin.Signature(); sig != nil && sig.Doc != "" {
+		item.Documentation = &lsp.MarkupContent{Kind: "markdown", Value: sig.Doc}
+	}
 }
@@ -15,7 +15,7 @@ func NewInitializeResponse(id int) InitializeResponse {
 			},
 			Capabilities: ServerCapabilities{
 				TextDocumentSync: TextDocumentSyncOptions{
-					Change:    1,
+					Change:    2,
 					OpenClose: true,
 				},
 				HoverProvider: true,
@@ -29,11 +29,41 @@ func NewInitializeResponse(id int) InitializeResponse {
 				ReferencesProvider: true,
 				RenameProvider: true,
 				CodeActionProvider: true,
+				SignatureHelpProvider: &SignatureHelpOptions{
+					TriggerCharacters:   []string{"(", ","},
+					RetriggerCharacters: []string{")"},
+				},
+				InlayHintProvider: true,
+				FoldingRangeProvider: true,
+				SelectionRangeProvider: true,
+				DocumentHighlightProvider: true,
+				WorkspaceSymbolProvider: true,
+				CallHierarchyProvider: true,
 			},
 		},
 	}
 }
 
+// RequestCancelled is the error code for requests the client cancelled.
+const RequestCancelled = -32800
+
+func NewCancelledResponse(id int) ErrorResponse {
+	return NewErrorResponse(id, RequestCancelled, "request cancelled")
+}
+
+func NewErrorResponse(id, code int, message string) ErrorResponse {
+	return ErrorResponse{
+		Response: Response{
+			RPC: "2.0",
+			ID:  &id,
+		},
+		Error: ResponseError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
 func HoverResponseMessage(id int)HoverResponse{
 	return HoverResponse{
 		Response: Response{
@@ -41,7 +71,7 @@ func HoverResponseMessage(id int)HoverResponse{
 			ID: &id,
 		},
 		Result: Hover{
-			Contents: "Hello world",
+			Contents: MarkupContent{Kind: "plaintext", Value: "Hello world"},
 		},
 	}
 }
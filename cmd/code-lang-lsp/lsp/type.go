@@ -1,5 +1,7 @@
 package lsp
 
+import "encoding/json"
+
 type Request struct {
 	RPC    string `json:"jsonrpc"`
 	ID     int    `json:"id"`
@@ -16,6 +18,53 @@ type Response struct {
 	// Error ....
 }
 
+// ResponseError is the error member of a failed response.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type ErrorResponse struct {
+	Response
+	Error ResponseError `json:"error"`
+}
+
+// Error codes of failed responses.
+const (
+	InvalidRequest       = -32600
+	MethodNotFound       = -32601
+	InvalidParams        = -32602
+	ServerNotInitialized = -32002
+)
+
+// ClientResponse is the client's response to a request the server sent.
+type ClientResponse struct {
+	Response
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+// NullResponse is a successful response without a value, as to shutdown.
+type NullResponse struct {
+	Response
+	Result *struct{} `json:"result"`
+}
+
+// ServerRequest is a request the server sends the client.
+type ServerRequest struct {
+	Request
+	Params any `json:"params,omitempty"`
+}
+
+type CancelParams struct {
+	ID int `json:"id"`
+}
+
+type CancelRequestNotification struct {
+	Notification
+	Params CancelParams `json:"params"`
+}
+
 type Notification struct {
 	RPC    string `json:"jsonrpc"`
 	Method string `json:"method"`
@@ -29,8 +78,9 @@ type ClientInfo struct {
 }
 
 type InitializeParams struct {
-	ClientInfo *ClientInfo `json:"clientInfo"`
-	RootUri    string      `json:"rootUri"`
+	ClientInfo   *ClientInfo        `json:"clientInfo"`
+	RootUri      string             `json:"rootUri"`
+	Capabilities ClientCapabilities `json:"capabilities"`
 
 	//.... more to come
 }
@@ -41,6 +91,7 @@ type TextDocumentSyncOptions struct {
 }
 
 type ServerCapabilities struct {
+	PositionEncoding string `json:"positionEncoding,omitempty"`
 	TextDocumentSync TextDocumentSyncOptions `json:"textDocumentSync"`
 	HoverProvider bool `json:"hoverProvider"`
 	CompletionProvider *CompletionOptions `json:"completionProvider,omitempty"`
@@ -51,6 +102,18 @@ type ServerCapabilities struct {
 	ReferencesProvider bool `json:"referencesProvider,omitempty"`
 	RenameProvider bool `json:"renameProvider,omitempty"`
 	CodeActionProvider bool `json:"codeActionProvider,omitempty"`
+	SemanticTokensProvider *SemanticTokensOptions `json:"semanticTokensProvider,omitempty"`
+	SignatureHelpProvider *SignatureHelpOptions `json:"signatureHelpProvider,omitempty"`
+	InlayHintProvider bool `json:"inlayHintProvider,omitempty"`
+	FoldingRangeProvider bool `json:"foldingRangeProvider,omitempty"`
+	SelectionRangeProvider bool `json:"selectionRangeProvider,omitempty"`
+	DocumentHighlightProvider bool `json:"documentHighlightProvider,omitempty"`
+	WorkspaceSymbolProvider bool `json:"workspaceSymbolProvider,omitempty"`
+	CallHierarchyProvider bool `json:"callHierarchyProvider,omitempty"`
+	CodeLensProvider *CodeLensOptions `json:"codeLensProvider,omitempty"`
+	ExecuteCommandProvider *ExecuteCommandOptions `json:"executeCommandProvider,omitempty"`
+	DiagnosticProvider *DiagnosticOptions `json:"diagnosticProvider,omitempty"`
+	DocumentLinkProvider *DocumentLinkOptions `json:"documentLinkProvider,omitempty"`
 }
 
 type CompletionOptions struct {
@@ -100,8 +163,11 @@ type VersionTextDocumentIdentifier struct {
 	Version int `json:"version"`
 }
 
+// TextDocumentContentChange replaces Range with Text, or the whole
+// document when Range is nil.
 type TextDocumentContentChange struct {
-	Text string `json:"text"`
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
 }
 
 type DidChangeTextDocumentParams struct {
@@ -158,7 +224,7 @@ type HoverResponse struct {
 }
 
 type Hover struct {
-	Contents string `json:"contents"`
+	Contents MarkupContent `json:"contents"`
 	// Range Range `json:"range"`
 }
 
@@ -175,10 +241,16 @@ type Location struct {
 type Diagnostic struct {
 	Range    Range  `json:"range"`
 	Severity int    `json:"severity,omitempty"`
+	Code     string `json:"code,omitempty"`
 	Source   string `json:"source,omitempty"`
 	Message  string `json:"message"`
+	Tags     []int  `json:"tags,omitempty"`
 }
 
+// DiagnosticTagUnnecessary marks unused or unreachable code, which
+// editors fade out.
+const DiagnosticTagUnnecessary = 1
+
 type PublishDiagnosticsParams struct {
 	URI         string       `json:"uri"`
 	Diagnostics []Diagnostic `json:"diagnostics"`
@@ -193,9 +265,17 @@ type CompletionItem struct {
 	Label string `json:"label"`
 	Kind  int    `json:"kind,omitempty"`
 	Detail string `json:"detail,omitempty"`
+	Documentation *MarkupContent `json:"documentation,omitempty"`
 	InsertText string `json:"insertText,omitempty"`
+	InsertTextFormat int `json:"insertTextFormat,omitempty"`
 }
 
+// Insert text formats: plain text, or a snippet with tab stops.
+const (
+	InsertTextFormatPlainText = 1
+	InsertTextFormatSnippet   = 2
+)
+
 type CompletionList struct {
 	IsIncomplete bool             `json:"isIncomplete"`
 	Items        []CompletionItem `json:"items"`
@@ -278,8 +358,13 @@ type DocumentSymbolResponse struct {
 	Result []DocumentSymbol `json:"result"`
 }
 
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+}
+
 type ReferenceParams struct {
 	TextDocumentPositionParams
+	Context ReferenceContext `json:"context"`
 }
 
 type ReferenceRequest struct {
@@ -333,9 +418,11 @@ type CodeActionRequest struct {
 }
 
 type CodeAction struct {
-	Title string        `json:"title"`
-	Kind  string        `json:"kind,omitempty"`
-	Edit  *WorkspaceEdit `json:"edit,omitempty"`
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
 }
 
 type CodeActionResponse struct {
@@ -351,3 +438,523 @@ type DidCloseTextDocumentNotification struct {
 	Notification
 	Params DidCloseTextDocumentParams `json:"params"`
 }
+
+type SemanticTokensLegend struct {
+	TokenTypes     []string `json:"tokenTypes"`
+	TokenModifiers []string `json:"tokenModifiers"`
+}
+
+type SemanticTokensOptions struct {
+	Legend SemanticTokensLegend `json:"legend"`
+	Range  bool                 `json:"range"`
+	Full   bool                 `json:"full"`
+}
+
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SemanticTokensRequest struct {
+	Request
+	Params SemanticTokensParams `json:"params"`
+}
+
+type SemanticTokensRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type SemanticTokensRangeRequest struct {
+	Request
+	Params SemanticTokensRangeParams `json:"params"`
+}
+
+type SemanticTokens struct {
+	Data []int `json:"data"`
+}
+
+type SemanticTokensResponse struct {
+	Response
+	Result SemanticTokens `json:"result"`
+}
+
+// MarkupContent is text the client renders, as "plaintext" or "markdown".
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type SignatureHelpOptions struct {
+	TriggerCharacters   []string `json:"triggerCharacters,omitempty"`
+	RetriggerCharacters []string `json:"retriggerCharacters,omitempty"`
+}
+
+type SignatureHelpParams struct {
+	TextDocumentPositionParams
+}
+
+type SignatureHelpRequest struct {
+	Request
+	Params SignatureHelpParams `json:"params"`
+}
+
+type ParameterInformation struct {
+	Label string `json:"label"`
+}
+
+type SignatureInformation struct {
+	Label         string                 `json:"label"`
+	Documentation *MarkupContent         `json:"documentation,omitempty"`
+	Parameters    []ParameterInformation `json:"parameters"`
+}
+
+type SignatureHelp struct {
+	Signatures      []SignatureInformation `json:"signatures"`
+	ActiveSignature int                    `json:"activeSignature"`
+	ActiveParameter int                    `json:"activeParameter"`
+}
+
+// SignatureHelpResponse has a nil Result outside of a call.
+type SignatureHelpResponse struct {
+	Response
+	Result *SignatureHelp `json:"result"`
+}
+
+const (
+	InlayHintKindType      = 1
+	InlayHintKindParameter = 2
+)
+
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type InlayHintRequest struct {
+	Request
+	Params InlayHintParams `json:"params"`
+}
+
+type InlayHint struct {
+	Position     Position `json:"position"`
+	Label        string   `json:"label"`
+	Kind         int      `json:"kind,omitempty"`
+	PaddingLeft  bool     `json:"paddingLeft,omitempty"`
+	PaddingRight bool     `json:"paddingRight,omitempty"`
+}
+
+type InlayHintResponse struct {
+	Response
+	Result []InlayHint `json:"result"`
+}
+
+const (
+	FoldingRangeKindComment = "comment"
+	FoldingRangeKindImports = "imports"
+	FoldingRangeKindRegion  = "region"
+)
+
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type FoldingRangeRequest struct {
+	Request
+	Params FoldingRangeParams `json:"params"`
+}
+
+type FoldingRange struct {
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+type FoldingRangeResponse struct {
+	Response
+	Result []FoldingRange `json:"result"`
+}
+
+type SelectionRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Positions    []Position             `json:"positions"`
+}
+
+type SelectionRangeRequest struct {
+	Request
+	Params SelectionRangeParams `json:"params"`
+}
+
+type SelectionRange struct {
+	Range  Range           `json:"range"`
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
+
+type SelectionRangeResponse struct {
+	Response
+	Result []SelectionRange `json:"result"`
+}
+
+const (
+	DocumentHighlightKindText  = 1
+	DocumentHighlightKindRead  = 2
+	DocumentHighlightKindWrite = 3
+)
+
+type DocumentHighlightRequest struct {
+	Request
+	Params TextDocumentPositionParams `json:"params"`
+}
+
+type DocumentHighlight struct {
+	Range Range `json:"range"`
+	Kind  int   `json:"kind,omitempty"`
+}
+
+type DocumentHighlightResponse struct {
+	Response
+	Result []DocumentHighlight `json:"result"`
+}
+
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+type WorkspaceSymbolRequest struct {
+	Request
+	Params WorkspaceSymbolParams `json:"params"`
+}
+
+type SymbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	Location      Location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
+
+type WorkspaceSymbolResponse struct {
+	Response
+	Result []SymbolInformation `json:"result"`
+}
+
+// CallHierarchyItem is a function, or a file for calls made at its top
+// level.
+type CallHierarchyItem struct {
+	Name           string `json:"name"`
+	Kind           int    `json:"kind"`
+	Detail         string `json:"detail,omitempty"`
+	URI            string `json:"uri"`
+	Range          Range  `json:"range"`
+	SelectionRange Range  `json:"selectionRange"`
+}
+
+type CallHierarchyPrepareRequest struct {
+	Request
+	Params TextDocumentPositionParams `json:"params"`
+}
+
+type CallHierarchyPrepareResponse struct {
+	Response
+	Result []CallHierarchyItem `json:"result"`
+}
+
+type CallHierarchyCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+}
+
+type CallHierarchyCallsRequest struct {
+	Request
+	Params CallHierarchyCallsParams `json:"params"`
+}
+
+// CallHierarchyIncomingCall is a caller of an item; FromRanges are the
+// calls within it.
+type CallHierarchyIncomingCall struct {
+	From       CallHierarchyItem `json:"from"`
+	FromRanges []Range           `json:"fromRanges"`
+}
+
+type CallHierarchyIncomingCallsResponse struct {
+	Response
+	Result []CallHierarchyIncomingCall `json:"result"`
+}
+
+// CallHierarchyOutgoingCall is a function an item calls; FromRanges are
+// the calls within the item.
+type CallHierarchyOutgoingCall struct {
+	To         CallHierarchyItem `json:"to"`
+	FromRanges []Range           `json:"fromRanges"`
+}
+
+type CallHierarchyOutgoingCallsResponse struct {
+	Response
+	Result []CallHierarchyOutgoingCall `json:"result"`
+}
+
+type ClientCapabilities struct {
+	Workspace    *WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+	TextDocument *TextDocumentClientCapabilities `json:"textDocument,omitempty"`
+	General      *GeneralClientCapabilities      `json:"general,omitempty"`
+}
+
+type TextDocumentClientCapabilities struct {
+	// Diagnostic is set when the client pulls diagnostics.
+	Diagnostic *DynamicRegistrationCapability `json:"diagnostic,omitempty"`
+}
+
+type GeneralClientCapabilities struct {
+	// PositionEncodings lists the encodings the client can count columns
+	// in, in order of preference.
+	PositionEncodings []string `json:"positionEncodings,omitempty"`
+}
+
+type WorkspaceClientCapabilities struct {
+	DidChangeWatchedFiles  *DynamicRegistrationCapability `json:"didChangeWatchedFiles,omitempty"`
+	DidChangeConfiguration *DynamicRegistrationCapability `json:"didChangeConfiguration,omitempty"`
+	// Configuration is set when the client answers workspace/configuration.
+	Configuration bool `json:"configuration,omitempty"`
+}
+
+type DynamicRegistrationCapability struct {
+	DynamicRegistration bool `json:"dynamicRegistration"`
+}
+
+type Registration struct {
+	ID              string `json:"id"`
+	Method          string `json:"method"`
+	RegisterOptions any    `json:"registerOptions,omitempty"`
+}
+
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+}
+
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
+const (
+	FileCreated = 1
+	FileChanged = 2
+	FileDeleted = 3
+)
+
+type FileEvent struct {
+	URI  string `json:"uri"`
+	Type int    `json:"type"`
+}
+
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
+type DidChangeWatchedFilesNotification struct {
+	Notification
+	Params DidChangeWatchedFilesParams `json:"params"`
+}
+
+type Command struct {
+	Title     string `json:"title"`
+	Command   string `json:"command"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+type CodeLensOptions struct {
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+type CodeLens struct {
+	Range   Range    `json:"range"`
+	Command *Command `json:"command,omitempty"`
+}
+
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeLensRequest struct {
+	Request
+	Params CodeLensParams `json:"params"`
+}
+
+type CodeLensResponse struct {
+	Response
+	Result []CodeLens `json:"result"`
+}
+
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
+
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments"`
+}
+
+type ExecuteCommandRequest struct {
+	Request
+	Params ExecuteCommandParams `json:"params"`
+}
+
+const (
+	MessageTypeError   = 1
+	MessageTypeWarning = 2
+	MessageTypeInfo    = 3
+	MessageTypeLog     = 4
+)
+
+type ShowMessageParams struct {
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
+
+type ShowMessageNotification struct {
+	Notification
+	Params ShowMessageParams `json:"params"`
+}
+
+type DiagnosticOptions struct {
+	Identifier            string `json:"identifier,omitempty"`
+	InterFileDependencies bool   `json:"interFileDependencies"`
+	WorkspaceDiagnostics  bool   `json:"workspaceDiagnostics"`
+}
+
+type DocumentDiagnosticParams struct {
+	TextDocument     TextDocumentIdentifier `json:"textDocument"`
+	Identifier       string                 `json:"identifier,omitempty"`
+	PreviousResultID string                 `json:"previousResultId,omitempty"`
+}
+
+type DocumentDiagnosticRequest struct {
+	Request
+	Params DocumentDiagnosticParams `json:"params"`
+}
+
+// Kinds of diagnostic report: the full list, or word that the list the
+// client has under the same result ID is still current.
+const (
+	DiagnosticReportFull      = "full"
+	DiagnosticReportUnchanged = "unchanged"
+)
+
+// DocumentDiagnosticReport is a full report, with Items, or an unchanged
+// one, without.
+type DocumentDiagnosticReport struct {
+	Kind     string       `json:"kind"`
+	ResultID string       `json:"resultId,omitempty"`
+	Items    []Diagnostic `json:"items,omitempty"`
+}
+
+// MarshalJSON keeps the items of a full report even when there are none.
+func (r DocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	type report DocumentDiagnosticReport
+	if r.Kind != DiagnosticReportFull {
+		return json.Marshal(report(r))
+	}
+	items := r.Items
+	if items == nil {
+		items = []Diagnostic{}
+	}
+	return json.Marshal(struct {
+		Kind     string       `json:"kind"`
+		ResultID string       `json:"resultId,omitempty"`
+		Items    []Diagnostic `json:"items"`
+	}{r.Kind, r.ResultID, items})
+}
+
+type DocumentDiagnosticResponse struct {
+	Response
+	Result DocumentDiagnosticReport `json:"result"`
+}
+
+type PreviousResultID struct {
+	URI   string `json:"uri"`
+	Value string `json:"value"`
+}
+
+type WorkspaceDiagnosticParams struct {
+	Identifier        string             `json:"identifier,omitempty"`
+	PreviousResultIDs []PreviousResultID `json:"previousResultIds"`
+}
+
+type WorkspaceDiagnosticRequest struct {
+	Request
+	Params WorkspaceDiagnosticParams `json:"params"`
+}
+
+// WorkspaceDocumentDiagnosticReport is the report of one document in the
+// workspace. Version is that of the open document, or null.
+type WorkspaceDocumentDiagnosticReport struct {
+	URI     string `json:"uri"`
+	Version *int   `json:"version"`
+	DocumentDiagnosticReport
+}
+
+// MarshalJSON flattens the document's report into the object.
+func (r WorkspaceDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	report, err := json.Marshal(r.DocumentDiagnosticReport)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(report, &fields); err != nil {
+		return nil, err
+	}
+	fields["uri"], _ = json.Marshal(r.URI)
+	fields["version"], _ = json.Marshal(r.Version)
+	return json.Marshal(fields)
+}
+
+type WorkspaceDiagnosticReport struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items"`
+}
+
+type WorkspaceDiagnosticResponse struct {
+	Response
+	Result WorkspaceDiagnosticReport `json:"result"`
+}
+
+type DocumentLinkOptions struct {
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+type DocumentLink struct {
+	Range   Range  `json:"range"`
+	Target  string `json:"target,omitempty"`
+	Tooltip string `json:"tooltip,omitempty"`
+}
+
+type DocumentLinkParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentLinkRequest struct {
+	Request
+	Params DocumentLinkParams `json:"params"`
+}
+
+type DocumentLinkResponse struct {
+	Response
+	Result []DocumentLink `json:"result"`
+}
+
+type ConfigurationItem struct {
+	ScopeURI string `json:"scopeUri,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
+
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+type DidChangeConfigurationParams struct {
+	Settings json.RawMessage `json:"settings"`
+}
+
+type DidChangeConfigurationNotification struct {
+	Notification
+	Params DidChangeConfigurationParams `json:"params"`
+}
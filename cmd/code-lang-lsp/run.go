@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/analysis"
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/repl"
+	"github.com/walonCode/code-lang/internal/std/general"
+)
+
+// runError matches the error that stopped a program, as repl.Execute
+// reports it.
+var runError = regexp.MustCompile(`\[Line (\d+), Column (\d+)\] (?:ERROR: )?(.*)`)
+
+// runTimeout is how long a program started from a code lens may run
+// before it is stopped.
+const runTimeout = 30 * time.Second
+
+// executeCommand starts the file or test a code lens asks for and returns
+// once it is running. The program runs in-process, off the message loop;
+// when it finishes a message reports what it printed and, when it failed,
+// a diagnostic marks the line of the error.
+func (s *Server) executeCommand(params lsp.ExecuteCommandParams) error {
+	if params.Command != analysis.RunFileCommand && params.Command != analysis.RunTestCommand {
+		return fmt.Errorf("unknown command %q", params.Command)
+	}
+
+	var uri, test string
+	if len(params.Arguments) < 1 || json.Unmarshal(params.Arguments[0], &uri) != nil {
+		return errors.New("expected a document URI")
+	}
+	doc := s.state.GetDocument(uri)
+	if doc == nil {
+		return fmt.Errorf("unknown document %s", uri)
+	}
+
+	name, passed := filepath.Base(analysis.URIToPath(uri)), " finished"
+	source := doc.Text
+	if params.Command == analysis.RunTestCommand {
+		if len(params.Arguments) < 2 || json.Unmarshal(params.Arguments[1], &test) != nil {
+			return errors.New("expected a test name")
+		}
+		if !slices.Contains(doc.Tests(), test) {
+			return fmt.Errorf("no test %s in %s", test, name)
+		}
+		name, passed = test, " passed"
+		source += "\n" + test + "();\n"
+	}
+
+	if len(doc.ParserErrors) != 0 {
+		s.showMessage(lsp.MessageTypeError, name+" has syntax errors")
+		return nil
+	}
+
+	s.runs.start(func(ctx context.Context) {
+		output := run(ctx, analysis.URIToPath(uri), source, s.state.ModulePaths())
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			s.showMessage(lsp.MessageTypeError, report(fmt.Sprintf("%s stopped after %s", name, s.runs.timeout), output))
+			return
+		case context.Canceled:
+			return
+		}
+		s.reportRun(doc, name, passed, output)
+	})
+	return nil
+}
+
+// reportRun shows the outcome of running doc, with a diagnostic on the
+// line of the error that stopped it.
+func (s *Server) reportRun(doc *analysis.Document, name, passed, output string) {
+	m := runError.FindStringSubmatch(output)
+	if m == nil {
+		s.showMessage(lsp.MessageTypeInfo, report(name+passed, output))
+		return
+	}
+
+	s.showMessage(lsp.MessageTypeError, report(name+" failed: "+m[3], output))
+	line, _ := strconv.Atoi(m[1])
+	col, _ := strconv.Atoi(m[2])
+	if lines := strings.Split(doc.Text, "\n"); line >= 1 && line <= len(lines) {
+		text := lines[line-1]
+		failure := lsp.Diagnostic{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: line - 1, Character: min(max(col-1, 0), len(text))},
+				End:   lsp.Position{Line: line - 1, Character: len(text)},
+			},
+			Severity: 1,
+			Source:   "run",
+			Message:  m[3],
+		}
+		s.publish(doc, append(s.state.Diagnostics(doc), failure))
+	}
+}
+
+// run executes source as the file at path with its output captured, until
+// it finishes, calls os.exit or ctx is done, searching modulePaths for
+// imports. Standard input carries the protocol, so the program reads an
+// empty one. The plugins it started are closed after it.
+func run(ctx context.Context, path, source string, modulePaths []string) string {
+	defer evaluator.ClosePlugins()
+	defer func(stdin io.Reader) { general.Stdin = stdin }(general.Stdin)
+	general.Stdin = bytes.NewReader(nil)
+
+	var out bytes.Buffer
+	repl.ExecuteFileContext(ctx, path, source, modulePaths, &out)
+	return out.String()
+}
+
+// runner runs the programs code lenses start one at a time, since they
+// share the redirected standard input and output, each on a goroutine
+// and under a deadline.
+type runner struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	ctx     context.Context
+	stop    context.CancelFunc
+	timeout time.Duration
+	// inline runs programs on the caller's goroutine, so session tests see
+	// their results in a fixed order.
+	inline bool
+}
+
+func newRunner() *runner {
+	ctx, stop := context.WithCancel(context.Background())
+	return &runner{ctx: ctx, stop: stop, timeout: runTimeout}
+}
+
+func (r *runner) start(program func(ctx context.Context)) {
+	if r.inline {
+		r.run(program)
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(program)
+	}()
+}
+
+func (r *runner) run(program func(ctx context.Context)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx, cancel := context.WithTimeout(r.ctx, r.timeout)
+	defer cancel()
+	program(ctx)
+}
+
+// close stops the programs still running or waiting to and waits for
+// them.
+func (r *runner) close() {
+	r.stop()
+	r.wg.Wait()
+}
+
+// report is a summary followed by what the program printed.
+func report(summary, output string) string {
+	if output = strings.TrimSpace(output); output != "" {
+		return summary + "\n" + output
+	}
+	return summary
+}
+
+func (s *Server) showMessage(typ int, message string) {
+	writeResponse(s.writer, lsp.ShowMessageNotification{
+		Notification: lsp.Notification{
+			RPC:    "2.0",
+			Method: "window/showMessage",
+		},
+		Params: lsp.ShowMessageParams{Type: typ, Message: message},
+	})
+}
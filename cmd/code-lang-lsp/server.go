@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/analysis"
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/jsonrpc"
+)
+
+// analysisDelay is how long the server waits after the last edit of a
+// document before re-analyzing it.
+const analysisDelay = 150 * time.Millisecond
+
+// Server is a language server for one client, speaking JSON-RPC over any
+// reader and writer.
+type Server struct {
+	logger    *serverLog
+	state     *analysis.State
+	writer    *syncWriter
+	cancelled *cancellations
+	runs      *runner
+
+	// Lifecycle, touched only by the message loop.
+	initialized bool
+	shutdown    bool
+	exited      bool
+	watchFiles  bool
+	// pullDiagnostics is set when the client asks for diagnostics rather
+	// than having them published.
+	pullDiagnostics bool
+	// The client reports configuration changes once asked to, and answers
+	// workspace/configuration requests.
+	watchConfiguration bool
+	pullConfiguration  bool
+
+	// Requests sent to the client that await its response.
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]func(result json.RawMessage, err *lsp.ResponseError)
+}
+
+func NewServer(logger *log.Logger) *Server {
+	return &Server{
+		logger:    newServerLog(logger),
+		state:     analysis.NewState(),
+		cancelled: newCancellations(),
+		runs:      newRunner(),
+		pending:   make(map[int]func(json.RawMessage, *lsp.ResponseError)),
+	}
+}
+
+type message struct {
+	method  string
+	content []byte
+	env     envelope
+}
+
+// envelope holds the members that tell requests, notifications and
+// responses apart.
+type envelope struct {
+	ID     *int               `json:"id"`
+	Result json.RawMessage    `json:"result"`
+	Error  *lsp.ResponseError `json:"error"`
+}
+
+// Serve handles the messages read from r, writing to w, until the client
+// sends exit or closes r. It returns the exit status the protocol asks
+// for: 0 when a shutdown request came first, 1 otherwise.
+func (s *Server) Serve(r io.Reader, w io.Writer) int {
+	s.writer = &syncWriter{w: w}
+
+	scanner := bufio.NewScanner(r)
+	// Allow larger LSP messages than the default 64K token limit.
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	scanner.Split(jsonrpc.Spilt)
+
+	// The reader goroutine only decodes messages, so cancellations are
+	// seen while earlier requests are still being handled.
+	messages := make(chan message, 64)
+	go func() {
+		defer close(messages)
+		for scanner.Scan() {
+			method, content, err := jsonrpc.DecodeMessage(scanner.Bytes())
+			if err != nil {
+				s.logger.Errorf("Got an error: %s ", err)
+				continue
+			}
+			if method == "$/cancelRequest" {
+				var request lsp.CancelRequestNotification
+				if err := json.Unmarshal(content, &request); err == nil {
+					s.cancelled.add(request.Params.ID)
+				}
+				continue
+			}
+
+			var env envelope
+			if err := json.Unmarshal(content, &env); err != nil {
+				s.logger.Printf("Unable to parse message: %s", err)
+				continue
+			}
+			if method != "" && env.ID != nil {
+				s.cancelled.queue(*env.ID)
+			}
+			messages <- message{method: method, content: append([]byte(nil), content...), env: env}
+		}
+
+		if err := scanner.Err(); err != nil {
+			s.logger.Errorf("scanner error: %v", err)
+		}
+	}()
+
+	for msg := range messages {
+		env := msg.env
+		if msg.method == "" {
+			if env.ID != nil {
+				s.resolve(*env.ID, env.Result, env.Error)
+			}
+			continue
+		}
+
+		isRequest := env.ID != nil
+		switch {
+		case msg.method == "exit":
+			s.exited = true
+		case isRequest && s.cancelled.has(*env.ID):
+			s.logger.Debugf("cancelled request %d (%s)", *env.ID, msg.method)
+			writeResponse(s.writer, lsp.NewCancelledResponse(*env.ID))
+		case !s.initialized && msg.method != "initialize":
+			if isRequest {
+				writeResponse(s.writer, lsp.NewErrorResponse(*env.ID, lsp.ServerNotInitialized, "server not initialized"))
+			}
+		case s.shutdown:
+			if isRequest {
+				writeResponse(s.writer, lsp.NewErrorResponse(*env.ID, lsp.InvalidRequest, "server is shutting down"))
+			}
+		default:
+			s.handleMessage(msg.method, msg.content)
+		}
+		if isRequest {
+			s.cancelled.done(*env.ID)
+		}
+		if s.exited {
+			break
+		}
+	}
+
+	// Nothing may write to w once Serve returns.
+	s.state.Close()
+	s.runs.close()
+
+	if s.shutdown && s.exited {
+		return 0
+	}
+	return 1
+}
+
+// request sends a request to the client. handle is called by the message
+// loop with the result, or the error, once the client responds.
+func (s *Server) request(method string, params any, handle func(result json.RawMessage, err *lsp.ResponseError)) {
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.pending[id] = handle
+	s.mu.Unlock()
+
+	writeResponse(s.writer, lsp.ServerRequest{
+		Request: lsp.Request{RPC: "2.0", ID: id, Method: method},
+		Params:  params,
+	})
+}
+
+// resolve passes the client's response to the request with id to its
+// handler.
+func (s *Server) resolve(id int, result json.RawMessage, err *lsp.ResponseError) {
+	s.mu.Lock()
+	handle, ok := s.pending[id]
+	delete(s.pending, id)
+	s.mu.Unlock()
+
+	if !ok {
+		s.logger.Printf("response to unknown request %d", id)
+		return
+	}
+	if handle != nil {
+		handle(result, err)
+	}
+}
+
+// registerFileWatchers asks the client to report changes to .cl files made
+// outside the editor, so the import graph follows them.
+func (s *Server) registerFileWatchers() {
+	params := lsp.RegistrationParams{
+		Registrations: []lsp.Registration{{
+			ID:     "watch-cl-files",
+			Method: "workspace/didChangeWatchedFiles",
+			RegisterOptions: lsp.DidChangeWatchedFilesRegistrationOptions{
+				Watchers: []lsp.FileSystemWatcher{{GlobPattern: "**/*.cl"}},
+			},
+		}},
+	}
+	s.request("client/registerCapability", params, func(_ json.RawMessage, err *lsp.ResponseError) {
+		if err != nil {
+			s.logger.Printf("client refused to watch files: %s", err.Message)
+		}
+	})
+}
+
+// registerConfigurationChanges asks the client to report changes to the
+// settings.
+func (s *Server) registerConfigurationChanges() {
+	params := lsp.RegistrationParams{
+		Registrations: []lsp.Registration{{
+			ID:              "watch-configuration",
+			Method:          "workspace/didChangeConfiguration",
+			RegisterOptions: map[string]string{"section": analysis.SettingsSection},
+		}},
+	}
+	s.request("client/registerCapability", params, func(_ json.RawMessage, err *lsp.ResponseError) {
+		if err != nil {
+			s.logger.Errorf("client refused to report configuration changes: %s", err.Message)
+		}
+	})
+}
+
+// fetchSettings asks the client for the server's settings and applies
+// them once it answers.
+func (s *Server) fetchSettings() {
+	params := lsp.ConfigurationParams{Items: []lsp.ConfigurationItem{{Section: analysis.SettingsSection}}}
+	s.request("workspace/configuration", params, func(result json.RawMessage, err *lsp.ResponseError) {
+		if err != nil {
+			s.logger.Errorf("client did not send its configuration: %s", err.Message)
+			return
+		}
+		var values []json.RawMessage
+		if err := json.Unmarshal(result, &values); err != nil || len(values) == 0 {
+			s.logger.Errorf("unable to parse the configuration: %v", err)
+			return
+		}
+		s.configure(values[0])
+	})
+}
+
+// configure applies settings the client sent, as analysis.Settings
+// describes them. A null section restores the defaults.
+func (s *Server) configure(raw json.RawMessage) {
+	var settings analysis.Settings
+	if len(raw) != 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &settings); err != nil {
+			s.showMessage(lsp.MessageTypeError, "invalid code-lang settings: "+err.Error())
+			return
+		}
+	}
+
+	if settings.Log.File != "" {
+		path, err := s.state.ResolvePath(settings.Log.File)
+		if err == nil {
+			err = s.logger.SetFile(path)
+		}
+		if err != nil {
+			s.showMessage(lsp.MessageTypeWarning, "cannot log to "+settings.Log.File+": "+err.Error())
+		}
+	}
+	level := settings.Log.Level
+	if level == "" {
+		level = "info"
+	}
+	if err := s.logger.SetLevel(level); err != nil {
+		s.showMessage(lsp.MessageTypeWarning, err.Error())
+	}
+
+	docs, err := s.state.Configure(settings)
+	if err != nil {
+		s.showMessage(lsp.MessageTypeError, "invalid code-lang settings: "+err.Error())
+		return
+	}
+	for _, doc := range docs {
+		s.publishDiagnostics(doc)
+	}
+}
+
+func (s *Server) publishDiagnostics(doc *analysis.Document) {
+	if s.pullDiagnostics {
+		return
+	}
+	s.publish(doc, s.state.Diagnostics(doc))
+}
+
+// publish sends diags, whose positions count bytes, as the diagnostics of
+// doc.
+func (s *Server) publish(doc *analysis.Document, diags []lsp.Diagnostic) {
+	writeResponse(s.writer, lsp.PublishDiagnosticsNotification{
+		Notification: lsp.Notification{
+			RPC:    "2.0",
+			Method: "textDocument/publishDiagnostics",
+		},
+		Params: lsp.PublishDiagnosticsParams{
+			URI:         doc.URI,
+			Diagnostics: diagnosticsToClient(analysis.NewConverter(doc.Text, s.state.Encoding), diags),
+		},
+	})
+}
+
+// diagnosticReport is the pulled report of diags, the diagnostics of doc.
+// Its result ID hashes what the client is sent, so a client still holding
+// previousID is told nothing changed.
+func (s *Server) diagnosticReport(doc *analysis.Document, diags []lsp.Diagnostic, previousID string) lsp.DocumentDiagnosticReport {
+	items := diagnosticsToClient(analysis.NewConverter(doc.Text, s.state.Encoding), diags)
+	data, _ := json.Marshal(items)
+	hash := fnv.New64a()
+	hash.Write(data)
+	id := strconv.FormatUint(hash.Sum64(), 16)
+	if id == previousID {
+		return lsp.DocumentDiagnosticReport{Kind: lsp.DiagnosticReportUnchanged, ResultID: id}
+	}
+	return lsp.DocumentDiagnosticReport{Kind: lsp.DiagnosticReportFull, ResultID: id, Items: items}
+}
+
+func writeResponse(writer io.Writer, msg any) {
+	reply, _ := jsonrpc.EncodeMessage(msg)
+	writer.Write([]byte(reply))
+}
+
+// syncWriter serializes writes from the message loop and from background
+// analysis publishing diagnostics.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// cancellations records the requests read but not yet answered, and
+// which of them the client cancelled before the server got to them.
+type cancellations struct {
+	mu sync.Mutex
+	// ids maps each pending request ID to whether it was cancelled.
+	ids map[int]bool
+}
+
+func newCancellations() *cancellations {
+	return &cancellations{ids: make(map[int]bool)}
+}
+
+// queue records a request that was read.
+func (c *cancellations) queue(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids[id] = false
+}
+
+// add cancels a pending request. Cancels for requests that were already
+// answered, or never sent, are ignored.
+func (c *cancellations) add(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.ids[id]; ok {
+		c.ids[id] = true
+	}
+}
+
+func (c *cancellations) has(id int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ids[id]
+}
+
+// done forgets a request once it is answered.
+func (c *cancellations) done(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.ids, id)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/analysis"
+	"github.com/walonCode/code-lang/cmd/code-lang-lsp/lsp"
+	"github.com/walonCode/code-lang/internal/jsonrpc"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the session tests")
+
+// TestSessions replays the client messages of each testdata/sessions/*.jsonl
+// file, one JSON message per line, and compares what the server writes
+// back, and its exit status, with the matching .golden file.
+func TestSessions(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "sessions", "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no sessions found")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		t.Run(name, func(t *testing.T) {
+			input, err := frameSession(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output bytes.Buffer
+			server := NewServer(log.New(io.Discard, "", 0))
+			server.runs.inline = true
+			status := server.Serve(bytes.NewReader(input), &output)
+			got, err := transcript(output.Bytes(), status)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(file, ".jsonl") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%s; run go test -update to create it", err)
+			}
+			if got != string(want) {
+				t.Errorf("session output differs from %s; run go test -update if the change is intended.\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// frameSession reads the messages of a session file, skipping blank lines
+// and # comments, and frames them as the client would send them.
+func frameSession(file string) ([]byte, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return frameMessages(file, string(content))
+}
+
+// frameMessages frames the JSON messages of a session, one per line, with
+// name used in errors.
+func frameMessages(name, session string) ([]byte, error) {
+	var framed bytes.Buffer
+	for i, line := range strings.Split(session, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			return nil, fmt.Errorf("%s:%d: invalid JSON", name, i+1)
+		}
+		fmt.Fprintf(&framed, "Content-Length: %d\r\n\r\n%s", len(line), line)
+	}
+	return framed.Bytes(), nil
+}
+
+// transcript renders the server's messages as indented JSON with sorted
+// keys, followed by its exit status, so golden files diff well.
+func transcript(output []byte, status int) (string, error) {
+	var out strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	scanner.Split(jsonrpc.Spilt)
+	for scanner.Scan() {
+		_, content, err := jsonrpc.DecodeMessage(scanner.Bytes())
+		if err != nil {
+			return "", err
+		}
+		var msg any
+		if err := json.Unmarshal(content, &msg); err != nil {
+			return "", err
+		}
+		pretty, err := json.MarshalIndent(msg, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		out.Write(pretty)
+		out.WriteString("\n\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&out, "exit status %d\n", status)
+	return out.String(), nil
+}
+
+func TestServerRequestResponse(t *testing.T) {
+	server := NewServer(log.New(io.Discard, "", 0))
+	var output bytes.Buffer
+	server.writer = &syncWriter{w: &output}
+
+	var result string
+	var failure *lsp.ResponseError
+	server.request("workspace/configuration", map[string]any{"items": []any{}}, func(raw json.RawMessage, err *lsp.ResponseError) {
+		json.Unmarshal(raw, &result)
+		failure = err
+	})
+	server.request("window/showMessageRequest", nil, func(_ json.RawMessage, err *lsp.ResponseError) {
+		failure = err
+	})
+
+	got, err := transcript(output.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `"id": 1`) || !strings.Contains(got, `"method": "workspace/configuration"`) || !strings.Contains(got, `"id": 2`) {
+		t.Fatalf("requests not written as expected:\n%s", got)
+	}
+
+	server.resolve(1, json.RawMessage(`"ok"`), nil)
+	if result != "ok" || failure != nil {
+		t.Errorf("wrong result: %q %v", result, failure)
+	}
+	server.resolve(2, nil, &lsp.ResponseError{Code: lsp.MethodNotFound, Message: "nope"})
+	if failure == nil || failure.Code != lsp.MethodNotFound {
+		t.Errorf("expected the error to reach the handler, got %v", failure)
+	}
+	if len(server.pending) != 0 {
+		t.Errorf("expected no pending requests, got %d", len(server.pending))
+	}
+}
+
+func TestRunStopsAtDeadline(t *testing.T) {
+	server := NewServer(log.New(io.Discard, "", 0))
+	server.runs.timeout = 50 * time.Millisecond
+	var output bytes.Buffer
+	server.writer = &syncWriter{w: &output}
+
+	uri := "file:///tmp/forever.cl"
+	server.state.OpenDocument(uri, "while (true) {\n\tlet n = 1;\n};\n")
+	arg, _ := json.Marshal(uri)
+	started := time.Now()
+	if err := server.executeCommand(lsp.ExecuteCommandParams{Command: analysis.RunFileCommand, Arguments: []json.RawMessage{arg}}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed >= server.runs.timeout {
+		t.Errorf("executeCommand waited %s for the program", elapsed)
+	}
+
+	server.runs.wg.Wait()
+	got, err := transcript(output.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "forever.cl stopped after 50ms") {
+		t.Errorf("expected a message that the program was stopped, got:\n%s", got)
+	}
+}
+
+// Analyses scheduled by edits must not write once Serve has returned; run
+// with -race.
+func TestServeStopsScheduledAnalysis(t *testing.T) {
+	input, err := frameMessages(t.Name(), `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"","capabilities":{}}}
+{"jsonrpc":"2.0","method":"initialized","params":{}}
+{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///session/edit.cl","languageId":"code-lang","version":1,"text":"let x = 1;"}}}
+{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///session/edit.cl","version":2},"contentChanges":[{"text":"let x = y;"}]}}
+{"jsonrpc":"2.0","method":"exit"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	NewServer(log.New(io.Discard, "", 0)).Serve(bytes.NewReader(input), &output)
+	written := output.Len()
+	time.Sleep(2 * analysisDelay)
+	if output.Len() != written {
+		t.Errorf("the server wrote %d bytes after Serve returned", output.Len()-written)
+	}
+}
+
+// Closing a document with an analysis still scheduled must not keep Serve
+// from returning.
+func TestServeExitsAfterCloseWithScheduledAnalysis(t *testing.T) {
+	input, err := frameMessages(t.Name(), `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"","capabilities":{}}}
+{"jsonrpc":"2.0","method":"initialized","params":{}}
+{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///session/close.cl","languageId":"code-lang","version":1,"text":"let x = 1;"}}}
+{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///session/close.cl","version":2},"contentChanges":[{"text":"let x = y;"}]}}
+{"jsonrpc":"2.0","method":"textDocument/didClose","params":{"textDocument":{"uri":"file:///session/close.cl"}}}
+{"jsonrpc":"2.0","method":"exit"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		var output bytes.Buffer
+		NewServer(log.New(io.Discard, "", 0)).Serve(bytes.NewReader(input), &output)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after exit")
+	}
+}
+
+func TestCancellations(t *testing.T) {
+	c := newCancellations()
+	c.queue(1)
+	c.queue(2)
+	c.add(1)
+	c.add(3)
+	if !c.has(1) || c.has(2) || c.has(3) {
+		t.Errorf("wrong cancellations: %v", c.ids)
+	}
+
+	c.done(1)
+	c.done(2)
+	c.add(2)
+	if len(c.ids) != 0 {
+		t.Errorf("expected answered and unknown requests to be forgotten, got %v", c.ids)
+	}
+}
+
+// Cancels that arrive after the response must not be kept for the rest of
+// the session.
+func TestServeForgetsAnsweredRequests(t *testing.T) {
+	input, err := frameMessages(t.Name(), `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"","capabilities":{}}}
+{"jsonrpc":"2.0","method":"initialized","params":{}}
+{"jsonrpc":"2.0","id":2,"method":"shutdown"}
+{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}
+{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":7}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(log.New(io.Discard, "", 0))
+	var output bytes.Buffer
+	server.Serve(bytes.NewReader(input), &output)
+	if len(server.cancelled.ids) != 0 {
+		t.Errorf("expected no pending requests, got %v", server.cancelled.ids)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/object"
+)
+
+const docUsage = `usage:
+  code-lang doc                  list the std modules
+  code-lang doc <module>         show every member of a module
+  code-lang doc <module>.<name>  show one member
+  code-lang doc <name>           show a global builtin such as print`
+
+// globalModule holds the builtins callable without an import.
+const globalModule = "fmt"
+
+func runDoc(args []string) {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, docUsage)
+		os.Exit(1)
+	}
+
+	if len(args) == 0 {
+		for _, name := range evaluator.BuiltinModuleNames() {
+			if name == globalModule {
+				fmt.Printf("%-10s builtins available without an import\n", name)
+			} else {
+				fmt.Println(name)
+			}
+		}
+		return
+	}
+
+	query := args[0]
+	if module, ok := evaluator.BuiltinModule(query); ok {
+		prefix := query + "."
+		if query == globalModule {
+			prefix = ""
+		}
+		names := make([]string, 0, len(module.Members))
+		for name := range module.Members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			if i > 0 {
+				fmt.Println()
+			}
+			printMemberDoc(prefix+name, module.Members[name])
+		}
+		return
+	}
+
+	path, name := globalModule, query
+	if i := strings.LastIndex(query, "."); i >= 0 {
+		path, name = query[:i], query[i+1:]
+	}
+	module, ok := evaluator.BuiltinModule(path)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: no std module or builtin named %s\n", query)
+		os.Exit(1)
+	}
+	value, ok := module.Members[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: module %s has no member %s\n", path, name)
+		os.Exit(1)
+	}
+	printMemberDoc(query, value)
+}
+
+func printMemberDoc(name string, value object.Object) {
+	fn, ok := value.(*object.Builtin)
+	if !ok {
+		fmt.Printf("%s = %s\n", name, value.Inspect())
+		return
+	}
+	if fn.Signature == nil {
+		fmt.Printf("%s(...)\n", name)
+		return
+	}
+	fmt.Println(fn.Signature.Format(name))
+	if fn.Signature.Doc != "" {
+		fmt.Printf("    %s\n", fn.Signature.Doc)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/lint"
+	"github.com/walonCode/code-lang/internal/parser"
+)
+
+const lintUsage = `usage:
+  code-lang lint [--fix] [path...]   check .cl files against the lint rules
+  code-lang lint --rules             list the rules and their default severity
+
+Rules are configured under "lint" in code-lang.json. With --fix, the fixes
+rules offer are applied to the files. The exit status is 1 when a problem
+with severity error remains.`
+
+func runLint(args []string) {
+	fix := false
+	var paths []string
+	for _, arg := range args {
+		switch arg {
+		case "--fix":
+			fix = true
+		case "--rules":
+			for _, rule := range lint.Rules() {
+				fmt.Printf("%-22s %-8s %s\n", rule.Name, rule.Severity, rule.Doc)
+			}
+			return
+		case "-h", "--help":
+			fmt.Println(lintUsage)
+			return
+		default:
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var files []string
+	for _, path := range paths {
+		found, err := clFiles(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		files = append(files, found...)
+	}
+
+	failed := false
+	for _, path := range files {
+		if lintFile(path, fix) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// lintFile prints the problems found in one file, after applying their
+// fixes when fix is set, and reports whether any is an error.
+func lintFile(path string, fix bool) bool {
+	config, err := lint.LoadConfig(filepath.Dir(path))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return true
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not open file %s\n", path)
+		return true
+	}
+	source := string(content)
+
+	p := parser.New(lexer.New(source))
+	program := p.ParsePrograme()
+	if errs := p.ParseErrors(); len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Printf("%s:%d:%d: error: %s\n", path, err.Start.Line, err.Start.Column, err.Message)
+		}
+		return true
+	}
+	diagnostics := lint.Run(program, source, config)
+
+	if fix {
+		var fixes []lint.Fix
+		for _, d := range diagnostics {
+			if len(d.Fixes) > 0 {
+				fixes = append(fixes, d.Fixes[0])
+			}
+		}
+		if len(fixes) > 0 {
+			source = lint.Apply(source, fixes)
+			if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+				return true
+			}
+			p = parser.New(lexer.New(source))
+			diagnostics = lint.Run(p.ParsePrograme(), source, config)
+		}
+	}
+
+	failed := false
+	for _, d := range diagnostics {
+		fmt.Printf("%s:%d:%d: %s: %s (%s)\n", path, d.Start.Line, d.Start.Column, d.Severity, d.Message, d.Rule)
+		if d.Severity == lint.Error {
+			failed = true
+		}
+	}
+	return failed
+}
@@ -6,6 +6,7 @@ import (
 	"os/user"
 	"path/filepath"
 
+	"github.com/walonCode/code-lang/internal/evaluator"
 	"github.com/walonCode/code-lang/internal/repl"
 )
 
@@ -20,6 +21,14 @@ func main() {
 		switch os.Args[1]{
 			case "-v", "--version":
 				fmt.Printf("code-lang %s %s\n", Version, Commit)
+			case "mod":
+				runMod(os.Args[2:])
+			case "doc":
+				runDoc(os.Args[2:])
+			case "vet":
+				runVet(os.Args[2:])
+			case "lint":
+				runLint(os.Args[2:])
 			default:
 				runFile(os.Args[1])
 		}
@@ -40,7 +49,8 @@ func runFile(path string){
 		os.Exit(1)
 	}
 	
-	repl.Execute(string(file), os.Stdout)
+	repl.ExecuteFile(path, string(file), os.Stdout)
+	evaluator.ClosePlugins()
 }
 
 func runRepl(){
@@ -52,5 +62,6 @@ func runRepl(){
 	fmt.Printf("Hello %s! This is the Code-Lang Programming Language\n", usr.Username)
 	fmt.Printf("Feel free to start type in the commands\n")
 
+	defer evaluator.ClosePlugins()
 	repl.Start(os.Stdout)
 }
\ No newline at end of file
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/walonCode/code-lang/internal/mod"
+)
+
+const modUsage = `usage:
+  code-lang mod init [name]           create code-lang.json in the current directory
+  code-lang mod add <name> <source>   add a dependency (local path or git URL) and vendor it
+  code-lang mod vendor [--frozen]     fetch every dependency into vendor/`
+
+func runMod(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, modUsage)
+		os.Exit(1)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "init":
+		name := ""
+		if len(args) > 1 {
+			name = args[1]
+		}
+		m, err := mod.Init(dir, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("created %s for package %s\n", mod.ManifestFile, m.Name)
+	case "add":
+		if len(args) != 3 {
+			fmt.Fprintln(os.Stderr, modUsage)
+			os.Exit(1)
+		}
+		root := findRoot(dir)
+		lock, err := mod.Add(root, args[1], args[2])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		printLock(lock)
+	case "vendor":
+		frozen := len(args) > 1 && args[1] == "--frozen"
+		root := findRoot(dir)
+		lock, err := mod.Vendor(root, frozen)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		printLock(lock)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown mod command %s\n%s\n", args[0], modUsage)
+		os.Exit(1)
+	}
+}
+
+func findRoot(dir string) string {
+	root, ok := mod.FindRoot(dir)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: no %s found, run `code-lang mod init` first\n", mod.ManifestFile)
+		os.Exit(1)
+	}
+	return root
+}
+
+func printLock(lock *mod.Lockfile) {
+	names := make([]string, 0, len(lock.Packages))
+	for name := range lock.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("vendored %s from %s\n", name, lock.Packages[name].Source)
+	}
+}
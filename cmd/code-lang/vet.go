@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/evaluator"
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/parser"
+	"github.com/walonCode/code-lang/internal/symbol"
+)
+
+const vetUsage = `usage:
+  code-lang vet [path...]   report errors and suspicious code in .cl files
+
+Directories are searched recursively, skipping vendor/. With no path the
+current directory is vetted. Unused top-level lets are reported in files
+that no vetted file imports, and in any file when export leaves them out.`
+
+func runVet(args []string) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	var files []string
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			fmt.Println(vetUsage)
+			return
+		}
+		found, err := clFiles(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		files = append(files, found...)
+	}
+
+	imported := importedFiles(files)
+	problems := 0
+	for _, path := range files {
+		abs, err := filepath.Abs(path)
+		problems += vetFile(path, err == nil && !imported[abs])
+	}
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// clFiles returns path if it is a file, or every .cl file below it if it
+// is a directory.
+func clFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && (d.Name() == "vendor" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) == ".cl" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// importedFiles returns the absolute paths of the .cl files that files
+// import. The others are scripts, whose top-level lets nothing else reads.
+func importedFiles(files []string) map[string]bool {
+	imported := map[string]bool{}
+	for _, path := range files {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		program := parser.New(lexer.New(string(source))).ParsePrograme()
+		for _, stmt := range program.Statements {
+			s, ok := stmt.(*ast.ImportStatement)
+			if !ok || s == nil {
+				continue
+			}
+			if target := evaluator.ResolveModule(path, s.Path); target != "" {
+				if abs, err := filepath.Abs(target); err == nil {
+					imported[abs] = true
+				}
+			}
+		}
+	}
+	return imported
+}
+
+// vetFile prints the problems found in one file as path:line:column lines
+// and returns how many there were. script is set for files nothing
+// imports.
+func vetFile(path string, script bool) int {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not open file %s\n", path)
+		return 1
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParsePrograme()
+	if errs := p.ParseErrors(); len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Printf("%s:%d:%d: error: %s\n", path, err.Start.Line, err.Start.Column, err.Message)
+		}
+		return len(errs)
+	}
+
+	builder := symbol.NewBuilder()
+	builder.ModuleResolver = evaluator.ModuleResolver(path)
+	builder.Script = script
+	if global, ok := evaluator.BuiltinModule(globalModule); ok {
+		for name := range global.Members {
+			builder.Define(name, symbol.FUNCTION)
+		}
+	}
+	builder.Visit(program)
+
+	for _, err := range builder.Errors {
+		fmt.Printf("%s:%d:%d: error: %s\n", path, err.Start.Line, err.Start.Column, err.Message)
+	}
+
+	warnings := builder.Warnings
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Start.Offset < warnings[j].Start.Offset })
+	for _, w := range warnings {
+		fmt.Printf("%s:%d:%d: %s (%s)\n", path, w.Start.Line, w.Start.Column, w.Message, w.Code)
+	}
+
+	return len(builder.Errors) + len(warnings)
+}
@@ -9,6 +9,7 @@ import (
 
 // array
 type ArrayLiteral struct {
+	Span
 	Token    token.Token
 	Elements []Expression
 }
@@ -32,6 +33,7 @@ func (al *ArrayLiteral) Column() int { return al.Token.Column }
 
 // array index expression
 type IndexExpression struct {
+	Span
 	Token token.Token //[
 	Left  Expression
 	Index Expression
@@ -12,6 +12,26 @@ type Node interface {
 	String() string
 	Line() int
 	Column() int
+	// Pos and End are where the node's source begins and the position
+	// just past where it ends.
+	Pos() token.Position
+	End() token.Position
+}
+
+// Span is the source a node covers. Every node embeds one, filled in by
+// the parser.
+type Span struct {
+	StartPos token.Position
+	EndPos   token.Position
+}
+
+func (s *Span) Pos() token.Position { return s.StartPos }
+func (s *Span) End() token.Position { return s.EndPos }
+
+// SetSpan records where the node begins and ends.
+func (s *Span) SetSpan(start, end token.Position) {
+	s.StartPos = start
+	s.EndPos = end
 }
 
 type Statement interface {
@@ -25,10 +45,12 @@ type Expression interface {
 }
 
 type Program struct {
+	Span
 	Statements []Statement
 }
 
 type LetStatement struct {
+	Span
 	Token token.Token
 	Name  *Identifier
 	Value Expression
@@ -56,6 +78,7 @@ func (ls *LetStatement) Line() int   { return ls.Token.Line }
 func (ls *LetStatement) Column() int { return ls.Token.Column }
 
 type ConstStatement struct {
+	Span
 	Token token.Token
 	Name  *Identifier
 	Value Expression
@@ -82,6 +105,7 @@ func (cs *ConstStatement) Line() int   { return cs.Token.Line }
 func (cs *ConstStatement) Column() int { return cs.Token.Column }
 
 type ReturnStatement struct {
+	Span
 	Token       token.Token
 	ReturnValue Expression
 }
@@ -102,6 +126,7 @@ func (i *ReturnStatement) Line() int   { return i.Token.Line }
 func (i *ReturnStatement) Column() int { return i.Token.Column }
 
 type PrefixExpression struct {
+	Span
 	Token    token.Token
 	Operator string
 	Right    Expression
@@ -124,6 +149,7 @@ func (i *PrefixExpression) Line() int   { return i.Token.Line }
 func (i *PrefixExpression) Column() int { return i.Token.Column }
 
 type InfixExpression struct {
+	Span
 	Token    token.Token
 	Left     Expression
 	Right    Expression
@@ -148,6 +174,7 @@ func (i *InfixExpression) Line() int   { return i.Token.Line }
 func (i *InfixExpression) Column() int { return i.Token.Column }
 
 type Identifier struct {
+	Span
 	Token token.Token
 	Value string
 }
@@ -160,6 +187,7 @@ func (i *Identifier) Line() int            { return i.Token.Line }
 func (i *Identifier) Column() int          { return i.Token.Column }
 
 type IntegerLiteral struct {
+	Span
 	Token token.Token
 	Value int64
 }
@@ -172,6 +200,7 @@ func (i *IntegerLiteral) Line() int            { return i.Token.Line }
 func (i *IntegerLiteral) Column() int          { return i.Token.Column }
 
 type ExpressionStatement struct {
+	Span
 	Token      token.Token
 	Expression Expression
 }
@@ -215,6 +244,7 @@ func (p *Program) Column() int { return p.Statements[0].Column() }
 
 // Boolean
 type Boolean struct {
+	Span
 	Token token.Token
 	Value bool
 }
@@ -226,6 +256,7 @@ func (b *Boolean) Line() int            { return b.Token.Line }
 func (b *Boolean) Column() int          { return b.Token.Column }
 
 type BlockStatement struct {
+	Span
 	Token      token.Token
 	Statements []Statement
 }
@@ -246,6 +277,7 @@ func (bs *BlockStatement) Column() int { return bs.Token.Column }
 
 // call expression
 type CallExpression struct {
+	Span
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
@@ -271,6 +303,7 @@ func (ce *CallExpression) Column() int { return ce.Token.Column }
 
 // strings
 type StringLiteral struct {
+	Span
 	Token token.Token
 	Value string
 }
@@ -284,6 +317,7 @@ func (sl *StringLiteral) Column() int          { return sl.Token.Column }
 
 // char
 type CharLiteral struct {
+	Span
 	Token token.Token
 	Value rune
 }
@@ -296,6 +330,7 @@ func (sl *CharLiteral) Column() int          { return sl.Token.Column }
 
 // float
 type FloatLiteral struct {
+	Span
 	Token token.Token
 	Value float64
 }
@@ -307,6 +342,7 @@ func (sl *FloatLiteral) Line() int            { return sl.Token.Line }
 func (sl *FloatLiteral) Column() int          { return sl.Token.Column }
 
 type MemberExpression struct {
+	Span
 	Token    token.Token
 	Object   Expression
 	Property *Identifier
@@ -327,6 +363,7 @@ func (m *MemberExpression) Line() int   { return m.Token.Line }
 func (m *MemberExpression) Column() int { return m.Token.Column }
 
 type BreakStatement struct {
+	Span
 	Token token.Token
 }
 
@@ -337,6 +374,7 @@ func (bs *BreakStatement) Line() int            { return bs.Token.Line }
 func (bs *BreakStatement) Column() int          { return bs.Token.Column }
 
 type ContinueStatement struct {
+	Span
 	Token token.Token
 }
 
@@ -0,0 +1,21 @@
+package ast
+
+import (
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// ExportStatement marks a top-level declaration as part of a module's
+// public members, e.g. `export let add = fn(a, b) { ... };`
+type ExportStatement struct {
+	Span
+	Token     token.Token
+	Statement Statement
+}
+
+func (es *ExportStatement) statementNode()       {}
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExportStatement) String() string {
+	return "export " + es.Statement.String()
+}
+func (es *ExportStatement) Line() int   { return es.Token.Line }
+func (es *ExportStatement) Column() int { return es.Token.Column }
@@ -7,6 +7,7 @@ import (
 )
 
 type ForExpression struct {
+	Span
 	Token     token.Token // The 'for' token
 	Init      Statement   // e.g., let i = 0;
 	Condition Expression  // e.g., i < 10;
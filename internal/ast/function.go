@@ -9,6 +9,7 @@ import (
 
 // function
 type FunctionLiteral struct {
+	Span
 	Token      token.Token
 	Parameters []*Identifier
 	Body       BlockStatement
@@ -9,6 +9,7 @@ import (
 
 // hash literal
 type HashLiteral struct {
+	Span
 	Token token.Token
 	Pairs map[Expression]Expression
 }
@@ -12,6 +12,7 @@ type ELSE_IF struct {
 }
 
 type IfExpression struct {
+	Span
 	Token       token.Token
 	Condition   Expression
 	Consequence *BlockStatement
@@ -2,24 +2,58 @@ package ast
 
 import (
 	"bytes"
+	"path"
+	"strings"
 
 	"github.com/walonCode/code-lang/internal/token"
 )
 
 type ImportStatement struct {
+	Span
 	Token token.Token
-	Path string
+	Path  string
+	// Alias is set for `import "x" as y;`
+	Alias *Identifier
+	// Names is set for `from "x" import a, b;`
+	Names []*Identifier
 }
-func (i *ImportStatement) statementNode()      {}
+
+func (i *ImportStatement) statementNode()       {}
 func (i *ImportStatement) TokenLiteral() string { return i.Token.Literal }
 func (i *ImportStatement) String() string {
 	var out bytes.Buffer
-	
+
+	if len(i.Names) > 0 {
+		names := []string{}
+		for _, n := range i.Names {
+			names = append(names, n.String())
+		}
+		out.WriteString("from ")
+		out.WriteString(i.Path)
+		out.WriteString(" import ")
+		out.WriteString(strings.Join(names, ", "))
+		return out.String()
+	}
+
 	out.WriteString("import")
 	out.WriteString(" ")
 	out.WriteString(i.Path)
-	
+
+	if i.Alias != nil {
+		out.WriteString(" as ")
+		out.WriteString(i.Alias.String())
+	}
+
 	return out.String()
 }
 func (i *ImportStatement) Line() int   { return i.Token.Line }
-func (i *ImportStatement) Column() int { return i.Token.Column }
\ No newline at end of file
+func (i *ImportStatement) Column() int { return i.Token.Column }
+
+// Name returns the identifier the imported module is bound to: the alias
+// when one is given, otherwise the last segment of the import path.
+func (i *ImportStatement) Name() string {
+	if i.Alias != nil {
+		return i.Alias.Value
+	}
+	return strings.TrimSuffix(path.Base(i.Path), ".cl")
+}
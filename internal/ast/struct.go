@@ -8,6 +8,7 @@ import (
 
 // struct
 type StructStatement struct {
+	Span
 	Token token.Token
 	Name *Identifier
 	Fields map[string]Expression
@@ -36,6 +37,7 @@ func (ss *StructStatement)Column()int { return ss.Token.Column}
 
 
 type StructLiteral struct {
+	Span
 	Token token.Token
 	Name *Identifier
 	Fields map[string]Expression
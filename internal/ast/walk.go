@@ -0,0 +1,150 @@
+package ast
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Inspect calls fn for node and, while fn returns true, for each of its
+// children in source order, like go/ast.Inspect.
+func Inspect(node Node, fn func(Node) bool) {
+	if IsNil(node) || !fn(node) {
+		return
+	}
+
+	for _, child := range Children(node) {
+		Inspect(child, fn)
+	}
+}
+
+// Children returns the direct children of node in source order.
+func Children(node Node) []Node {
+	var out []Node
+	add := func(nodes ...Node) {
+		for _, n := range nodes {
+			if !IsNil(n) {
+				out = append(out, n)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			add(s)
+		}
+	case *LetStatement:
+		add(n.Name, n.Value)
+	case *ConstStatement:
+		add(n.Name, n.Value)
+	case *ReturnStatement:
+		add(n.ReturnValue)
+	case *ExpressionStatement:
+		add(n.Expression)
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			add(s)
+		}
+	case *ExportStatement:
+		add(n.Statement)
+	case *StructStatement:
+		add(n.Name)
+		for _, f := range SortedFields(n.Fields) {
+			add(n.Fields[f])
+		}
+	case *EnumStatement:
+		add(n.Name)
+		for _, v := range n.Variants {
+			if v != nil {
+				add(v.Name)
+			}
+		}
+	case *ImportStatement:
+		if n.Alias != nil {
+			add(n.Alias)
+		}
+		for _, name := range n.Names {
+			add(name)
+		}
+	case *PrefixExpression:
+		add(n.Right)
+	case *InfixExpression:
+		add(n.Left, n.Right)
+	case *IfExpression:
+		add(n.Condition, n.Consequence)
+		for _, elif := range n.IfElse {
+			if elif != nil {
+				add(elif.Condition, elif.Consequence)
+			}
+		}
+		add(n.Alternative)
+	case *FunctionLiteral:
+		for _, p := range n.Parameters {
+			add(p)
+		}
+		add(&n.Body)
+	case *CallExpression:
+		add(n.Function)
+		for _, a := range n.Arguments {
+			add(a)
+		}
+	case *ArrayLiteral:
+		for _, el := range n.Elements {
+			add(el)
+		}
+	case *IndexExpression:
+		add(n.Left, n.Index)
+	case *HashLiteral:
+		keys := make([]Expression, 0, len(n.Pairs))
+		for k := range n.Pairs {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return nodeBefore(keys[i], keys[j]) })
+		for _, k := range keys {
+			add(k, n.Pairs[k])
+		}
+	case *StructLiteral:
+		add(n.Name)
+		for _, f := range SortedFields(n.Fields) {
+			add(n.Fields[f])
+		}
+	case *MemberExpression:
+		add(n.Object, n.Property)
+	case *ForExpression:
+		add(n.Init, n.Condition, n.Post, n.Body)
+	case *WhileExpression:
+		add(n.Condition, n.Body)
+	}
+
+	return out
+}
+
+// SortedFields orders struct fields by where their values appear.
+func SortedFields(fields map[string]Expression) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := fields[names[i]], fields[names[j]]
+		if IsNil(a) || IsNil(b) {
+			return names[i] < names[j]
+		}
+		return nodeBefore(a, b)
+	})
+	return names
+}
+
+func nodeBefore(a, b Node) bool {
+	return a.Pos().Offset < b.Pos().Offset
+}
+
+// IsNil reports whether node is nil or a typed nil pointer, which the
+// parser leaves behind on errors.
+func IsNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
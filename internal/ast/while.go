@@ -7,6 +7,7 @@ import (
 )
 
 type WhileExpression struct {
+	Span
 	Token token.Token
 	Condition Expression
 	Body *BlockStatement
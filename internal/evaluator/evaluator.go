@@ -1,23 +1,56 @@
 package evaluator
 
 import (
+	"context"
 	"maps"
 	"math"
-	"os"
-	"path/filepath"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/walonCode/code-lang/internal/ast"
-	"github.com/walonCode/code-lang/internal/lexer"
 	"github.com/walonCode/code-lang/internal/object"
-	"github.com/walonCode/code-lang/internal/parser"
 )
 
-var moduleCache = map[string]*object.Module{}
+// moduleCache holds the modules importable by name: the std modules, those
+// added with RegisterModule and running plugins. Modules read from .cl
+// files are cached per program, in Evaluator.Modules, so edits to them are
+// seen by the next run. The language server
+// reads it while programs run, so it is used under moduleCacheMu.
+var (
+	moduleCacheMu sync.RWMutex
+	moduleCache   = map[string]*object.Module{}
+)
+
+func cachedModule(key string) (*object.Module, bool) {
+	moduleCacheMu.RLock()
+	defer moduleCacheMu.RUnlock()
+	module, ok := moduleCache[key]
+	return module, ok
+}
+
+func cacheModule(key string, module *object.Module) {
+	moduleCacheMu.Lock()
+	defer moduleCacheMu.Unlock()
+	moduleCache[key] = module
+}
 
 type Evaluator struct {
 	loopDepth   int
 	Resolutions map[ast.Node]int
+	// File is the path of the script being evaluated; imports are resolved
+	// relative to it. Empty means the current working directory.
+	File        string
+	importStack []string
+	// SearchPath lists directories searched for imports after those of
+	// CODELANG_PATH.
+	SearchPath []string
+	// Modules are the .cl modules the program has loaded, by file name, so
+	// each is evaluated once per program. Nil starts with none.
+	Modules map[string]*object.Module
+	// Context stops the program once it is done: loops and function calls
+	// check it. Nil means the program runs to the end.
+	Context context.Context
 }
 
 func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
@@ -67,6 +100,11 @@ func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		env.Set(node.Name.Value, structType)
 		return object.NULL
+	case *ast.EnumStatement:
+		env.Set(node.Name.Value, evalEnumStatement(node))
+		return object.NULL
+	case *ast.ExportStatement:
+		return e.Eval(node.Statement, env)
 
 	//expression
 	case *ast.IntegerLiteral:
@@ -192,51 +230,63 @@ func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
 	return nil
 }
 
-func (e *Evaluator) evalBreakStatement(node *ast.BreakStatement) object.Object {
-	if e.loopDepth == 0 {
-		return object.NewError(node.Line(), node.Column(), "break not inside a loop")
+func evalEnumStatement(node *ast.EnumStatement) *object.EnumType {
+	enumType := &object.EnumType{
+		Name:     node.Name.Value,
+		Variants: make(map[string]object.Object),
 	}
 
-	return &object.Break{}
-}
+	for _, variant := range node.Variants {
+		if len(variant.Fields) == 0 {
+			enumType.Variants[variant.Name.Value] = &object.EnumValue{
+				EnumName: enumType.Name,
+				Variant:  variant.Name.Value,
+			}
+			continue
+		}
 
-func (e *Evaluator) evalContinueStatement(node *ast.ContinueStatement) object.Object {
-	if e.loopDepth == 0 {
-		return object.NewError(node.Line(), node.Column(), "continue not inside a loop")
+		enumType.Variants[variant.Name.Value] = enumConstructor(enumType.Name, variant)
 	}
 
-	return &object.Continue{}
+	return enumType
 }
 
-func (e *Evaluator) evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
-	modulePath := node.Path
-
-	if mod, ok := moduleCache[modulePath]; ok {
-		env.Set(modulePath, mod)
-		return mod
-	}
-
-	fileName := filepath.Clean(modulePath + ".cl")
-	content, err := os.ReadFile(fileName)
-	if err != nil {
-		return object.NewError(node.Line(), node.Column(), "could not read module %q : %s", modulePath, err)
+func enumConstructor(enumName string, variant *ast.EnumVariant) *object.Builtin {
+	fields := []string{}
+	for _, f := range variant.Fields {
+		fields = append(fields, f.Value)
 	}
 
-	moduleEnv := object.NewEnclosedEnvironment(env)
+	return &object.Builtin{
+		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
+			if len(args) != len(fields) {
+				return object.NewError(node.Line(), node.Column(), "wrong number of arguments for %s.%s. got=%d, want=%d", enumName, variant.Name.Value, len(args), len(fields))
+			}
 
-	l := lexer.New(string(content))
-	p := parser.New(l)
-	programe := p.ParsePrograme()
+			return &object.EnumValue{
+				EnumName: enumName,
+				Variant:  variant.Name.Value,
+				Fields:   fields,
+				Values:   args,
+			}
+		},
+	}
+}
 
-	e.Eval(programe, moduleEnv)
+func (e *Evaluator) evalBreakStatement(node *ast.BreakStatement) object.Object {
+	if e.loopDepth == 0 {
+		return object.NewError(node.Line(), node.Column(), "break not inside a loop")
+	}
 
-	moduleobj := &object.Module{Members: map[string]object.Object{}}
-	maps.Copy(moduleobj.Members, moduleEnv.Store)
-	env.Set(modulePath, moduleobj)
+	return &object.Break{}
+}
 
-	moduleCache[modulePath] = moduleobj
+func (e *Evaluator) evalContinueStatement(node *ast.ContinueStatement) object.Object {
+	if e.loopDepth == 0 {
+		return object.NewError(node.Line(), node.Column(), "continue not inside a loop")
+	}
 
-	return moduleobj
+	return &object.Continue{}
 }
 
 func isAssignment(op string) bool {
@@ -412,6 +462,18 @@ func evalMemberExpression(obj object.Object, node *ast.MemberExpression) object.
 			return object.NewError(node.Line(), node.Column(), "unknown field %s on  %s", node.Property.Value, obj.TypeName)
 		}
 		return val
+	case *object.EnumType:
+		val, ok := obj.Variants[node.Property.Value]
+		if !ok {
+			return object.NewError(node.Line(), node.Column(), "enum %s has no variant %s", obj.Name, node.Property.Value)
+		}
+		return val
+	case *object.EnumValue:
+		val, ok := obj.Field(node.Property.Value)
+		if !ok {
+			return object.NewError(node.Line(), node.Column(), "unknown field %s on %s.%s", node.Property.Value, obj.EnumName, obj.Variant)
+		}
+		return val
 	default:
 		return object.NewError(node.Line(), node.Column(), "cannot access property %s on %s", node.Property.Value, obj.Type())
 	}
@@ -424,6 +486,9 @@ func (e *Evaluator) evalWhileExpression(node *ast.WhileExpression, env *object.E
 	defer func() { e.loopDepth-- }()
 
 	for {
+		if err := e.interrupted(node); err != nil {
+			return err
+		}
 		if node.Condition != nil {
 			condition := e.Eval(node.Condition, env)
 			if isError(condition) {
@@ -470,6 +535,9 @@ func (e *Evaluator) evalForExpression(node *ast.ForExpression, env *object.Envir
 	defer func() { e.loopDepth-- }()
 
 	for {
+		if err := e.interrupted(node); err != nil {
+			return err
+		}
 		if node.Condition != nil {
 			condition := e.Eval(node.Condition, forEnv)
 			if isError(condition) {
@@ -547,16 +615,32 @@ func evalIndexExpression(left, index object.Object, node *ast.IndexExpression) o
 	}
 }
 
+// evalStringIndexExpression returns the character that starts at byte
+// offset index, or "" for the other bytes of a multi-byte character, so
+// joining s[i] for every i in 0..len(s) gives back s. Bytes that are not
+// valid UTF-8 are returned alone.
 func evalStringIndexExpression(left, index object.Object) object.Object {
-	strObject := left.(*object.String)
+	str := left.(*object.String).Value
 	idx := index.(*object.Integer).Value
-	max := int64(len(strObject.Value))
+	max := int64(len(str))
 
-	if idx < 0 || idx > max {
+	if idx < 0 || idx >= max {
 		return object.NULL
 	}
 
-	return &object.String{Value: string(strObject.Value[idx])}
+	start := idx
+	for start > 0 && idx-start < utf8.UTFMax-1 && !utf8.RuneStart(str[start]) {
+		start--
+	}
+	_, size := utf8.DecodeRuneInString(str[start:])
+	if start+int64(size) <= idx {
+		// Not part of a valid character.
+		return &object.String{Value: str[idx : idx+1]}
+	}
+	if start < idx {
+		return &object.String{Value: ""}
+	}
+	return &object.String{Value: str[idx : idx+int64(size)]}
 }
 
 func evalHashIndexExpression(hash, index object.Object, node *ast.IndexExpression) object.Object {
@@ -590,6 +674,9 @@ func evalArrayIndexExpression(left, index object.Object) object.Object {
 func (e *Evaluator) applyFunction(fn object.Object, args []object.Object, node *ast.CallExpression) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if err := e.interrupted(node); err != nil {
+			return err
+		}
 		extendedEnv := extendFunctionEnv(fn, args)
 		evaluated := e.Eval(fn.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
@@ -600,6 +687,14 @@ func (e *Evaluator) applyFunction(fn object.Object, args []object.Object, node *
 	}
 }
 
+// interrupted returns an error at node once the program's context is done.
+func (e *Evaluator) interrupted(node ast.Node) *object.Error {
+	if e.Context == nil || e.Context.Err() == nil {
+		return nil
+	}
+	return object.NewError(node.Line(), node.Column(), "program stopped: %s", e.Context.Err())
+}
+
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
@@ -755,6 +850,8 @@ func evalInfixExpression(node *ast.InfixExpression, left, right object.Object) o
 		return evalFloatAndIntegerInfixExpression(node, left, right)
 	case left.Type() == object.FLOAT_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalFloatAndIntegerInfixExpression(node, left, right)
+	case left.Type() == object.ENUM_OBJ && right.Type() == object.ENUM_OBJ:
+		return evalEnumInfixExpression(node, left, right)
 	case node.Operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case node.Operator == "!=":
@@ -928,6 +1025,20 @@ func evalMixedStringOrCharInfixExpression(node *ast.InfixExpression, left, right
 	return &object.String{Value: leftVal + string(rightVal)}
 }
 
+func evalEnumInfixExpression(node *ast.InfixExpression, left, right object.Object) object.Object {
+	leftVal := left.(*object.EnumValue)
+	rightVal := right.(*object.EnumValue)
+
+	switch node.Operator {
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Equals(rightVal))
+	case "!=":
+		return nativeBoolToBooleanObject(!leftVal.Equals(rightVal))
+	default:
+		return object.NewError(node.Line(), node.Column(), "unknown operator: %s %s %s", left.Type(), node.Operator, right.Type())
+	}
+}
+
 func evalCharInfixExpression(node *ast.InfixExpression, left, right object.Object) object.Object {
 	leftVal := left.(*object.Char).Value
 	rightVal := right.(*object.Char).Value
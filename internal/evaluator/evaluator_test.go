@@ -1,7 +1,10 @@
 package evaluator
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/walonCode/code-lang/internal/lexer"
 	"github.com/walonCode/code-lang/internal/object"
@@ -405,6 +408,58 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`len("héllo");`, 6},
+		{`import "strings"; strings.rune_len("héllo");`, 5},
+		{`import "strings"; strings.rune_at("héllo", 1);`, "é"},
+		{`import "strings"; strings.rune_at("héllo", 5);`, nil},
+		{`import "strings"; len(strings.runes("日本語"));`, 3},
+		{`"héllo"[0];`, "h"},
+		{`"héllo"[1];`, "é"},
+		{`"héllo"[2];`, ""},
+		{`"héllo"[3];`, "l"},
+		{`"日本"[3];`, "本"},
+		{`"日本"[4];`, ""},
+		{`let s = "añ日🎉!"; let out = ""; for (let i = 0; i < len(s); i += 1) { out = out + s[i]; }; out;`, "añ日🎉!"},
+		{`"abc"[3];`, nil},
+		{`let café = 'é'; café;`, 'é'},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("%s: expected %q, got=%T (%+v)", tt.input, expected, evaluated, evaluated)
+			}
+		case rune:
+			char, ok := evaluated.(*object.Char)
+			if !ok || char.Value != expected {
+				t.Errorf("%s: expected %q, got=%T (%+v)", tt.input, expected, evaluated, evaluated)
+			}
+		case nil:
+			if evaluated != object.NULL {
+				t.Errorf("%s: expected null, got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+
+	invalid := &object.String{Value: "a\xffé\x80"}
+	for i, expected := range []string{"a", "\xff", "é", "", "\x80"} {
+		got := evalStringIndexExpression(invalid, &object.Integer{Value: int64(i)}).(*object.String)
+		if got.Value != expected {
+			t.Errorf("index %d of invalid UTF-8: expected %q, got=%q", i, expected, got.Value)
+		}
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3];"
 	evaluated := testEval(input)
@@ -612,3 +667,86 @@ func TestConstantReassignment(t *testing.T) {
 		}
 	}
 }
+
+func TestEnums(t *testing.T) {
+	enum := `enum Status { Active, Suspended(reason), Deleted };`
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{enum + `Status.Active == Status.Active;`, true},
+		{enum + `Status.Active == Status.Deleted;`, false},
+		{enum + `Status.Active != Status.Deleted;`, true},
+		{enum + `Status.Suspended("spam") == Status.Suspended("spam");`, true},
+		{enum + `Status.Suspended("spam") == Status.Suspended("abuse");`, false},
+		{enum + `let s = Status.Suspended("spam"); s.reason;`, "spam"},
+		{enum + `let s = Status.Active; if (s == Status.Active) { "yes"; } else { "no"; };`, "yes"},
+		{enum + `Status.Suspended("spam");`, `Status.Suspended("spam")`},
+		{enum + `Status.Deleted;`, "Status.Deleted"},
+		{enum + `typeof(Status.Active);`, "Status"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if evaluated == nil {
+				t.Errorf("got nil for %q", tt.input)
+				continue
+			}
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			`enum Status { Active, Suspended(reason) }; Status.Suspended();`,
+			"wrong number of arguments for Status.Suspended. got=0, want=1",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestContextStopsProgram(t *testing.T) {
+	tests := []string{
+		"while (true) { let x = 1; };",
+		"for (let i = 0; i >= 0; i += 1) { let x = 1; };",
+		"let f = fn() { return f(); }; f();",
+	}
+	for _, input := range tests {
+		program := parser.New(lexer.New(input)).ParsePrograme()
+		builder := symbol.NewBuilder()
+		builder.Visit(program)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		evaluator := Evaluator{Resolutions: builder.Resolutions, Context: ctx}
+		result := evaluator.Eval(program, object.NewEnvironment())
+		cancel()
+
+		errObj, ok := result.(*object.Error)
+		if !ok || !strings.Contains(errObj.Message, "program stopped: context deadline exceeded") {
+			t.Errorf("%q was not stopped. got=%T (%+v)", input, result, result)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/mod"
+	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/parser"
+	"github.com/walonCode/code-lang/internal/std/general"
+	"github.com/walonCode/code-lang/internal/symbol"
+)
+
+const (
+	// SearchPathEnv lists extra directories searched for imports, separated
+	// by the OS path list separator.
+	SearchPathEnv = "CODELANG_PATH"
+
+	moduleExtension = ".cl"
+	// packageEntry is loaded when an import path names a directory.
+	packageEntry = "mod.cl"
+)
+
+func (e *Evaluator) evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	mod, errObj := e.loadModule(node)
+	if errObj != nil {
+		return errObj
+	}
+
+	if len(node.Names) > 0 {
+		for _, name := range node.Names {
+			val, ok := mod.Members[name.Value]
+			if !ok {
+				return object.NewError(name.Line(), name.Column(), "module %q has no member %s", node.Path, name.Value)
+			}
+			env.Set(name.Value, val)
+		}
+		return mod
+	}
+
+	env.Set(node.Name(), mod)
+	return mod
+}
+
+func (e *Evaluator) loadModule(node *ast.ImportStatement) (*object.Module, *object.Error) {
+	if mod, ok := cachedModule(node.Path); ok {
+		return mod, nil
+	}
+
+	if command, ok := mod.FindPlugin(e.currentDir(), node.Path); ok {
+		return e.loadPlugin(node, command)
+	}
+
+	fileName, searched := e.resolveModule(node.Path)
+	if fileName == "" {
+		return nil, object.NewError(node.Line(), node.Column(), "could not find module %q (searched: %s)", node.Path, strings.Join(searched, ", "))
+	}
+
+	if mod, ok := e.Modules[fileName]; ok {
+		return mod, nil
+	}
+
+	chain := e.importChain()
+	for i, f := range chain {
+		if f == fileName {
+			cycle := append(chain[i:len(chain):len(chain)], fileName)
+			return nil, object.NewError(node.Line(), node.Column(), "import cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, object.NewError(node.Line(), node.Column(), "could not read module %q : %s", node.Path, err)
+	}
+
+	l := lexer.New(string(content))
+	p := parser.New(l)
+	programe := p.ParsePrograme()
+	if len(p.Errors()) != 0 {
+		return nil, object.NewError(node.Line(), node.Column(), "could not parse module %q: %s", node.Path, p.Errors()[0])
+	}
+
+	moduleEnv := newModuleEnvironment()
+
+	prevFile, prevStack := e.File, e.importStack
+	e.File = fileName
+	e.importStack = append(chain, fileName)
+	result := e.Eval(programe, moduleEnv)
+	e.File, e.importStack = prevFile, prevStack
+
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, object.NewError(node.Line(), node.Column(), "error in module %q: %s", node.Path, errObj.Inspect())
+	}
+
+	moduleobj := &object.Module{Members: map[string]object.Object{}}
+	for name := range symbol.ModuleExports(programe) {
+		if val, ok := moduleEnv.Store[name]; ok {
+			moduleobj.Members[name] = val
+		}
+	}
+
+	if e.Modules == nil {
+		e.Modules = map[string]*object.Module{}
+	}
+	e.Modules[fileName] = moduleobj
+
+	return moduleobj, nil
+}
+
+// ModuleResolver returns a symbol.Builder module resolver for imports made
+// from file, searching the directories of searchPath last. Std and plugin
+// modules are not resolved, since their members are not declared in
+// code-lang.
+func ModuleResolver(file string, searchPath ...string) func(path string) map[string]symbol.SymbolKind {
+	return func(path string) map[string]symbol.SymbolKind {
+		fileName := ResolveModule(file, path, searchPath...)
+		if fileName == "" {
+			return nil
+		}
+
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil
+		}
+
+		p := parser.New(lexer.New(string(content)))
+		program := p.ParsePrograme()
+		if len(p.Errors()) != 0 {
+			return nil
+		}
+
+		return symbol.ModuleExports(program)
+	}
+}
+
+// ResolveModule returns the .cl file that an import of importPath made from
+// file loads, searching the directories of searchPath last, or "" for std
+// modules, plugins and paths that do not resolve.
+func ResolveModule(file, importPath string, searchPath ...string) string {
+	if _, ok := cachedModule(importPath); ok {
+		return ""
+	}
+
+	e := &Evaluator{File: file, SearchPath: searchPath}
+	if _, ok := mod.FindPlugin(e.currentDir(), importPath); ok {
+		return ""
+	}
+
+	fileName, _ := e.resolveModule(importPath)
+	return fileName
+}
+
+// IsPluginModule reports whether an import of importPath made from file
+// loads a plugin declared in an enclosing code-lang.json.
+func IsPluginModule(file, importPath string) bool {
+	_, ok := mod.FindPlugin((&Evaluator{File: file}).currentDir(), importPath)
+	return ok
+}
+
+// IsBuiltinModule reports whether path names a std module or one added
+// with RegisterModule, as opposed to a .cl file or a plugin.
+func IsBuiltinModule(path string) bool {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, pluginKeyPrefix) {
+		return false
+	}
+	_, ok := cachedModule(path)
+	return ok
+}
+
+// BuiltinModule returns the std or registered module importable as name.
+func BuiltinModule(name string) (*object.Module, bool) {
+	if !IsBuiltinModule(name) {
+		return nil, false
+	}
+	return cachedModule(name)
+}
+
+// BuiltinModuleNames returns the names of every std or registered module,
+// sorted.
+func BuiltinModuleNames() []string {
+	moduleCacheMu.RLock()
+	var names []string
+	for name := range moduleCache {
+		names = append(names, name)
+	}
+	moduleCacheMu.RUnlock()
+
+	names = slices.DeleteFunc(names, func(name string) bool { return !IsBuiltinModule(name) })
+	sort.Strings(names)
+	return names
+}
+
+// resolveModule maps an import path to a file on disk. Paths are resolved
+// relative to the importing file first; unless the path is explicitly
+// relative ("./" or "../"), vendored packages of every enclosing
+// code-lang.json, every directory in CODELANG_PATH and then those of
+// SearchPath are searched.
+// It returns the absolute file name, or "" and the candidates it tried.
+func (e *Evaluator) resolveModule(importPath string) (string, []string) {
+	var searched []string
+
+	for _, dir := range e.searchDirs(importPath) {
+		for _, candidate := range moduleCandidates(dir, importPath) {
+			searched = append(searched, candidate)
+			info, err := os.Stat(candidate)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if abs, err := filepath.Abs(candidate); err == nil {
+				return abs, searched
+			}
+			return candidate, searched
+		}
+	}
+
+	return "", searched
+}
+
+func (e *Evaluator) searchDirs(importPath string) []string {
+	if filepath.IsAbs(importPath) {
+		return []string{""}
+	}
+
+	dirs := []string{e.currentDir()}
+	if isRelativeImport(importPath) {
+		return dirs
+	}
+
+	dirs = append(dirs, mod.VendorDirs(e.currentDir())...)
+
+	for _, dir := range append(filepath.SplitList(os.Getenv(SearchPathEnv)), e.SearchPath...) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+func (e *Evaluator) currentDir() string {
+	if e.File == "" {
+		return "."
+	}
+	return filepath.Dir(e.File)
+}
+
+// importChain returns the files currently being evaluated, outermost first.
+func (e *Evaluator) importChain() []string {
+	if len(e.importStack) > 0 {
+		return e.importStack[:len(e.importStack):len(e.importStack)]
+	}
+	if e.File == "" {
+		return nil
+	}
+	if abs, err := filepath.Abs(e.File); err == nil {
+		return []string{abs}
+	}
+	return []string{e.File}
+}
+
+func moduleCandidates(dir, importPath string) []string {
+	base := filepath.Join(dir, filepath.FromSlash(importPath))
+	if strings.HasSuffix(importPath, moduleExtension) {
+		return []string{base}
+	}
+	return []string{base + moduleExtension, filepath.Join(base, packageEntry)}
+}
+
+func isRelativeImport(importPath string) bool {
+	return strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../")
+}
+
+// newModuleEnvironment returns the environment a module body is evaluated
+// in: the fmt builtins are visible, but only the module's own bindings end
+// up in its Store.
+func newModuleEnvironment() *object.Environment {
+	builtins := object.NewEnvironment()
+	for name, fn := range general.Module().Members {
+		builtins.Set(name, fn)
+	}
+	return object.NewEnclosedEnvironment(builtins)
+}
@@ -0,0 +1,371 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/parser"
+	"github.com/walonCode/code-lang/internal/std/general"
+	"github.com/walonCode/code-lang/internal/symbol"
+)
+
+func writeModuleFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testEvalFile(t *testing.T, path string) object.Object {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := lexer.New(string(content))
+	p := parser.New(l)
+	program := p.ParsePrograme()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	env := object.NewEnvironment()
+	genMod := general.Module()
+	for name, obj := range genMod.Members {
+		env.Set(name, obj)
+	}
+
+	builder := symbol.NewBuilder()
+	for name := range genMod.Members {
+		builder.Define(name, symbol.FUNCTION)
+	}
+	builder.Visit(program)
+	if len(builder.Errors) != 0 {
+		t.Fatalf("symbol errors: %v", builder.Errors)
+	}
+
+	evaluator := Evaluator{Resolutions: builder.Resolutions, File: path}
+	return evaluator.Eval(program, env)
+}
+
+func TestImportResolution(t *testing.T) {
+	util := `let add = fn(a, b) { return a + b; };`
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected int64
+	}{
+		{
+			"relative to importing file",
+			map[string]string{
+				"app/main.cl":     `import "lib/util"; util.add(1, 2);`,
+				"app/lib/util.cl": util,
+			},
+			3,
+		},
+		{
+			"alias",
+			map[string]string{
+				"app/main.cl":     `import "lib/util" as u; u.add(2, 3);`,
+				"app/lib/util.cl": util,
+			},
+			5,
+		},
+		{
+			"selective import",
+			map[string]string{
+				"app/main.cl":     `from "lib/util" import add; add(3, 4);`,
+				"app/lib/util.cl": util,
+			},
+			7,
+		},
+		{
+			"parent directory",
+			map[string]string{
+				"app/cmd/main.cl": `import "../lib/util"; util.add(4, 5);`,
+				"app/lib/util.cl": util,
+			},
+			9,
+		},
+		{
+			"directory package",
+			map[string]string{
+				"app/main.cl":          `import "mathx"; mathx.add(5, 6);`,
+				"app/mathx/mod.cl":     `import "./helpers"; let add = helpers.add;`,
+				"app/mathx/helpers.cl": util,
+			},
+			11,
+		},
+		{
+			"nested module imports relative to itself",
+			map[string]string{
+				"app/main.cl":      `import "lib/outer"; outer.twice(6);`,
+				"app/lib/outer.cl": `import "inner"; let twice = fn(x) { return inner.add(x, x); };`,
+				"app/lib/inner.cl": util,
+			},
+			12,
+		},
+	}
+
+	for _, tt := range tests {
+		dir := writeModuleFiles(t, tt.files)
+		var mainFile string
+		for name := range tt.files {
+			if strings.HasSuffix(name, "main.cl") {
+				mainFile = filepath.Join(dir, filepath.FromSlash(name))
+			}
+		}
+		evaluated := testEvalFile(t, mainFile)
+		if errObj, ok := evaluated.(*object.Error); ok {
+			t.Errorf("%s: unexpected error: %s", tt.name, errObj.Message)
+			continue
+		}
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestImportSearchPath(t *testing.T) {
+	libDir := writeModuleFiles(t, map[string]string{
+		"shared.cl": `let answer = 42;`,
+	})
+	appDir := writeModuleFiles(t, map[string]string{
+		"main.cl": `import "shared"; shared.answer;`,
+	})
+
+	t.Setenv(SearchPathEnv, libDir)
+
+	evaluated := testEvalFile(t, filepath.Join(appDir, "main.cl"))
+	testIntegerObject(t, evaluated, 42)
+}
+
+func TestResolveModuleSearchPath(t *testing.T) {
+	t.Setenv(SearchPathEnv, "")
+	libDir := writeModuleFiles(t, map[string]string{
+		"shared.cl": `let answer = 42;`,
+	})
+	main := filepath.Join(t.TempDir(), "main.cl")
+
+	if got := ResolveModule(main, "shared"); got != "" {
+		t.Errorf("shared resolved without a search path: %s", got)
+	}
+	if got := ResolveModule(main, "shared", libDir); got != filepath.Join(libDir, "shared.cl") {
+		t.Errorf("wrong module. got=%q", got)
+	}
+}
+
+func TestImportVendoredPackage(t *testing.T) {
+	dir := writeModuleFiles(t, map[string]string{
+		"code-lang.json":           `{"name": "app", "version": "0.1.0"}`,
+		"vendor/strutil/mod.cl":    `let shout = fn(s) { return s + "!"; };`,
+		"src/main.cl":              `import "strutil"; strutil.shout("hi");`,
+		"vendor/strutil/README.md": `not code`,
+	})
+
+	evaluated := testEvalFile(t, filepath.Join(dir, "src", "main.cl"))
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "hi!" {
+		t.Errorf("wrong value. got=%q", str.Value)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		expectedMessage string
+	}{
+		{
+			"missing module",
+			map[string]string{"main.cl": `import "nope"; 1;`},
+			`could not find module "nope"`,
+		},
+		{
+			"import cycle",
+			map[string]string{
+				"main.cl": `import "a"; 1;`,
+				"a.cl":    `import "b";`,
+				"b.cl":    `import "a";`,
+			},
+			"import cycle detected",
+		},
+		{
+			"missing selective member",
+			map[string]string{
+				"main.cl": `from "lib" import missing; 1;`,
+				"lib.cl":  `let present = 1;`,
+			},
+			`module "lib" has no member missing`,
+		},
+	}
+
+	for _, tt := range tests {
+		dir := writeModuleFiles(t, tt.files)
+		evaluated := testEvalFile(t, filepath.Join(dir, "main.cl"))
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.name, evaluated, evaluated)
+			continue
+		}
+		if !strings.Contains(errObj.Message, tt.expectedMessage) {
+			t.Errorf("%s: wrong error message. expected to contain %q, got=%q", tt.name, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestModuleDoesNotSeeImporterScope(t *testing.T) {
+	dir := writeModuleFiles(t, map[string]string{
+		"main.cl": `let secret = 1; import "lib"; 1;`,
+		"lib.cl":  `let leaked = secret;`,
+	})
+
+	evaluated := testEvalFile(t, filepath.Join(dir, "main.cl"))
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if !strings.Contains(errObj.Message, "identifier not found: secret") {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestModuleExportControl(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		expected        int64
+		expectedMessage string
+	}{
+		{
+			"public names are visible",
+			map[string]string{
+				"main.cl": `import "lib"; lib.add(1, 2);`,
+				"lib.cl":  `let _offset = 0; let add = fn(a, b) { return a + b + _offset; };`,
+			},
+			3,
+			"",
+		},
+		{
+			"underscore names are private",
+			map[string]string{
+				"main.cl": `import "lib"; lib._offset;`,
+				"lib.cl":  `let _offset = 0; let add = fn(a, b) { return a + b; };`,
+			},
+			0,
+			"module has not member _offset",
+		},
+		{
+			"only exported names are public when export is used",
+			map[string]string{
+				"main.cl": `import "lib"; lib.sub(1, 2);`,
+				"lib.cl":  `export let add = fn(a, b) { return a + b; }; let sub = fn(a, b) { return a - b; };`,
+			},
+			0,
+			"module has not member sub",
+		},
+		{
+			"imported modules do not leak",
+			map[string]string{
+				"main.cl":  `import "lib"; lib.inner;`,
+				"lib.cl":   `import "inner"; let x = inner.y;`,
+				"inner.cl": `let y = 1;`,
+			},
+			0,
+			"module has not member inner",
+		},
+	}
+
+	for _, tt := range tests {
+		dir := writeModuleFiles(t, tt.files)
+		evaluated := testEvalFile(t, filepath.Join(dir, "main.cl"))
+		if tt.expectedMessage == "" {
+			testIntegerObject(t, evaluated, tt.expected)
+			continue
+		}
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.name, evaluated, evaluated)
+			continue
+		}
+		if !strings.Contains(errObj.Message, tt.expectedMessage) {
+			t.Errorf("%s: wrong error message. expected to contain %q, got=%q", tt.name, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestBuiltinModulesAreDocumented(t *testing.T) {
+	for _, name := range BuiltinModuleNames() {
+		module, _ := BuiltinModule(name)
+		for member, value := range module.Members {
+			fn, ok := value.(*object.Builtin)
+			if !ok {
+				continue
+			}
+			if fn.Signature == nil || fn.Signature.Doc == "" {
+				t.Errorf("%s.%s has no signature", name, member)
+			}
+		}
+	}
+
+	if _, ok := BuiltinModule("/abs/path.cl"); ok {
+		t.Errorf("file modules are not builtin")
+	}
+}
+
+// The language server resolves imports while code lenses run programs;
+// run with -race.
+func TestModuleCacheConcurrentUse(t *testing.T) {
+	files := map[string]string{}
+	var main strings.Builder
+	for i := range 20 {
+		name := "m" + string(rune('a'+i))
+		files[name+".cl"] = "let value = 1;"
+		main.WriteString("import \"" + name + "\";\n")
+	}
+	main.WriteString("1;")
+	files["main.cl"] = main.String()
+	dir := writeModuleFiles(t, files)
+	path := filepath.Join(dir, "main.cl")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			ResolveModule(path, "ma")
+			IsBuiltinModule("math")
+			BuiltinModuleNames()
+		}
+	}()
+	testIntegerObject(t, testEvalFile(t, path), 1)
+	<-done
+}
+
+func TestFileModulesReloadEachRun(t *testing.T) {
+	dir := writeModuleFiles(t, map[string]string{
+		"lib.cl":  `let value = 1;`,
+		"main.cl": `import "lib"; lib.value;`,
+	})
+	path := filepath.Join(dir, "main.cl")
+	testIntegerObject(t, testEvalFile(t, path), 1)
+
+	if err := os.WriteFile(filepath.Join(dir, "lib.cl"), []byte(`let value = 2;`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testIntegerObject(t, testEvalFile(t, path), 2)
+}
@@ -0,0 +1,283 @@
+package evaluator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/jsonrpc"
+	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/plugin"
+)
+
+// RegisterModule makes a Go module importable by name, exactly like the
+// std modules. It is meant to be called from init functions of modules
+// compiled in behind build tags, and panics on a name that is taken.
+func RegisterModule(name string, module *object.Module) {
+	if _, ok := cachedModule(name); ok {
+		panic(fmt.Sprintf("module %q is already registered", name))
+	}
+	cacheModule(name, module)
+}
+
+// pluginProcess is a running plugin executable and the pipe to it.
+type pluginProcess struct {
+	mu      sync.Mutex
+	key     string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	scanner *bufio.Scanner
+	nextID  int
+}
+
+// pluginKeyPrefix marks plugin modules in moduleCache.
+const pluginKeyPrefix = "plugin:"
+
+// runningPlugins holds the process behind each plugin module in
+// moduleCache. It is used under moduleCacheMu too.
+var runningPlugins = map[string]*pluginProcess{}
+
+// ClosePlugins asks every running plugin to exit and waits for it. Modules
+// backed by a closed plugin are dropped, so importing them again restarts
+// the plugin.
+func ClosePlugins() {
+	moduleCacheMu.Lock()
+	keys := make([]string, 0, len(runningPlugins))
+	for key := range runningPlugins {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	processes := make([]*pluginProcess, len(keys))
+	for i, key := range keys {
+		processes[i] = runningPlugins[key]
+		delete(runningPlugins, key)
+		delete(moduleCache, key)
+	}
+	moduleCacheMu.Unlock()
+
+	for _, p := range processes {
+		p.close()
+	}
+}
+
+func (e *Evaluator) loadPlugin(node *ast.ImportStatement, command []string) (*object.Module, *object.Error) {
+	key := pluginKeyPrefix + strings.Join(command, " ")
+
+	// The lock is held while the plugin starts so that two imports of it
+	// cannot start it twice.
+	moduleCacheMu.Lock()
+	defer moduleCacheMu.Unlock()
+	if module, ok := moduleCache[key]; ok {
+		return module, nil
+	}
+
+	p, functions, err := startPlugin(key, node.Path, command)
+	if err != nil {
+		return nil, object.NewError(node.Line(), node.Column(), "could not start plugin %q: %s", node.Path, err)
+	}
+
+	module := &object.Module{Members: map[string]object.Object{}}
+	for _, name := range functions {
+		module.Members[name] = p.builtin(node.Path, name)
+	}
+
+	runningPlugins[key] = p
+	moduleCache[key] = module
+
+	return module, nil
+}
+
+func startPlugin(key, name string, command []string) (*pluginProcess, []string, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	scanner.Split(jsonrpc.Spilt)
+
+	p := &pluginProcess{key: key, cmd: cmd, stdin: stdin, scanner: scanner}
+
+	var result plugin.InitializeResult
+	if err := p.request(plugin.MethodInitialize, plugin.InitializeParams{Module: name}, &result); err != nil {
+		p.close()
+		return nil, nil, err
+	}
+
+	return p, result.Functions, nil
+}
+
+func (p *pluginProcess) builtin(module, function string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
+			values := make([]any, len(args))
+			for i, arg := range args {
+				value, err := toPluginValue(arg)
+				if err != nil {
+					return object.NewError(node.Line(), node.Column(), "%s.%s: %s", module, function, err)
+				}
+				values[i] = value
+			}
+
+			var result any
+			if err := p.request(plugin.MethodCall, plugin.CallParams{Function: function, Args: values}, &result); err != nil {
+				return object.NewError(node.Line(), node.Column(), "%s.%s: %s", module, function, err)
+			}
+
+			return fromPluginValue(result)
+		},
+	}
+}
+
+// request sends one request and decodes the matching response into result.
+func (p *pluginProcess) request(method string, params any, result any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	id := p.nextID
+
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	msg, err := jsonrpc.EncodeMessage(plugin.Request{RPC: "2.0", ID: &id, Method: method, Params: rawParams})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(p.stdin, msg); err != nil {
+		return fmt.Errorf("plugin is not running: %w", err)
+	}
+
+	for p.scanner.Scan() {
+		_, content, err := jsonrpc.DecodeMessage(p.scanner.Bytes())
+		if err != nil {
+			return err
+		}
+
+		var resp plugin.Response
+		if err := json.Unmarshal(content, &resp); err != nil {
+			return err
+		}
+		if resp.ID != id {
+			continue
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(resp.Result))
+		dec.UseNumber()
+		return dec.Decode(result)
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("plugin exited")
+}
+
+func (p *pluginProcess) close() {
+	if msg, err := jsonrpc.EncodeMessage(plugin.Request{RPC: "2.0", Method: plugin.MethodExit}); err == nil {
+		io.WriteString(p.stdin, msg)
+	}
+	p.stdin.Close()
+	p.cmd.Wait()
+}
+
+func toPluginValue(obj object.Object) (any, error) {
+	switch o := obj.(type) {
+	case *object.Null:
+		return nil, nil
+	case *object.Boolean:
+		return o.Value, nil
+	case *object.Integer:
+		return o.Value, nil
+	case *object.Float:
+		return o.Value, nil
+	case *object.String:
+		return o.Value, nil
+	case *object.Char:
+		return string(o.Value), nil
+	case *object.Array:
+		values := make([]any, len(o.Elements))
+		for i, elem := range o.Elements {
+			value, err := toPluginValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case *object.Hash:
+		values := map[string]any{}
+		for _, pair := range o.Pairs {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return nil, fmt.Errorf("hash keys passed to plugins must be strings, got %s", pair.Key.Type())
+			}
+			value, err := toPluginValue(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			values[key.Value] = value
+		}
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("cannot pass %s to a plugin", obj.Type())
+}
+
+func fromPluginValue(value any) object.Object {
+	switch v := value.(type) {
+	case nil:
+		return object.NULL
+	case bool:
+		return nativeBoolToBooleanObject(v)
+	case string:
+		return &object.String{Value: v}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return &object.Integer{Value: i}
+		}
+		f, _ := v.Float64()
+		return &object.Float{Value: f}
+	case []any:
+		arr := &object.Array{Elements: make([]object.Object, len(v))}
+		for i, elem := range v {
+			arr.Elements[i] = fromPluginValue(elem)
+		}
+		return arr
+	case map[string]any:
+		pairs := map[object.HashKey]object.HashPair{}
+		for key, elem := range v {
+			k := &object.String{Value: key}
+			pairs[k.HashKey()] = object.HashPair{Key: k, Value: fromPluginValue(elem)}
+		}
+		return &object.Hash{Pairs: pairs}
+	}
+
+	return &object.String{Value: fmt.Sprintf("%v", value)}
+}
@@ -0,0 +1,181 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/plugin"
+)
+
+const testPluginEnv = "CODELANG_TEST_PLUGIN"
+
+// TestMain doubles as the plugin executable used by the plugin tests: the
+// test binary re-runs itself with testPluginEnv set.
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) == "1" {
+		if err := plugin.Serve(testPluginFuncs); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+var testPluginFuncs = map[string]plugin.Func{
+	"add": func(args []any) (any, error) {
+		var sum int64
+		for _, arg := range args {
+			n, ok := arg.(json.Number)
+			if !ok {
+				return nil, errors.New("add expects integers")
+			}
+			i, err := n.Int64()
+			if err != nil {
+				return nil, err
+			}
+			sum += i
+		}
+		return sum, nil
+	},
+	"describe": func(args []any) (any, error) {
+		return map[string]any{"count": len(args), "first": args[0], "ratio": 0.5}, nil
+	},
+}
+
+// testPluginCommand returns the command that serves testPluginFuncs. The
+// plugins started by the test are closed when it ends.
+func testPluginCommand(t *testing.T) string {
+	t.Helper()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(testPluginEnv, "1")
+	t.Cleanup(ClosePlugins)
+	return self
+}
+
+// testPluginManifest returns a code-lang.json that declares the test
+// plugin as the module mylib.
+func testPluginManifest(t *testing.T) string {
+	t.Helper()
+	manifest, err := json.Marshal(map[string]any{
+		"name":    "app",
+		"version": "0.1.0",
+		"plugins": map[string]string{"mylib": testPluginCommand(t)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(manifest)
+}
+
+func TestPluginModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import "mylib"; mylib.add(1, 2, 39);`, 42},
+		{`from "mylib" import add; add(20, 22);`, 42},
+		{`import "mylib"; mylib.describe(["a", 1])["count"];`, 1},
+		{`import "mylib"; mylib.describe("x")["first"];`, "x"},
+		{`import "mylib"; mylib.describe(true)["ratio"];`, 0.5},
+	}
+
+	for _, tt := range tests {
+		dir := writeModuleFiles(t, map[string]string{"code-lang.json": testPluginManifest(t), "main.cl": tt.input})
+		evaluated := testEvalFile(t, filepath.Join(dir, "main.cl"))
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("expected %q, got=%s", expected, evaluated.Inspect())
+			}
+		case float64:
+			f, ok := evaluated.(*object.Float)
+			if !ok || f.Value != expected {
+				t.Errorf("expected %v, got=%s", expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestPluginErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "mylib"; mylib.add("one");`, "mylib.add: add expects integers"},
+		{`import "mylib"; mylib.add(fn() {});`, "mylib.add: cannot pass FUNCTION to a plugin"},
+		{`import "mylib"; mylib.missing;`, "missing"},
+	}
+
+	for _, tt := range tests {
+		dir := writeModuleFiles(t, map[string]string{"code-lang.json": testPluginManifest(t), "main.cl": tt.input})
+		evaluated := testEvalFile(t, filepath.Join(dir, "main.cl"))
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected error for %q, got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if !strings.Contains(errObj.Message, tt.expected) {
+			t.Errorf("wrong error message. expected to contain %q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+// Concurrent imports of a plugin start one process; run with -race.
+func TestPluginStartsOnce(t *testing.T) {
+	self := testPluginCommand(t)
+
+	modules := make([]*object.Module, 4)
+	var wg sync.WaitGroup
+	for i := range modules {
+		wg.Go(func() {
+			e := &Evaluator{}
+			modules[i], _ = e.loadPlugin(&ast.ImportStatement{Path: "mylib"}, []string{self})
+		})
+	}
+	wg.Wait()
+
+	for _, module := range modules[1:] {
+		if module == nil || module != modules[0] {
+			t.Fatalf("expected every import to share one module, got %v", modules)
+		}
+	}
+	moduleCacheMu.RLock()
+	running := len(runningPlugins)
+	moduleCacheMu.RUnlock()
+	if running != 1 {
+		t.Errorf("expected 1 running plugin, got %d", running)
+	}
+}
+
+func TestRegisterModule(t *testing.T) {
+	RegisterModule("testnative", &object.Module{Members: map[string]object.Object{
+		"answer": &object.Integer{Value: 42},
+	}})
+	t.Cleanup(func() { delete(moduleCache, "testnative") })
+
+	testIntegerObject(t, testEval(`import "testnative"; testnative.answer;`), 42)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected RegisterModule to panic on a taken name")
+		}
+	}()
+	RegisterModule("math", &object.Module{})
+}
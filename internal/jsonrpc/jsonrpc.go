@@ -1,4 +1,6 @@
-package rpc
+// Package jsonrpc frames JSON-RPC messages with a Content-Length header,
+// as the language server and plugins exchange them.
+package jsonrpc
 
 import (
 	"bytes"
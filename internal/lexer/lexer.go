@@ -2,40 +2,49 @@ package lexer
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/walonCode/code-lang/internal/token"
 )
 
+// Lexer decodes its input as UTF-8. Positions and columns count bytes, so
+// they index the input directly.
 type Lexer struct {
 	input        string
 	position     int
 	readPosition int
-	ch           byte
-	line         int
-	column       int
+	ch           rune
+	// width is the number of bytes ch takes up in the input.
+	width  int
+	line   int
+	column int
 }
 
 // methods on the lexer
 func (l *Lexer) readChar() {
+	// columns advance by the bytes of the character before
+	previous := l.width
 	//check if we reach the end of the input
 	if l.readPosition >= len(l.input) {
 		//we set ch to 0 call ASCII 0 is NULL
 		l.ch = 0
-		l.column++
+		l.width = 1
+		l.column += previous
 	} else {
-		//if not we set the ch to current position
-		l.ch = l.input[l.readPosition]
+		//if not we decode the rune at the current position
+		l.ch, l.width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 		if l.ch == '\n' {
 			l.line++
 			l.column = 0
 		} else {
-			l.column++
+			l.column += previous
 		}
 	}
 	//set position to the current position of ch
 	l.position = l.readPosition
-	//increament the read position by 1
-	l.readPosition++
+	//increament the read position past ch
+	l.readPosition += l.width
 }
 
 func (l *Lexer) NextToken() token.Token {
@@ -43,6 +52,7 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.skipWhiteSpace()
 
+	start := l.position
 	currentLine := l.line
 	currentColumn := l.column
 
@@ -195,7 +205,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookUpIdent(tok.Literal)
 			tok.Line = currentLine
 			tok.Column = currentColumn
-			return tok
+			return l.finish(tok, start)
 		} else if isDigit(l.ch) {
 			tok.Literal = l.readNumber()
 			if strings.Contains(tok.Literal, ".") {
@@ -205,13 +215,29 @@ func (l *Lexer) NextToken() token.Token {
 			}
 			tok.Line = currentLine
 			tok.Column = currentColumn
-			return tok
+			return l.finish(tok, start)
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch, currentLine, currentColumn)
 		}
 	}
 
 	l.readChar()
+	return l.finish(tok, start)
+}
+
+// finish records the byte offsets tok covers, from start to the current
+// position, and the line and column just past it.
+func (l *Lexer) finish(tok token.Token, start int) token.Token {
+	start = min(start, len(l.input))
+	end := min(l.position, len(l.input))
+	text := l.input[start:end]
+
+	tok.Offset = start
+	tok.End = token.Position{Offset: end, Line: tok.Line, Column: tok.Column + len(text)}
+	if i := strings.LastIndexByte(text, '\n'); i != -1 {
+		tok.End.Line += strings.Count(text, "\n")
+		tok.End.Column = len(text) - i
+	}
 	return tok
 }
 
@@ -266,7 +292,7 @@ func (l *Lexer) readString() string {
 
 func (l *Lexer) readIndentifier() string {
 	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) {
+	for isLetter(l.ch) || isDigit(l.ch) || l.ch >= utf8.RuneSelf && unicode.IsDigit(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
@@ -304,29 +330,33 @@ func (l *Lexer) skipWhiteSpace() {
 	}
 }
 
-func (l *Lexer) peakChar() byte {
+func (l *Lexer) peakChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
-	} else {
-		return l.input[l.readPosition]
 	}
+	ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return ch
 }
 
 // helpers
-func newToken(tokenType token.TokenType, ch byte, line, column int) token.Token {
+func newToken(tokenType token.TokenType, ch rune, line, column int) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch), Line: line, Column: column}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isLetter reports whether ch may start an identifier: a Unicode letter
+// or an underscore.
+func isLetter(ch rune) bool {
+	return ch == '_' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
-func isDigit(ch byte) bool {
+// isDigit reports whether ch is an ASCII digit, the only digits numbers
+// are written with.
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input, line: 1, column: 0}
+	l := &Lexer{input: input, line: 1, column: 0, width: 1}
 	l.readChar()
 	return l
 }
@@ -290,3 +290,87 @@ let result = add(x, y);
 		}
 	}
 }
+
+func TestTokenEndPositions(t *testing.T) {
+	input := "let s = \"a\nb\";\n x >= 'c'"
+
+	tests := []struct {
+		expectedLiteral string
+		offset          int
+		end             token.Position
+	}{
+		{"let", 0, token.Position{Offset: 3, Line: 1, Column: 4}},
+		{"s", 4, token.Position{Offset: 5, Line: 1, Column: 6}},
+		{"=", 6, token.Position{Offset: 7, Line: 1, Column: 8}},
+		{"a\nb", 8, token.Position{Offset: 13, Line: 2, Column: 3}},
+		{";", 13, token.Position{Offset: 14, Line: 2, Column: 4}},
+		{"x", 16, token.Position{Offset: 17, Line: 3, Column: 3}},
+		{">=", 18, token.Position{Offset: 20, Line: 3, Column: 6}},
+		{"c", 21, token.Position{Offset: 24, Line: 3, Column: 10}},
+		{"", 24, token.Position{Offset: 24, Line: 3, Column: 10}},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Offset != tt.offset {
+			t.Fatalf("tests[%d] - offset wrong. expected=%d, got=%d", i, tt.offset, tok.Offset)
+		}
+
+		if tok.End != tt.end {
+			t.Fatalf("tests[%d] - end wrong. expected=%+v, got=%+v", i, tt.end, tok.End)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "let café = \"naïve\"; let π2 = 'é';\n日本 + x;"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		offset          int
+		line, column    int
+	}{
+		{token.LET, "let", 0, 1, 1},
+		{token.IDENT, "café", 4, 1, 5},
+		{token.ASSIGN, "=", 10, 1, 11},
+		{token.STRING, "naïve", 12, 1, 13},
+		{token.SEMICOLON, ";", 20, 1, 21},
+		{token.LET, "let", 22, 1, 23},
+		{token.IDENT, "π2", 26, 1, 27},
+		{token.ASSIGN, "=", 30, 1, 31},
+		{token.CHAR, "é", 32, 1, 33},
+		{token.SEMICOLON, ";", 36, 1, 37},
+		{token.IDENT, "日本", 38, 2, 1},
+		{token.PLUS, "+", 45, 2, 8},
+		{token.IDENT, "x", 47, 2, 10},
+		{token.SEMICOLON, ";", 48, 2, 11},
+		{token.EOF, "", 49, 2, 12},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%v %q, got=%v %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+
+		if tok.Offset != tt.offset || tok.Line != tt.line || tok.Column != tt.column {
+			t.Fatalf("tests[%d] - position wrong. expected=%d %d:%d, got=%d %d:%d",
+				i, tt.offset, tt.line, tt.column, tok.Offset, tok.Line, tok.Column)
+		}
+
+		if tt.expectedLiteral == "café" && tok.End != (token.Position{Offset: 9, Line: 1, Column: 10}) {
+			t.Fatalf("tests[%d] - end wrong. got=%+v", i, tok.End)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/walonCode/code-lang/internal/mod"
+)
+
+// Config turns rules on and off and sets their options. It is read from
+// the "lint" key of code-lang.json:
+//
+//	"lint": {
+//	    "rules": {
+//	        "no-magic-numbers": "warning",
+//	        "max-function-length": {"severity": "error", "max": 30},
+//	        "prefer-const": "off"
+//	    }
+//	}
+type Config struct {
+	Rules map[string]RuleConfig `json:"rules"`
+}
+
+// RuleConfig is a rule's entry in the config: either just a severity, or
+// an object with a "severity" and the rule's options.
+type RuleConfig struct {
+	Severity Severity
+	Options  map[string]any
+}
+
+func (rc *RuleConfig) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		severity, err := ParseSeverity(name)
+		rc.Severity = severity
+		return err
+	}
+
+	options := map[string]any{}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return fmt.Errorf("a rule is configured with a severity or an object")
+	}
+
+	rc.Severity = Warning
+	if s, ok := options["severity"]; ok {
+		name, ok := s.(string)
+		if !ok {
+			return fmt.Errorf("severity must be a string")
+		}
+		severity, err := ParseSeverity(name)
+		if err != nil {
+			return err
+		}
+		rc.Severity = severity
+		delete(options, "severity")
+	}
+	rc.Options = options
+	return nil
+}
+
+// ParseConfig reads a config and checks that it names known rules only.
+func ParseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return config, nil
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid lint config: %w", err)
+	}
+	for name := range config.Rules {
+		if _, ok := Lookup(name); !ok {
+			return nil, fmt.Errorf("invalid lint config: unknown rule %q", name)
+		}
+	}
+	return config, nil
+}
+
+// LoadConfig reads the lint config of the project dir belongs to. Without
+// a code-lang.json, or without a "lint" key in it, the defaults apply.
+func LoadConfig(dir string) (*Config, error) {
+	root, ok := mod.FindRoot(dir)
+	if !ok {
+		return &Config{}, nil
+	}
+	m, err := mod.LoadManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(m.Lint)
+}
+
+// settings returns the severity and options rule runs with.
+func (c *Config) settings(rule *Rule) (Severity, map[string]any) {
+	if c != nil {
+		if rc, ok := c.Rules[rule.Name]; ok {
+			return rc.Severity, rc.Options
+		}
+	}
+	return rule.Severity, nil
+}
+
+// Merge returns c with the rules override configures replacing its own.
+// A nil c, as LoadConfig returns on errors, counts as the defaults.
+func (c *Config) Merge(override *Config) *Config {
+	merged := &Config{Rules: map[string]RuleConfig{}}
+	if c != nil {
+		for name, rc := range c.Rules {
+			merged.Rules[name] = rc
+		}
+	}
+	if override != nil {
+		for name, rc := range override.Rules {
+			merged.Rules[name] = rc
+		}
+	}
+	return merged
+}
@@ -0,0 +1,213 @@
+// Package lint checks programs against style rules that go beyond what the
+// symbol builder reports: naming, function length, magic numbers and the
+// like. Rules are registered by name, enabled and configured per project in
+// code-lang.json, and may offer fixes as source edits.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+type Severity int
+
+const (
+	Off Severity = iota
+	Info
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Off:
+		return "off"
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSeverity reads a severity as written in the config.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "off":
+		return Off, nil
+	case "info":
+		return Info, nil
+	case "warning", "warn":
+		return Warning, nil
+	case "error":
+		return Error, nil
+	}
+	return Off, fmt.Errorf("unknown severity %q, expected off, info, warning or error", s)
+}
+
+// Rule is one check. Check walks the program and calls Report on the
+// context for every problem it finds.
+type Rule struct {
+	Name string
+	Doc  string
+	// Severity is used when the config does not mention the rule.
+	Severity Severity
+	Check    func(ctx *Context)
+}
+
+// Diagnostic is a problem a rule reported.
+type Diagnostic struct {
+	Rule     string
+	Severity Severity
+	Start    token.Position
+	End      token.Position
+	Message  string
+	Fixes    []Fix
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("[Line %d, Column %d] %s", d.Start.Line, d.Start.Column, d.Message)
+}
+
+// Fix is a named set of edits that resolves a diagnostic.
+type Fix struct {
+	Title string
+	Edits []Edit
+}
+
+// Edit replaces the source between Start and End with NewText.
+type Edit struct {
+	Start   token.Position
+	End     token.Position
+	NewText string
+}
+
+var rules = map[string]*Rule{}
+
+// Register adds a rule to the registry. It is meant to be called from init
+// functions, and panics on a name that is taken.
+func Register(rule *Rule) {
+	if _, ok := rules[rule.Name]; ok {
+		panic(fmt.Sprintf("lint rule %q is already registered", rule.Name))
+	}
+	rules[rule.Name] = rule
+}
+
+// Lookup returns the registered rule called name.
+func Lookup(name string) (*Rule, bool) {
+	rule, ok := rules[name]
+	return rule, ok
+}
+
+// Rules returns every registered rule, sorted by name.
+func Rules() []*Rule {
+	out := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, rule)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Context is what a rule sees while it checks a program.
+type Context struct {
+	Program *ast.Program
+	Source  string
+	// Options are the rule's settings from the config, as decoded from
+	// JSON.
+	Options map[string]any
+
+	rule        *Rule
+	severity    Severity
+	diagnostics []Diagnostic
+}
+
+// Report records a problem covering node.
+func (c *Context) Report(node ast.Node, message string, fixes ...Fix) {
+	c.ReportRange(node.Pos(), node.End(), message, fixes...)
+}
+
+// ReportRange records a problem covering the source between start and end.
+func (c *Context) ReportRange(start, end token.Position, message string, fixes ...Fix) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Rule:     c.rule.Name,
+		Severity: c.severity,
+		Start:    start,
+		End:      end,
+		Message:  message,
+		Fixes:    fixes,
+	})
+}
+
+// Int returns the integer option called name, or def when it is not set.
+func (c *Context) Int(name string, def int) int {
+	if v, ok := c.Options[name].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// String returns the string option called name, or def when it is not set.
+func (c *Context) String(name, def string) string {
+	if v, ok := c.Options[name].(string); ok {
+		return v
+	}
+	return def
+}
+
+// Run checks program against every rule config enables and returns what
+// they report in source order. A nil config enables the rules that are on
+// by default.
+func Run(program *ast.Program, source string, config *Config) []Diagnostic {
+	var diagnostics []Diagnostic
+	if program == nil {
+		return diagnostics
+	}
+
+	for _, rule := range Rules() {
+		severity, options := config.settings(rule)
+		if severity == Off {
+			continue
+		}
+		ctx := &Context{Program: program, Source: source, Options: options, rule: rule, severity: severity}
+		rule.Check(ctx)
+		diagnostics = append(diagnostics, ctx.diagnostics...)
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Start.Offset < diagnostics[j].Start.Offset
+	})
+	return diagnostics
+}
+
+// Apply returns source with the edits of fixes applied. Edits overlapping
+// one applied earlier are skipped, so the result is always well formed;
+// running the linter again finds what is left.
+func Apply(source string, fixes []Fix) string {
+	var edits []Edit
+	for _, fix := range fixes {
+		edits = append(edits, fix.Edits...)
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Start.Offset < edits[j].Start.Offset })
+
+	var out strings.Builder
+	last := 0
+	for _, edit := range edits {
+		start, end := edit.Start.Offset, edit.End.Offset
+		if start < last || end < start || end > len(source) {
+			continue
+		}
+		out.WriteString(source[last:start])
+		out.WriteString(edit.NewText)
+		last = end
+	}
+	out.WriteString(source[last:])
+	return out.String()
+}
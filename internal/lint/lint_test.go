@@ -0,0 +1,190 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/lexer"
+	"github.com/walonCode/code-lang/internal/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParsePrograme()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has %d errors: %v", len(p.Errors()), p.Errors())
+	}
+	return program
+}
+
+// only enables rule alone, at warning severity.
+func only(rule string, options map[string]any) *Config {
+	config := &Config{Rules: map[string]RuleConfig{}}
+	for _, r := range Rules() {
+		config.Rules[r.Name] = RuleConfig{Severity: Off}
+	}
+	config.Rules[rule] = RuleConfig{Severity: Warning, Options: options}
+	return config
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule     string
+		options  map[string]any
+		input    string
+		expected []string
+	}{
+		{
+			"naming", nil,
+			`let userName = 1; let user_id = 2; let Bad = 3; let mixed_Case = 4;
+let café = 7; let 日本 = 8; let Éclair = 9;
+const MAX_SIZE = 5; const tau = 6;
+struct point { x: 0 }; enum Color { red, Green };
+let f = fn(Arg) { Arg; };`,
+			[]string{
+				"variable Bad should start with a lowercase letter",
+				"variable mixed_Case should start with a lowercase letter",
+				"variable Éclair should start with a lowercase letter",
+				"struct point should be PascalCase",
+				"variant red should be PascalCase",
+				"parameter Arg should start with a lowercase letter",
+			},
+		},
+		{
+			"naming", map[string]any{"style": "snake_case"},
+			`let userName = 1; let user_id = 2;`,
+			[]string{"variable userName should be snake_case"},
+		},
+		{
+			"max-function-length", map[string]any{"max": float64(3)},
+			"let short = fn() {\n\t1;\n};\nlet long = fn() {\n\t1;\n\t2;\n};",
+			[]string{"function is 4 lines long, more than the 3 allowed"},
+		},
+		{
+			"no-magic-numbers", nil,
+			`const SECONDS = 60 * 60; struct Box { size: 10 };
+let a = 0; let b = -1; let c = 42; let d = -7; let e = 2.5;`,
+			[]string{"magic number 42", "magic number -7", "magic number 2.5"},
+		},
+		{
+			"prefer-const", nil,
+			`let a = 1; let b = 2; b = 3; let c = 4; c += 1;
+for (let i = 0; i < 3; i += 1) { let d = i; };`,
+			[]string{"a is never reassigned", "d is never reassigned"},
+		},
+		{
+			"no-print-in-library", nil,
+			`export let add = fn(a, b) { print(a); a + b; };`,
+			[]string{"library module calls print"},
+		},
+		{
+			"no-print-in-library", nil,
+			`let add = fn(a, b) { print(a); a + b; };`,
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		diags := Run(program, tt.input, only(tt.rule, tt.options))
+		if len(diags) != len(tt.expected) {
+			t.Errorf("%s: expected %d diagnostics, got %d: %v", tt.rule, len(tt.expected), len(diags), diags)
+			continue
+		}
+		for i, msg := range tt.expected {
+			if diags[i].Rule != tt.rule || !strings.Contains(diags[i].Message, msg) {
+				t.Errorf("%s: diagnostic %d wrong. want %q, got %q", tt.rule, i, msg, diags[i].Message)
+			}
+		}
+	}
+}
+
+func TestDefaultSeverities(t *testing.T) {
+	input := "let Count = 42;"
+	diags := Run(parse(t, input), input, nil)
+	if len(diags) != 1 || diags[0].Rule != "naming" || diags[0].Severity != Warning {
+		t.Errorf("expected only naming at warning by default, got %+v", diags)
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	tests := []struct {
+		rule     string
+		input    string
+		expected string
+	}{
+		{"prefer-const", "let a = 1;\nprint(a);", "const a = 1;\nprint(a);"},
+		{
+			"no-print-in-library",
+			"export let add = fn(a, b) {\n\tprint(a);\n\ta + b;\n};",
+			"export let add = fn(a, b) {\n\ta + b;\n};",
+		},
+		{
+			"no-print-in-library",
+			"export let add = fn(a, b) { print(a); a + b; };",
+			"export let add = fn(a, b) {  a + b; };",
+		},
+	}
+
+	for _, tt := range tests {
+		var fixes []Fix
+		for _, d := range Run(parse(t, tt.input), tt.input, only(tt.rule, nil)) {
+			fixes = append(fixes, d.Fixes...)
+		}
+		if got := Apply(tt.input, fixes); got != tt.expected {
+			t.Errorf("%s: wrong fix.\nwant %q\ngot  %q", tt.rule, tt.expected, got)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`{"rules": {
+		"prefer-const": "off",
+		"max-function-length": {"severity": "error", "max": 10}
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	rule, _ := Lookup("max-function-length")
+	severity, options := config.settings(rule)
+	if severity != Error || options["max"] != float64(10) {
+		t.Errorf("wrong settings: %s %v", severity, options)
+	}
+	rule, _ = Lookup("prefer-const")
+	if severity, _ := config.settings(rule); severity != Off {
+		t.Errorf("expected prefer-const to be off, got %s", severity)
+	}
+
+	for _, input := range []string{
+		`{"rules": {"no-such-rule": "error"}}`,
+		`{"rules": {"prefer-const": "loud"}}`,
+	} {
+		if _, err := ParseConfig([]byte(input)); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	project, _ := ParseConfig([]byte(`{"rules": {"prefer-const": "error", "naming": "off"}}`))
+	override, _ := ParseConfig([]byte(`{"rules": {"naming": "warning"}}`))
+	merged := project.Merge(override)
+
+	for name, expected := range map[string]Severity{"prefer-const": Error, "naming": Warning, "no-magic-numbers": Off} {
+		rule, _ := Lookup(name)
+		if severity, _ := merged.settings(rule); severity != expected {
+			t.Errorf("wrong severity for %s. expected=%s, got=%s", name, expected, severity)
+		}
+	}
+	if rule, _ := Lookup("naming"); project.Rules["naming"].Severity != Off {
+		t.Errorf("merging changed the project config of %s", rule.Name)
+	}
+
+	var missing *Config
+	if merged := missing.Merge(override); merged.Rules["naming"].Severity != Warning {
+		t.Errorf("merging into the defaults lost the override")
+	}
+}
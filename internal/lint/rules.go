@@ -0,0 +1,289 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/symbol"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+func init() {
+	Register(&Rule{
+		Name: "naming",
+		Doc: "Bindings and parameters start with a lowercase letter and use one style, " +
+			`set with the "style" option to "camelCase" or "snake_case"; constants may also be UPPER_CASE; ` +
+			"structs, enums and variants are PascalCase.",
+		Severity: Warning,
+		Check:    checkNaming,
+	})
+	Register(&Rule{
+		Name:     "max-function-length",
+		Doc:      `Functions are at most "max" lines long, 50 by default.`,
+		Severity: Warning,
+		Check:    checkFunctionLength,
+	})
+	Register(&Rule{
+		Name: "no-magic-numbers",
+		Doc: "Numbers other than -1, 0, 1 and 2 are given a name with const instead of being " +
+			"written inline. Struct field defaults are allowed.",
+		Severity: Off,
+		Check:    checkMagicNumbers,
+	})
+	Register(&Rule{
+		Name:     "prefer-const",
+		Doc:      "Bindings that are never reassigned are declared with const.",
+		Severity: Off,
+		Check:    checkPreferConst,
+	})
+	Register(&Rule{
+		Name:     "no-print-in-library",
+		Doc:      "Modules that export names do not call print or printf; the program importing them decides what to output.",
+		Severity: Warning,
+		Check:    checkPrintInLibrary,
+	})
+}
+
+// The styles accept any letter, not just ASCII: letters without case, as
+// in 日本, count as lowercase.
+var (
+	camelCase  = regexp.MustCompile(`^_*[\p{Ll}\p{Lo}][\p{L}\p{Nd}]*$`)
+	snakeCase  = regexp.MustCompile(`^_*[\p{Ll}\p{Lo}][\p{Ll}\p{Lo}\p{Nd}]*(_[\p{Ll}\p{Lo}\p{Nd}]+)*$`)
+	upperCase  = regexp.MustCompile(`^_*\p{Lu}[\p{Lu}\p{Nd}]*(_[\p{Lu}\p{Nd}]+)*$`)
+	pascalCase = regexp.MustCompile(`^_*\p{Lu}[\p{L}\p{Nd}]*$`)
+)
+
+func checkNaming(ctx *Context) {
+	style := ctx.String("style", "")
+	binding := func(ident *ast.Identifier, what string, constant bool) {
+		if ident == nil {
+			return
+		}
+		name := ident.Value
+		if constant && upperCase.MatchString(name) {
+			return
+		}
+		switch style {
+		case "camelCase":
+			if !camelCase.MatchString(name) {
+				ctx.Report(ident, fmt.Sprintf("%s %s should be camelCase", what, name))
+			}
+		case "snake_case":
+			if !snakeCase.MatchString(name) {
+				ctx.Report(ident, fmt.Sprintf("%s %s should be snake_case", what, name))
+			}
+		default:
+			if !camelCase.MatchString(name) && !snakeCase.MatchString(name) {
+				ctx.Report(ident, fmt.Sprintf("%s %s should start with a lowercase letter and be camelCase or snake_case", what, name))
+			}
+		}
+	}
+	typeName := func(ident *ast.Identifier, what string) {
+		if ident != nil && !pascalCase.MatchString(ident.Value) {
+			ctx.Report(ident, fmt.Sprintf("%s %s should be PascalCase", what, ident.Value))
+		}
+	}
+
+	ast.Inspect(ctx.Program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			binding(n.Name, "variable", false)
+		case *ast.ConstStatement:
+			binding(n.Name, "constant", true)
+		case *ast.FunctionLiteral:
+			for _, param := range n.Parameters {
+				binding(param, "parameter", false)
+			}
+		case *ast.StructStatement:
+			typeName(n.Name, "struct")
+		case *ast.EnumStatement:
+			typeName(n.Name, "enum")
+			for _, variant := range n.Variants {
+				if variant != nil {
+					typeName(variant.Name, "variant")
+				}
+			}
+		}
+		return true
+	})
+}
+
+func checkFunctionLength(ctx *Context) {
+	limit := ctx.Int("max", 50)
+	ast.Inspect(ctx.Program, func(node ast.Node) bool {
+		fn, ok := node.(*ast.FunctionLiteral)
+		if !ok {
+			return true
+		}
+		if lines := fn.End().Line - fn.Pos().Line + 1; lines > limit {
+			ctx.ReportRange(fn.Token.Pos(), fn.Token.End,
+				fmt.Sprintf("function is %d lines long, more than the %d allowed", lines, limit))
+		}
+		return true
+	})
+}
+
+func checkMagicNumbers(ctx *Context) {
+	allowed := map[float64]bool{-1: true, 0: true, 1: true, 2: true}
+
+	// Named values and defaults are where numbers belong.
+	named := map[ast.Node]bool{}
+	ast.Inspect(ctx.Program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.ConstStatement:
+			if _, ok := n.Value.(*ast.FunctionLiteral); !ok {
+				named[n.Value] = true
+			}
+		case *ast.StructStatement:
+			for _, value := range n.Fields {
+				named[value] = true
+			}
+		}
+		return true
+	})
+
+	report := func(node ast.Node, literal string, negative bool) {
+		value, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return
+		}
+		if negative {
+			value, literal = -value, "-"+literal
+		}
+		if !allowed[value] {
+			ctx.Report(node, fmt.Sprintf("magic number %s; give it a name with const", literal))
+		}
+	}
+
+	ast.Inspect(ctx.Program, func(node ast.Node) bool {
+		if named[node] {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.PrefixExpression:
+			if n.Operator != "-" {
+				return true
+			}
+			switch right := n.Right.(type) {
+			case *ast.IntegerLiteral, *ast.FloatLiteral:
+				report(n, right.TokenLiteral(), true)
+				return false
+			}
+		case *ast.IntegerLiteral:
+			report(n, n.TokenLiteral(), false)
+		case *ast.FloatLiteral:
+			report(n, n.TokenLiteral(), false)
+		}
+		return true
+	})
+}
+
+func checkPreferConst(ctx *Context) {
+	// Names are matched without regard to scope, so a binding is only
+	// flagged when no binding of that name is ever reassigned.
+	declared := map[string]int{}
+	assigned := map[string]bool{}
+	loopInits := map[ast.Node]bool{}
+	var lets []*ast.LetStatement
+
+	ast.Inspect(ctx.Program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			if n.Name != nil {
+				declared[n.Name.Value]++
+				lets = append(lets, n)
+			}
+		case *ast.ConstStatement:
+			if n.Name != nil {
+				declared[n.Name.Value]++
+			}
+		case *ast.ForExpression:
+			loopInits[n.Init] = true
+		case *ast.InfixExpression:
+			if ident, ok := n.Left.(*ast.Identifier); ok && symbol.IsAssignmentOp(n.Operator) {
+				assigned[ident.Value] = true
+			}
+		}
+		return true
+	})
+
+	for _, let := range lets {
+		name := let.Name.Value
+		if loopInits[let] || assigned[name] || declared[name] > 1 || ast.IsNil(let.Value) {
+			continue
+		}
+		ctx.Report(let.Name, fmt.Sprintf("%s is never reassigned; declare it with const", name), Fix{
+			Title: "Change let to const",
+			Edits: []Edit{{Start: let.Token.Pos(), End: let.Token.End, NewText: "const"}},
+		})
+	}
+}
+
+func checkPrintInLibrary(ctx *Context) {
+	library := false
+	for _, stmt := range ctx.Program.Statements {
+		if _, ok := stmt.(*ast.ExportStatement); ok {
+			library = true
+			break
+		}
+	}
+	if !library {
+		return
+	}
+
+	ast.Inspect(ctx.Program, func(node ast.Node) bool {
+		var stmt *ast.ExpressionStatement
+		call, ok := node.(*ast.CallExpression)
+		if s, isStmt := node.(*ast.ExpressionStatement); isStmt {
+			stmt = s
+			call, ok = s.Expression.(*ast.CallExpression)
+		}
+		if !ok {
+			return true
+		}
+		fn, ok := call.Function.(*ast.Identifier)
+		if !ok || (fn.Value != "print" && fn.Value != "printf") {
+			return true
+		}
+
+		message := fmt.Sprintf("library module calls %s; return the value and let the caller output it", fn.Value)
+		if stmt == nil {
+			ctx.Report(call, message)
+			return true
+		}
+		start, end := LineDeletion(ctx.Source, stmt.Pos(), stmt.End())
+		ctx.Report(call, message, Fix{
+			Title: "Remove the " + fn.Value + " call",
+			Edits: []Edit{{Start: start, End: end}},
+		})
+		return false
+	})
+}
+
+// LineDeletion widens the source between start and end to whole lines when
+// nothing else is on them, so deleting it leaves no blank line behind.
+func LineDeletion(source string, start, end token.Position) (token.Position, token.Position) {
+	lineStart := strings.LastIndexByte(source[:start.Offset], '\n') + 1
+	lineEnd := strings.IndexByte(source[end.Offset:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(source)
+	} else {
+		lineEnd += end.Offset + 1
+	}
+	if strings.TrimSpace(source[lineStart:start.Offset]) != "" || strings.TrimSpace(source[end.Offset:lineEnd]) != "" {
+		return start, end
+	}
+
+	start.Column -= start.Offset - lineStart
+	start.Offset = lineStart
+	if lineEnd > end.Offset && source[lineEnd-1] == '\n' {
+		end = token.Position{Offset: lineEnd, Line: end.Line + 1, Column: 1}
+	} else {
+		end.Column += lineEnd - end.Offset
+		end.Offset = lineEnd
+	}
+	return start, end
+}
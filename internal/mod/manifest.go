@@ -0,0 +1,211 @@
+package mod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ManifestFile declares a package's name, version and dependencies.
+	ManifestFile = "code-lang.json"
+	// LockFile records the source and content hash of every vendored package.
+	LockFile = "code-lang.lock"
+	// VendorDir holds the vendored dependencies next to the manifest.
+	VendorDir = "vendor"
+)
+
+type Manifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Dependencies maps a package name to its source: a local path
+	// (relative to the manifest) or a git URL, optionally pinned with #ref.
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	// Plugins maps a module name to the command that serves it over the
+	// plugin protocol. Relative commands are resolved against the manifest.
+	Plugins map[string]string `json:"plugins,omitempty"`
+	// Lint configures `code-lang lint`. It is kept as raw JSON here and
+	// read by the lint package.
+	Lint json.RawMessage `json:"lint,omitempty"`
+}
+
+type Lockfile struct {
+	Packages map[string]LockedPackage `json:"packages"`
+}
+
+type LockedPackage struct {
+	Source string `json:"source"`
+	Commit string `json:"commit,omitempty"`
+	Hash   string `json:"hash"`
+}
+
+func LoadManifest(dir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+	}
+	if m.Dependencies == nil {
+		m.Dependencies = map[string]string{}
+	}
+
+	return &m, nil
+}
+
+func (m *Manifest) Save(dir string) error {
+	return writeJSON(filepath.Join(dir, ManifestFile), m)
+}
+
+// LoadLockfile reads the lockfile in dir. A missing lockfile is not an
+// error and yields an empty one.
+func LoadLockfile(dir string) (*Lockfile, error) {
+	lock := &Lockfile{Packages: map[string]LockedPackage{}}
+
+	content, err := os.ReadFile(filepath.Join(dir, LockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", LockFile, err)
+	}
+	if lock.Packages == nil {
+		lock.Packages = map[string]LockedPackage{}
+	}
+
+	return lock, nil
+}
+
+func (l *Lockfile) Save(dir string) error {
+	return writeJSON(filepath.Join(dir, LockFile), l)
+}
+
+// Init writes a new manifest in dir. The package name defaults to the
+// directory name.
+func Init(dir, name string) (*Manifest, error) {
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+		return nil, fmt.Errorf("%s already exists", ManifestFile)
+	}
+
+	if name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		name = filepath.Base(abs)
+	}
+
+	m := &Manifest{
+		Name:         name,
+		Version:      "0.1.0",
+		Dependencies: map[string]string{},
+	}
+
+	return m, m.Save(dir)
+}
+
+// Add declares a dependency in the manifest in dir and vendors it. The
+// manifest is only updated when vendoring succeeds.
+func Add(dir, name, source string) (*Lockfile, error) {
+	if name == "" || source == "" {
+		return nil, errors.New("a package name and source are required")
+	}
+
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Dependencies[name] = source
+
+	lock, err := vendor(dir, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return lock, m.Save(dir)
+}
+
+// FindRoot walks up from dir looking for a manifest and returns the
+// directory containing it.
+func FindRoot(dir string) (string, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(abs, ManifestFile)); err == nil {
+			return abs, true
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", false
+		}
+		abs = parent
+	}
+}
+
+// VendorDirs returns the vendor directory of every package enclosing dir,
+// innermost first. Imports of packages by name are looked up there.
+func VendorDirs(dir string) []string {
+	var dirs []string
+
+	for {
+		root, ok := FindRoot(dir)
+		if !ok {
+			return dirs
+		}
+		dirs = append(dirs, filepath.Join(root, VendorDir))
+
+		parent := filepath.Dir(root)
+		if parent == root {
+			return dirs
+		}
+		dir = parent
+	}
+}
+
+// FindPlugin looks up a plugin module in the manifests enclosing dir,
+// innermost first, and returns its command line.
+func FindPlugin(dir, name string) ([]string, bool) {
+	for {
+		root, ok := FindRoot(dir)
+		if !ok {
+			return nil, false
+		}
+
+		if m, err := LoadManifest(root); err == nil {
+			if command := strings.Fields(m.Plugins[name]); len(command) > 0 {
+				if !filepath.IsAbs(command[0]) && strings.ContainsAny(command[0], `/\`) {
+					command[0] = filepath.Join(root, filepath.FromSlash(command[0]))
+				}
+				return command, true
+			}
+		}
+
+		parent := filepath.Dir(root)
+		if parent == root {
+			return nil, false
+		}
+		dir = parent
+	}
+}
+
+func writeJSON(path string, v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0o644)
+}
@@ -0,0 +1,363 @@
+package mod
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestInit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "myapp")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Init(dir, "")
+	if err != nil {
+		t.Fatalf("Init failed: %s", err)
+	}
+	if m.Name != "myapp" || m.Version != "0.1.0" {
+		t.Errorf("wrong manifest. got=%+v", m)
+	}
+
+	loaded, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %s", err)
+	}
+	if loaded.Name != "myapp" {
+		t.Errorf("wrong name. got=%q", loaded.Name)
+	}
+
+	if _, err := Init(dir, "other"); err == nil {
+		t.Errorf("expected Init to fail when %s exists", ManifestFile)
+	}
+}
+
+func TestAddLocalPackage(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "app")
+	writeFiles(t, root, map[string]string{
+		"libs/strutil/mod.cl":          `let shout = fn(s) { return s + "!"; };`,
+		"libs/strutil/code-lang.json":  `{"name": "strutil", "version": "1.0.0", "dependencies": {"chars": "../chars"}}`,
+		"libs/strutil/vendor/stale.cl": `let stale = 1;`,
+		"libs/chars/mod.cl":            `let bang = "!";`,
+	})
+	if err := os.MkdirAll(app, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Init(app, "app"); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Add(app, "strutil", "../libs/strutil")
+	if err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	for _, name := range []string{"strutil", "chars"} {
+		pkg, ok := lock.Packages[name]
+		if !ok {
+			t.Fatalf("package %s missing from lockfile", name)
+		}
+		if !strings.HasPrefix(pkg.Hash, "sha256:") {
+			t.Errorf("package %s has wrong hash %q", name, pkg.Hash)
+		}
+		if _, err := os.Stat(filepath.Join(app, VendorDir, name, "mod.cl")); err != nil {
+			t.Errorf("package %s was not vendored: %s", name, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(app, VendorDir, "strutil", VendorDir)); err == nil {
+		t.Errorf("nested vendor directory should not be copied")
+	}
+
+	m, err := LoadManifest(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Dependencies["strutil"] != "../libs/strutil" {
+		t.Errorf("dependency not saved. got=%v", m.Dependencies)
+	}
+
+	saved, err := LoadLockfile(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Packages["chars"].Hash != lock.Packages["chars"].Hash {
+		t.Errorf("lockfile not saved. got=%+v", saved)
+	}
+}
+
+func TestAddKeepsLintConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/code-lang.json": `{"name": "app", "version": "0.1.0", "lint": {"rules": {"prefer-const": "error"}}}`,
+		"libs/chars/mod.cl":  `let bang = "!";`,
+	})
+	app := filepath.Join(root, "app")
+
+	if _, err := Add(app, "chars", "../libs/chars"); err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	manifest := readFile(t, filepath.Join(app, ManifestFile))
+	if !strings.Contains(manifest, `"prefer-const": "error"`) {
+		t.Errorf("lint config lost when saving the manifest:\n%s", manifest)
+	}
+}
+
+func TestAddFailureKeepsManifest(t *testing.T) {
+	app := t.TempDir()
+	if _, err := Init(app, "app"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Add(app, "missing", "./does-not-exist"); err == nil {
+		t.Fatalf("expected Add to fail")
+	}
+
+	m, err := LoadManifest(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Dependencies) != 0 {
+		t.Errorf("manifest should be unchanged. got=%v", m.Dependencies)
+	}
+}
+
+func TestVendorFrozen(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"lib/mod.cl":         `let version = 1;`,
+		"app/code-lang.json": `{"name": "app", "version": "0.1.0", "dependencies": {"lib": "../lib"}}`,
+	})
+	app := filepath.Join(root, "app")
+
+	if _, err := Vendor(app, false); err != nil {
+		t.Fatalf("Vendor failed: %s", err)
+	}
+	if _, err := Vendor(app, true); err != nil {
+		t.Fatalf("frozen Vendor of unchanged package failed: %s", err)
+	}
+
+	writeFiles(t, root, map[string]string{"lib/mod.cl": `let version = 2;`})
+
+	_, err := Vendor(app, true)
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected hash mismatch error. got=%v", err)
+	}
+	if got := readFile(t, filepath.Join(app, VendorDir, "lib", "mod.cl")); got != `let version = 1;` {
+		t.Errorf("vendor directory changed by failed vendor. got=%q", got)
+	}
+}
+
+func TestVendorConflictingSources(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a/mod.cl":           `let a = 1;`,
+		"a/code-lang.json":   `{"name": "a", "version": "1.0.0", "dependencies": {"util": "./util"}}`,
+		"a/util/mod.cl":      `let x = 1;`,
+		"util/mod.cl":        `let x = 2;`,
+		"app/code-lang.json": `{"name": "app", "version": "0.1.0", "dependencies": {"a": "../a", "util": "../util"}}`,
+	})
+
+	_, err := Vendor(filepath.Join(root, "app"), false)
+	if err == nil || !strings.Contains(err.Error(), "required from both") {
+		t.Fatalf("expected conflict error. got=%v", err)
+	}
+}
+
+func TestVendorRejectsGitOptions(t *testing.T) {
+	for _, spec := range []string{"git+--upload-pack=touch pwned", "git+https://example.com/x.git#--orphan=x"} {
+		app := t.TempDir()
+		writeFiles(t, app, map[string]string{
+			"code-lang.json": `{"name": "app", "version": "0.1.0"}`,
+		})
+		_, err := Add(app, "x", spec)
+		if err == nil || !strings.Contains(err.Error(), "invalid git") {
+			t.Errorf("expected %q to be rejected. got=%v", spec, err)
+		}
+	}
+}
+
+func TestVendorRejectsPackageNames(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"lib/mod.cl":          `let x = 1;`,
+		"evil/mod.cl":         `let y = 1;`,
+		"evil/code-lang.json": `{"name": "evil", "version": "1.0.0", "dependencies": {"../../escaped": "../lib"}}`,
+		"app/code-lang.json":  `{"name": "app", "version": "0.1.0"}`,
+	})
+	app := filepath.Join(root, "app")
+
+	for _, name := range []string{"../../escaped", "a/b", `a\b`, "..", "."} {
+		if _, err := Add(app, name, "../lib"); err == nil || !strings.Contains(err.Error(), "invalid package name") {
+			t.Errorf("expected %q to be rejected. got=%v", name, err)
+		}
+	}
+	if _, err := Add(app, "evil", "../evil"); err == nil || !strings.Contains(err.Error(), "invalid package name") {
+		t.Errorf("expected a nested dependency name to be rejected. got=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped")); err == nil {
+		t.Errorf("a package was written outside the project")
+	}
+}
+
+// gitIn returns a function running git in dir, skipping the test when git
+// is not installed.
+func gitIn(t *testing.T, dir string) func(args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	return func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+}
+
+func TestAddGitPackage(t *testing.T) {
+
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	writeFiles(t, repo, map[string]string{"mod.cl": `let greeting = "v1";`})
+
+	git := gitIn(t, repo)
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	writeFiles(t, repo, map[string]string{"mod.cl": `let greeting = "v2";`})
+	git("commit", "--quiet", "-am", "v2")
+
+	app := filepath.Join(root, "app")
+	if err := os.Mkdir(app, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Init(app, "app"); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Add(app, "greet", "file://"+filepath.ToSlash(repo)+"#v1")
+	if err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	pkg := lock.Packages["greet"]
+	if len(pkg.Commit) != 40 {
+		t.Errorf("expected a commit hash. got=%q", pkg.Commit)
+	}
+	if got := readFile(t, filepath.Join(app, VendorDir, "greet", "mod.cl")); got != `let greeting = "v1";` {
+		t.Errorf("wrong revision vendored. got=%q", got)
+	}
+	if _, err := os.Stat(filepath.Join(app, VendorDir, "greet", ".git")); err == nil {
+		t.Errorf(".git should not be vendored")
+	}
+}
+
+func TestVendorKeepsLockedCommit(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	writeFiles(t, repo, map[string]string{"mod.cl": `let greeting = "v1";`})
+
+	git := gitIn(t, repo)
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+
+	app := filepath.Join(root, "app")
+	writeFiles(t, app, map[string]string{"code-lang.json": `{"name": "app", "version": "0.1.0"}`})
+	url := "file://" + filepath.ToSlash(repo)
+	first, err := Add(app, "greet", url)
+	if err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	writeFiles(t, repo, map[string]string{"mod.cl": `let greeting = "v2";`})
+	git("commit", "--quiet", "-am", "v2")
+
+	for _, frozen := range []bool{false, true} {
+		lock, err := Vendor(app, frozen)
+		if err != nil {
+			t.Fatalf("Vendor(frozen=%v) failed after upstream moved: %s", frozen, err)
+		}
+		if got := lock.Packages["greet"]; got != first.Packages["greet"] {
+			t.Errorf("Vendor(frozen=%v) changed the locked package. got=%+v, want=%+v", frozen, got, first.Packages["greet"])
+		}
+		if got := readFile(t, filepath.Join(app, VendorDir, "greet", "mod.cl")); got != `let greeting = "v1";` {
+			t.Errorf("Vendor(frozen=%v) did not keep the locked commit. got=%q", frozen, got)
+		}
+	}
+
+	// A changed spec is resolved again.
+	git("tag", "v2")
+	if _, err := Add(app, "greet", url+"#v2"); err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+	if got := readFile(t, filepath.Join(app, VendorDir, "greet", "mod.cl")); got != `let greeting = "v2";` {
+		t.Errorf("changed spec was not resolved again. got=%q", got)
+	}
+}
+
+func TestVendorInvalidSubManifest(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"lib/mod.cl":         `let x = 1;`,
+		"lib/code-lang.json": `{"name": "lib",`,
+		"app/code-lang.json": `{"name": "app", "version": "0.1.0", "dependencies": {"lib": "../lib"}}`,
+	})
+
+	_, err := Vendor(filepath.Join(root, "app"), false)
+	if err == nil || !strings.Contains(err.Error(), "package lib: invalid code-lang.json") {
+		t.Fatalf("expected the invalid manifest to be reported. got=%v", err)
+	}
+}
+
+func TestVendorDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"code-lang.json":       `{"name": "outer", "version": "0.1.0"}`,
+		"inner/code-lang.json": `{"name": "inner", "version": "0.1.0"}`,
+		"inner/src/main.cl":    ``,
+	})
+
+	dirs := VendorDirs(filepath.Join(root, "inner", "src"))
+	if len(dirs) < 2 {
+		t.Fatalf("expected at least 2 vendor dirs. got=%v", dirs)
+	}
+	if dirs[0] != filepath.Join(root, "inner", VendorDir) || dirs[1] != filepath.Join(root, VendorDir) {
+		t.Errorf("wrong vendor dirs. got=%v", dirs)
+	}
+}
@@ -0,0 +1,323 @@
+package mod
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Vendor fetches every dependency of the package in dir (and their own
+// dependencies) into its vendor directory and rewrites the lockfile. Git
+// packages whose spec has not changed are checked out at their locked
+// commit. With frozen set, it fails instead if a package's source or
+// content no longer matches the lockfile.
+func Vendor(dir string, frozen bool) (*Lockfile, error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return vendor(dir, m, frozen)
+}
+
+type source struct {
+	git      bool
+	location string
+	ref      string
+}
+
+// lockString formats the source for the lockfile. Local paths are
+// recorded relative to the package root so the lockfile can be committed.
+func (s source) lockString(root string) string {
+	if !s.git {
+		if rel, err := filepath.Rel(root, s.location); err == nil {
+			return filepath.ToSlash(rel)
+		}
+		return s.location
+	}
+	if s.ref != "" {
+		return "git+" + s.location + "#" + s.ref
+	}
+	return "git+" + s.location
+}
+
+// parseSource interprets a dependency spec. Git sources are written as
+// git+<url>, or as any URL with a scheme or a .git suffix, and may pin a
+// branch, tag or commit with #ref. Anything else is a local path relative
+// to base.
+func parseSource(spec, base string) source {
+	location, ref, _ := strings.Cut(spec, "#")
+
+	if strings.HasPrefix(location, "git+") {
+		return source{git: true, location: strings.TrimPrefix(location, "git+"), ref: ref}
+	}
+	if strings.Contains(location, "://") || strings.HasSuffix(location, ".git") {
+		return source{git: true, location: location, ref: ref}
+	}
+
+	if !filepath.IsAbs(location) {
+		location = filepath.Join(base, filepath.FromSlash(location))
+	}
+	return source{location: filepath.Clean(location)}
+}
+
+func vendor(dir string, m *Manifest, frozen bool) (*Lockfile, error) {
+	previous, err := LoadLockfile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	staging, err := os.MkdirTemp(dir, ".vendor-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	type pending struct {
+		name string
+		spec string
+		base string
+	}
+
+	queue := []pending{}
+	for _, name := range sortedKeys(m.Dependencies) {
+		queue = append(queue, pending{name: name, spec: m.Dependencies[name], base: dir})
+	}
+
+	lock := &Lockfile{Packages: map[string]LockedPackage{}}
+
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+
+		if err := checkPackageName(dep.name); err != nil {
+			return nil, err
+		}
+
+		src := parseSource(dep.spec, dep.base)
+		if locked, ok := lock.Packages[dep.name]; ok {
+			if locked.Source != src.lockString(dir) {
+				return nil, fmt.Errorf("package %s is required from both %s and %s", dep.name, locked.Source, src.lockString(dir))
+			}
+			continue
+		}
+
+		// A git package whose spec is unchanged is taken from the commit
+		// in the lockfile; only changed specs are resolved again.
+		locked, wasLocked := previous.Packages[dep.name]
+		unchanged := wasLocked && locked.Source == src.lockString(dir)
+		if frozen && !unchanged {
+			if !wasLocked {
+				return nil, fmt.Errorf("package %s is not in %s", dep.name, LockFile)
+			}
+			return nil, fmt.Errorf("package %s does not match %s: source is %s, want %s", dep.name, LockFile, src.lockString(dir), locked.Source)
+		}
+		at := src
+		if unchanged && src.git && locked.Commit != "" {
+			at.ref = locked.Commit
+		}
+
+		target := filepath.Join(staging, dep.name)
+		commit, err := fetch(at, target)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", dep.name, err)
+		}
+
+		hash, err := hashDir(target)
+		if err != nil {
+			return nil, err
+		}
+
+		if frozen && locked.Hash != hash {
+			return nil, fmt.Errorf("package %s does not match %s: got %s, want %s", dep.name, LockFile, hash, locked.Hash)
+		}
+
+		lock.Packages[dep.name] = LockedPackage{Source: src.lockString(dir), Commit: commit, Hash: hash}
+
+		sub, err := LoadManifest(target)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", dep.name, err)
+		}
+		base := target
+		if !src.git {
+			base = src.location
+		}
+		for _, name := range sortedKeys(sub.Dependencies) {
+			queue = append(queue, pending{name: name, spec: sub.Dependencies[name], base: base})
+		}
+	}
+
+	vendorDir := filepath.Join(dir, VendorDir)
+	if err := os.RemoveAll(vendorDir); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(staging, vendorDir); err != nil {
+		return nil, err
+	}
+
+	return lock, lock.Save(dir)
+}
+
+// checkPackageName rejects names that are not a single directory under
+// vendor. Names come from the manifests of fetched packages too, so one
+// must not write outside the project.
+func checkPackageName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid package name %q", name)
+	}
+	return nil
+}
+
+// fetch copies a package into target and returns the git commit it was
+// taken from, if any.
+func fetch(src source, target string) (string, error) {
+	if !src.git {
+		info, err := os.Stat(src.location)
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s is not a directory", src.location)
+		}
+		return "", copyDir(src.location, target)
+	}
+
+	// Specs come from the manifests of fetched packages too: never let
+	// one pass options to git.
+	if strings.HasPrefix(src.location, "-") {
+		return "", fmt.Errorf("invalid git source %q", src.location)
+	}
+	if strings.HasPrefix(src.ref, "-") {
+		return "", fmt.Errorf("invalid git ref %q", src.ref)
+	}
+
+	if _, err := runGit("", "clone", "--quiet", "--", src.location, target); err != nil {
+		return "", err
+	}
+	if src.ref != "" {
+		if _, err := runGit(target, "checkout", "--quiet", src.ref); err != nil {
+			return "", err
+		}
+	}
+
+	commit, err := runGit(target, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	return commit, os.RemoveAll(filepath.Join(target, ".git"))
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// copyDir copies a package tree, leaving out version control data and the
+// package's own vendor directory (dependencies are vendored flat).
+func copyDir(from, to string) error {
+	return filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" || rel == VendorDir || strings.HasPrefix(d.Name(), ".vendor-") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(to, rel), 0o755)
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		return copyFile(path, filepath.Join(to, rel))
+	})
+}
+
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// hashDir returns a content hash over every file in dir and its relative
+// path, independent of timestamps and permissions.
+func hashDir(dir string) (string, error) {
+	h := sha256.New()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(content)
+		fmt.Fprintf(h, "%s %x\n", filepath.ToSlash(rel), sum)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,6 +35,8 @@ const (
 	STRUCT_INSTANCE  = "STRUCT"
 	BREAK_OBJ        = "BREAK"
 	CONTINUE_OBJ     = "CONTINUE"
+	ENUM_TYPE_OBJ    = "ENUM_TYPE"
+	ENUM_OBJ         = "ENUM"
 )
 
 // this allows us only to have on Bolean object and Null object
@@ -148,6 +151,9 @@ func (s *Float) Inspect() string  { return fmt.Sprintf("%f", s.Value) }
 // builtin obj
 type Builtin struct {
 	Fn BuiltinFunction
+	// Signature documents the builtin. It is nil for builtins made at
+	// runtime, such as plugin functions.
+	Signature *Signature
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
@@ -273,3 +279,90 @@ type Continue struct{}
 
 func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
 func (c *Continue) Inspect() string  { return "continue" }
+
+// enum type, holds the variants declared by an enum statement
+type EnumType struct {
+	Name     string
+	Variants map[string]Object
+}
+
+func (e *EnumType) Type() ObjectType { return ENUM_TYPE_OBJ }
+func (e *EnumType) Inspect() string  { return "enum " + e.Name }
+
+// enum value, a single variant with its (optional) payload
+type EnumValue struct {
+	EnumName string
+	Variant  string
+	Fields   []string
+	Values   []Object
+}
+
+func (e *EnumValue) Type() ObjectType { return ENUM_OBJ }
+func (e *EnumValue) Inspect() string {
+	var out bytes.Buffer
+	out.WriteString(e.EnumName + "." + e.Variant)
+	if len(e.Fields) == 0 {
+		return out.String()
+	}
+
+	values := []string{}
+	for _, v := range e.Values {
+		if str, ok := v.(*String); ok {
+			values = append(values, strconv.Quote(str.Value))
+			continue
+		}
+		values = append(values, v.Inspect())
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(values, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+// Field returns the payload value stored under the given field name.
+func (e *EnumValue) Field(name string) (Object, bool) {
+	for i, f := range e.Fields {
+		if f == name && i < len(e.Values) {
+			return e.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+// Equals reports whether both values are the same variant of the same enum
+// carrying equal payloads.
+func (e *EnumValue) Equals(other *EnumValue) bool {
+	if e.EnumName != other.EnumName || e.Variant != other.Variant {
+		return false
+	}
+	if len(e.Values) != len(other.Values) {
+		return false
+	}
+	for i := range e.Values {
+		if !objectsEqual(e.Values[i], other.Values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func objectsEqual(a, b Object) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil || a.Type() != b.Type() {
+		return false
+	}
+	switch a := a.(type) {
+	case *EnumValue:
+		return a.Equals(b.(*EnumValue))
+	case Hashable:
+		return a.HashKey() == b.(Hashable).HashKey()
+	case *Float:
+		return a.Value == b.(*Float).Value
+	case *Char:
+		return a.Value == b.(*Char).Value
+	default:
+		return false
+	}
+}
@@ -58,3 +58,21 @@ func TestIntegerHashKey(t *testing.T) {
 		t.Errorf("integers with twoerent content have same hash keys")
 	}
 }
+
+func TestSignatureFormat(t *testing.T) {
+	tests := []struct {
+		sig      *Signature
+		expected string
+	}{
+		{&Signature{Returns: "int"}, "f() -> int"},
+		{&Signature{Params: []Param{{Name: "a", Type: "int"}, {Name: "b", Type: "string"}}, Returns: "bool"}, "f(a: int, b: string) -> bool"},
+		{&Signature{Params: []Param{{Name: "values", Type: "any"}}, Variadic: true}, "f(...values: any)"},
+		{&Signature{Params: []Param{{Name: "code", Type: "int", Optional: true}}, Returns: "null"}, "f(code?: int) -> null"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.sig.Format("f"); got != tt.expected {
+			t.Errorf("wrong signature. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package object
+
+import "strings"
+
+// Param is one documented parameter of a builtin.
+type Param struct {
+	Name string
+	Type string
+	// Optional parameters may be left out of a call.
+	Optional bool
+}
+
+// Signature documents a Go-backed builtin: what it takes, what it returns
+// and what it does. Editors and `code-lang doc` read it; the evaluator
+// never does.
+type Signature struct {
+	Params []Param
+	// Variadic means the last parameter may be passed any number of times.
+	Variadic bool
+	Returns  string
+	Doc      string
+}
+
+// ParamLabel renders parameter i as it appears in Format, e.g. "x: number".
+func (s *Signature) ParamLabel(i int) string {
+	p := s.Params[i]
+	label := p.Name
+	if s.Variadic && i == len(s.Params)-1 {
+		label = "..." + label
+	}
+	if p.Optional {
+		label += "?"
+	}
+	if p.Type != "" {
+		label += ": " + p.Type
+	}
+	return label
+}
+
+// Format renders the signature of the builtin called name, e.g.
+// "pow(base: number, exp: number) -> float".
+func (s *Signature) Format(name string) string {
+	params := make([]string, len(s.Params))
+	for i := range s.Params {
+		params[i] = s.ParamLabel(i)
+	}
+
+	var out strings.Builder
+	out.WriteString(name)
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	if s.Returns != "" {
+		out.WriteString(" -> ")
+		out.WriteString(s.Returns)
+	}
+	return out.String()
+}
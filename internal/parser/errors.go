@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// ParseError describes one syntax error: the source it covers, the tokens
+// the parser could have accepted there, the token it found instead and a
+// hint on how to fix it.
+type ParseError struct {
+	Start    token.Position
+	End      token.Position
+	Expected []token.TokenType
+	Found    token.Token
+	Message  string
+	Hint     string
+}
+
+// Error renders the error in the "[Line l, Column c]message" form the
+// other tools print.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("[Line %d, Column %d]%s", e.Start.Line, e.Start.Column, e.Message)
+}
+
+// Snippet renders the error with the source line it is on and a caret
+// under the offending token:
+//
+//	error: expected ';', found 'let'
+//	 --> line 2, column 1
+//	  |
+//	2 | let y = 6;
+//	  | ^^^
+//	  = hint: statements end with ';' - add one at the end of the previous line
+func (e *ParseError) Snippet(source string) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "error: %s\n", e.Message)
+	fmt.Fprintf(&out, " --> line %d, column %d\n", e.Start.Line, e.Start.Column)
+
+	lines := strings.Split(source, "\n")
+	if e.Start.Line >= 1 && e.Start.Line <= len(lines) {
+		line := strings.TrimRight(lines[e.Start.Line-1], "\r")
+		gutter := strings.Repeat(" ", len(fmt.Sprint(e.Start.Line)))
+
+		col := min(max(e.Start.Column-1, 0), len(line))
+		width := 1
+		if e.End.Line == e.Start.Line && e.End.Column > e.Start.Column {
+			width = e.End.Column - e.Start.Column
+		}
+
+		// Tabs are kept so the caret lines up however they are rendered.
+		pad := []byte(line[:col])
+		for i, c := range pad {
+			if c != '\t' {
+				pad[i] = ' '
+			}
+		}
+
+		fmt.Fprintf(&out, "%s |\n", gutter)
+		fmt.Fprintf(&out, "%d | %s\n", e.Start.Line, line)
+		fmt.Fprintf(&out, "%s | %s%s\n", gutter, pad, strings.Repeat("^", width))
+		if e.Hint != "" {
+			fmt.Fprintf(&out, "%s = hint: %s\n", gutter, e.Hint)
+		}
+	} else if e.Hint != "" {
+		fmt.Fprintf(&out, " = hint: %s\n", e.Hint)
+	}
+
+	return out.String()
+}
+
+// addError records an error at found. While recovering from an earlier
+// error in the same statement, further errors are dropped, since they are
+// almost always caused by the first.
+func (p *Parser) addError(found token.Token, expected []token.TokenType, message, hint string) {
+	if p.recovering {
+		return
+	}
+	p.recovering = true
+
+	p.errors = append(p.errors, &ParseError{
+		Start:    found.Pos(),
+		End:      found.End,
+		Expected: expected,
+		Found:    found,
+		Message:  message,
+		Hint:     hint,
+	})
+}
+
+func (p *Parser) peekError(t token.TokenType) {
+	msg := fmt.Sprintf("expected %s, found %s", describeType(t), describeToken(p.peekToken))
+	p.addError(p.peekToken, []token.TokenType{t}, msg, expectHint(t, p.curToken, p.peekToken))
+}
+
+func (p *Parser) noPrefixParseError(t token.TokenType) {
+	msg := fmt.Sprintf("expected an expression, found %s", describeToken(p.curToken))
+	hint := ""
+	switch t {
+	case token.RPAREN, token.RBRACKET, token.RBRACE, token.SEMICOLON, token.COMMA:
+		hint = fmt.Sprintf("a value is missing before %s", describeType(t))
+	case token.EOF:
+		hint = "the input ends in the middle of an expression"
+	}
+	p.addError(p.curToken, nil, msg, hint)
+}
+
+// expectHint suggests a fix for a missing token.
+func expectHint(t token.TokenType, cur, found token.Token) string {
+	switch t {
+	case token.SEMICOLON:
+		if found.Line > cur.Line {
+			return "statements end with ';' - add one at the end of the previous line"
+		}
+		return "add ';' to end the statement"
+	case token.RPAREN:
+		return "add ')' to close the parenthesis"
+	case token.RBRACKET:
+		return "add ']' to close the bracket"
+	case token.RBRACE:
+		return "add '}' to close the block"
+	case token.LBRACE:
+		return "the body must be wrapped in braces"
+	case token.ASSIGN:
+		if cur.Type == token.IDENT {
+			return fmt.Sprintf("give %q a value: %s = value;", cur.Literal, cur.Literal)
+		}
+	case token.IDENT:
+		if token.LookUpIdent(found.Literal) != token.IDENT && found.Literal != "" {
+			return fmt.Sprintf("%q is a keyword and cannot be used as a name", found.Literal)
+		}
+	}
+	return ""
+}
+
+// synchronize skips the rest of a statement that failed to parse, so the
+// next one is parsed from a clean start. It stops on the statement's
+// semicolon, before a closing brace or a keyword that starts a statement,
+// and skips balanced braces on the way.
+func (p *Parser) synchronize() {
+	defer func() { p.recovering = false }()
+
+	depth := 0
+	for !p.curTokenIs(token.EOF) {
+		switch p.curToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+
+		if depth == 0 && (p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) || startsStatement(p.peekToken.Type)) {
+			return
+		}
+		p.nextToken()
+	}
+}
+
+func startsStatement(t token.TokenType) bool {
+	switch t {
+	case token.LET, token.CONST, token.RETURN, token.IMPORT, token.FROM,
+		token.STRUCT, token.ENUM, token.EXPORT, token.BREAK, token.CONTINUE:
+		return true
+	}
+	return false
+}
+
+// describeType names a token type the way it reads in source.
+func describeType(t token.TokenType) string {
+	switch t {
+	case token.IDENT:
+		return "a name"
+	case token.INT, token.FLOAT:
+		return "a number"
+	case token.STRING:
+		return "a string"
+	case token.CHAR:
+		return "a char"
+	case token.EOF:
+		return "end of input"
+	case token.FUNCTION:
+		return "'fn'"
+	case token.ELSE_IF:
+		return "'elseif'"
+	}
+	if s := string(t); strings.ToUpper(s) == s && strings.ToLower(s) != s {
+		return "'" + strings.ToLower(s) + "'"
+	}
+	return "'" + string(t) + "'"
+}
+
+// describeToken names a token found in the source, with its text.
+func describeToken(tok token.Token) string {
+	switch tok.Type {
+	case token.IDENT:
+		return fmt.Sprintf("name %q", tok.Literal)
+	case token.INT, token.FLOAT:
+		return "number " + tok.Literal
+	case token.STRING:
+		return fmt.Sprintf("string %q", tok.Literal)
+	case token.CHAR:
+		return fmt.Sprintf("char '%s'", tok.Literal)
+	case token.EOF:
+		return "end of input"
+	case token.ILLEGAL:
+		return fmt.Sprintf("unexpected character %q", tok.Literal)
+	}
+	return "'" + tok.Literal + "'"
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/walonCode/code-lang/internal/ast"
 	"github.com/walonCode/code-lang/internal/lexer"
@@ -77,7 +78,10 @@ type (
 type Parser struct {
 	l *lexer.Lexer
 
-	errors []string
+	errors []*ParseError
+	// recovering is set from an error until the parser has skipped to the
+	// next statement.
+	recovering bool
 
 	curToken  token.Token
 	peekToken token.Token
@@ -107,6 +111,8 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseReturnStatement()
 	case token.IMPORT:
 		return p.parseImportStatement()
+	case token.FROM:
+		return p.parseFromImportStatement()
 	case token.STRUCT:
 		return p.parseStructStatement()
 	case token.BREAK:
@@ -115,20 +121,38 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseContinueStatement()
 	case token.CONST:
 		return p.parseConstStatement()
+	case token.ENUM:
+		return p.parseEnumStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
 }
 
+// parseStatementOrSkip parses a statement and, when that fails, skips to
+// where the next one starts. What was parsed of a broken statement is
+// still returned.
+func (p *Parser) parseStatementOrSkip() ast.Statement {
+	errors := len(p.errors)
+	stmt := p.parseStatement()
+	if len(p.errors) != errors || p.recovering {
+		p.synchronize()
+	}
+	return stmt
+}
+
 func (p *Parser) parseBreakStatement() *ast.BreakStatement {
 	stmt := &ast.BreakStatement{Token: p.curToken}
 	p.expectPeek(token.SEMICOLON)
+	p.finish(stmt, stmt.Token.Pos())
 	return stmt
 }
 
 func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
 	stmt := &ast.ContinueStatement{Token: p.curToken}
 	p.expectPeek(token.SEMICOLON)
+	p.finish(stmt, stmt.Token.Pos())
 	return stmt
 }
 
@@ -139,10 +163,7 @@ func (p *Parser) parseStructStatement() *ast.StructStatement {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
-	}
+	stmt.Name = p.identifier()
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
@@ -176,6 +197,93 @@ func (p *Parser) parseStructStatement() *ast.StructStatement {
 		return nil
 	}
 
+	p.finish(stmt, stmt.Token.Pos())
+	return stmt
+}
+
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	switch p.curToken.Type {
+	case token.LET:
+		if s := p.parseLetStatement(); s != nil {
+			stmt.Statement = s
+		}
+	case token.CONST:
+		if s := p.parseConstStatement(); s != nil {
+			stmt.Statement = s
+		}
+	case token.STRUCT:
+		if s := p.parseStructStatement(); s != nil {
+			stmt.Statement = s
+		}
+	case token.ENUM:
+		if s := p.parseEnumStatement(); s != nil {
+			stmt.Statement = s
+		}
+	default:
+		expected := []token.TokenType{token.LET, token.CONST, token.STRUCT, token.ENUM}
+		msg := fmt.Sprintf("export must be followed by let, const, struct or enum, found %s", describeToken(p.curToken))
+		p.addError(p.curToken, expected, msg, "only bindings, structs and enums can be exported")
+	}
+
+	if stmt.Statement == nil {
+		return nil
+	}
+
+	p.finish(stmt, stmt.Token.Pos())
+	return stmt
+}
+
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = p.identifier()
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		variant := &ast.EnumVariant{
+			Name: p.identifier(),
+		}
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			variant.Fields = p.parseFunctionParameters()
+			if variant.Fields == nil {
+				return nil
+			}
+		}
+
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	// the trailing semicolon is optional for enums
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	p.finish(stmt, stmt.Token.Pos())
 	return stmt
 }
 
@@ -188,10 +296,53 @@ func (p *Parser) parseImportStatement() *ast.ImportStatement {
 
 	exp.Path = p.curToken.Literal
 
+	if p.peekTokenIs(token.AS) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		exp.Alias = p.identifier()
+	}
+
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+
+	p.finish(exp, exp.Token.Pos())
+	return exp
+}
+
+func (p *Parser) parseFromImportStatement() *ast.ImportStatement {
+	exp := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+
+	exp.Path = p.curToken.Literal
+
+	if !p.expectPeek(token.IMPORT) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Names = append(exp.Names, p.identifier())
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		exp.Names = append(exp.Names, p.identifier())
+	}
+
 	if !p.expectPeek(token.SEMICOLON) {
 		return nil
 	}
 
+	p.finish(exp, exp.Token.Pos())
 	return exp
 }
 
@@ -204,6 +355,7 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 		return nil
 	}
 
+	p.finish(stmt, stmt.Token.Pos())
 	return stmt
 }
 
@@ -213,7 +365,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, nil, msg, "")
 		return nil
 	}
 
@@ -228,7 +380,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.Atoi(p.curToken.Literal)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, nil, msg, "integers must fit in 64 bits")
 		return nil
 	}
 
@@ -237,11 +389,6 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return il
 }
 
-func (p *Parser) noPrefixParseError(t token.TokenType) {
-	msg := fmt.Sprintf("[Line %d, Column %d]no prefix parse function for %s found", p.curToken.Line, p.curToken.Column, t)
-	p.errors = append(p.errors, msg)
-}
-
 func (p *Parser) parseExpression(predence int) ast.Expression {
 	// defer untrace(trace("parseExpression"))
 	prefix := p.prefixParseFns[p.curToken.Type]
@@ -250,10 +397,14 @@ func (p *Parser) parseExpression(predence int) ast.Expression {
 		return nil
 	}
 
+	first := p.curToken.Pos()
 	leftExp := prefix()
+	p.finishExpression(leftExp, first)
 
 	if p.peekTokenIs(token.LBRACE) {
-		return p.parseStructLiteral(leftExp)
+		leftExp = p.parseStructLiteral(leftExp)
+		p.finishExpression(leftExp, first)
+		return leftExp
 	}
 
 	for !p.peekTokenIs(token.SEMICOLON) && predence < p.peekPredences() {
@@ -264,7 +415,11 @@ func (p *Parser) parseExpression(predence int) ast.Expression {
 
 		p.nextToken()
 
+		if leftExp == nil {
+			first = p.curToken.Pos()
+		}
 		leftExp = infix(leftExp)
+		p.finishExpression(leftExp, first)
 	}
 
 	return leftExp
@@ -288,7 +443,9 @@ func (p *Parser) parseStructLiteral(name ast.Expression) ast.Expression {
 		p.nextToken()
 		key := p.curToken.Literal
 
-		p.expectPeek(token.COLON)
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
 		p.nextToken()
 
 		value := p.parseExpression(LOWEST)
@@ -299,7 +456,9 @@ func (p *Parser) parseStructLiteral(name ast.Expression) ast.Expression {
 		}
 	}
 
-	p.expectPeek(token.RBRACE)
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
 	return lit
 }
 
@@ -314,6 +473,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 		return nil
 	}
 
+	p.finish(stmt, stmt.Token.Pos())
 	return stmt
 }
 
@@ -323,7 +483,7 @@ func (p *Parser) parseConstStatement() *ast.ConstStatement {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Name = p.identifier()
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -337,6 +497,7 @@ func (p *Parser) parseConstStatement() *ast.ConstStatement {
 		return nil
 	}
 
+	p.finish(stmt, stmt.Token.Pos())
 	return stmt
 }
 
@@ -346,7 +507,7 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Name = p.identifier()
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -360,6 +521,7 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
+	p.finish(stmt, stmt.Token.Pos())
 	return stmt
 }
 
@@ -385,32 +547,34 @@ func (p *Parser) ParsePrograme() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
+		if stmt := p.parseStatementOrSkip(); stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
 	}
 
+	program.SetSpan(token.Position{Line: 1, Column: 1}, p.curToken.Pos())
 	return program
 }
 
+// Errors returns the syntax errors as "[Line l, Column c]message" strings.
 func (p *Parser) Errors() []string {
-	return p.errors
+	msgs := make([]string, len(p.errors))
+	for i, err := range p.errors {
+		msgs[i] = err.Error()
+	}
+	return msgs
 }
 
-func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("[Line %d, Column %d]expect next token to be %s, got %s instead",
-		p.curToken.Line, p.curToken.Column,
-		t, p.peekToken.Type,
-	)
-	p.errors = append(p.errors, msg)
+// ParseErrors returns the syntax errors with their spans and hints.
+func (p *Parser) ParseErrors() []*ParseError {
+	return p.errors
 }
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []*ParseError{},
 	}
 
 	p.nextToken()
@@ -474,10 +638,7 @@ func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
 		return nil
 	}
 
-	exp.Property = &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
-	}
+	exp.Property = p.identifier()
 
 	return exp
 }
@@ -546,7 +707,9 @@ func (p *Parser) parseForExpression() ast.Expression {
 		// parseStatement requires one, so we parse expression and wrap.
 		postExp := p.parseExpression(LOWEST)
 		if postExp != nil {
-			exp.Post = &ast.ExpressionStatement{Token: p.curToken, Expression: postExp}
+			post := &ast.ExpressionStatement{Token: p.curToken, Expression: postExp}
+			post.SetSpan(postExp.Pos(), postExp.End())
+			exp.Post = post
 		}
 	}
 
@@ -641,7 +804,8 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 }
 
 func (p *Parser) parseCharLiteral() ast.Expression {
-	return &ast.CharLiteral{Token: p.curToken, Value: rune(p.curToken.Literal[0])}
+	value, _ := utf8.DecodeRuneInString(p.curToken.Literal)
+	return &ast.CharLiteral{Token: p.curToken, Value: value}
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -677,13 +841,13 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 
 	p.nextToken()
 
-	iden := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	iden := p.identifier()
 	idens = append(idens, iden)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		iden := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		iden := p.identifier()
 		idens = append(idens, iden)
 	}
 
@@ -760,13 +924,13 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	p.nextToken()
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
+		if stmt := p.parseStatementOrSkip(); stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 		p.nextToken()
 	}
 
+	p.finish(block, block.Token.Pos())
 	return block
 }
 
@@ -816,5 +980,35 @@ func (p *Parser) parseBoolean() ast.Expression {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return p.identifier()
+}
+
+// identifier returns the current token as an identifier.
+func (p *Parser) identifier() *ast.Identifier {
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident.SetSpan(p.curToken.Pos(), p.curToken.End)
+	return ident
+}
+
+type spanner interface {
+	SetSpan(start, end token.Position)
+}
+
+// finish records that node runs from first to the end of the current
+// token. Parse functions leave the current token on the last token of
+// what they parsed.
+func (p *Parser) finish(node spanner, first token.Position) {
+	node.SetSpan(first, p.curToken.End)
+}
+
+// finishExpression records the span of an expression parseExpression
+// built, unless an inner call already did, as for a grouped expression
+// whose span leaves out the parentheses.
+func (p *Parser) finishExpression(exp ast.Expression, first token.Position) {
+	if exp == nil || exp.End().Line != 0 {
+		return
+	}
+	if node, ok := exp.(spanner); ok {
+		p.finish(node, first)
+	}
 }
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/walonCode/code-lang/internal/ast"
@@ -1341,3 +1342,297 @@ func TestContinueStatement(t *testing.T) {
 		t.Errorf("continueStmt.TokenLiteral() not 'continue'. got=%s", continueStmt.TokenLiteral())
 	}
 }
+
+func TestEnumStatement(t *testing.T) {
+	input := `enum Status { Active, Suspended(reason), Deleted }`
+
+	l := lexer.New(input)
+	p := New(l)
+	programe := p.ParsePrograme()
+	checkParserErrors(t, p)
+
+	if len(programe.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(programe.Statements))
+	}
+
+	enum, ok := programe.Statements[0].(*ast.EnumStatement)
+	if !ok {
+		t.Fatalf("stmt is not *ast.EnumStatement. got=%T", programe.Statements[0])
+	}
+
+	if enum.Name.Value != "Status" {
+		t.Errorf("enum.Name.Value not 'Status'. got=%s", enum.Name.Value)
+	}
+
+	expected := []struct {
+		name   string
+		fields []string
+	}{
+		{"Active", nil},
+		{"Suspended", []string{"reason"}},
+		{"Deleted", nil},
+	}
+
+	if len(enum.Variants) != len(expected) {
+		t.Fatalf("enum.Variants does not contain %d variants. got=%d", len(expected), len(enum.Variants))
+	}
+
+	for i, tt := range expected {
+		variant := enum.Variants[i]
+		if variant.Name.Value != tt.name {
+			t.Errorf("variant %d name wrong. want=%s, got=%s", i, tt.name, variant.Name.Value)
+		}
+		if len(variant.Fields) != len(tt.fields) {
+			t.Errorf("variant %s has wrong number of fields. want=%d, got=%d", tt.name, len(tt.fields), len(variant.Fields))
+			continue
+		}
+		for j, f := range tt.fields {
+			if variant.Fields[j].Value != f {
+				t.Errorf("variant %s field %d wrong. want=%s, got=%s", tt.name, j, f, variant.Fields[j].Value)
+			}
+		}
+	}
+}
+
+func TestImportAliasAndFromStatements(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedPath  string
+		expectedName  string
+		expectedNames []string
+	}{
+		{`import "lib/util";`, "lib/util", "util", nil},
+		{`import "lib/util" as u;`, "lib/util", "u", nil},
+		{`from "lib/util" import add, sub;`, "lib/util", "util", []string{"add", "sub"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		programe := p.ParsePrograme()
+		checkParserErrors(t, p)
+
+		if len(programe.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(programe.Statements))
+		}
+
+		imp, ok := programe.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("stmt is not *ast.ImportStatement. got=%T", programe.Statements[0])
+		}
+
+		if imp.Path != tt.expectedPath {
+			t.Errorf("imp.Path wrong. want=%q, got=%q", tt.expectedPath, imp.Path)
+		}
+		if imp.Name() != tt.expectedName {
+			t.Errorf("imp.Name() wrong. want=%q, got=%q", tt.expectedName, imp.Name())
+		}
+		if len(imp.Names) != len(tt.expectedNames) {
+			t.Fatalf("imp.Names wrong length. want=%d, got=%d", len(tt.expectedNames), len(imp.Names))
+		}
+		for i, name := range tt.expectedNames {
+			if imp.Names[i].Value != name {
+				t.Errorf("imp.Names[%d] wrong. want=%q, got=%q", i, name, imp.Names[i].Value)
+			}
+		}
+	}
+}
+
+func TestExportStatement(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType string
+	}{
+		{`export let add = fn(a, b) { a + b; };`, "*ast.LetStatement"},
+		{`export const LIMIT = 10;`, "*ast.ConstStatement"},
+		{`export struct Point { x: 0 };`, "*ast.StructStatement"},
+		{`export enum Color { Red, Green }`, "*ast.EnumStatement"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		programe := p.ParsePrograme()
+		checkParserErrors(t, p)
+
+		if len(programe.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(programe.Statements))
+		}
+
+		stmt, ok := programe.Statements[0].(*ast.ExportStatement)
+		if !ok {
+			t.Fatalf("stmt is not *ast.ExportStatement. got=%T", programe.Statements[0])
+		}
+
+		if got := fmt.Sprintf("%T", stmt.Statement); got != tt.expectedType {
+			t.Errorf("exported statement has wrong type. want=%s, got=%s", tt.expectedType, got)
+		}
+	}
+
+	p := New(lexer.New(`export 5;`))
+	p.ParsePrograme()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected an error for exporting an expression")
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	input := `let total = add(1, (2 + 3)) * 4;
+if (total > 10) {
+  print("big");
+} else {
+  print("small");
+};
+let p = Point { x: 1 };`
+
+	p := New(lexer.New(input))
+	programe := p.ParsePrograme()
+	checkParserErrors(t, p)
+
+	source := func(n ast.Node) string {
+		return input[n.Pos().Offset:n.End().Offset]
+	}
+
+	let := programe.Statements[0].(*ast.LetStatement)
+	infix := let.Value.(*ast.InfixExpression)
+	call := infix.Left.(*ast.CallExpression)
+	ifStmt := programe.Statements[1].(*ast.ExpressionStatement)
+	ifExp := ifStmt.Expression.(*ast.IfExpression)
+	lit := programe.Statements[2].(*ast.LetStatement).Value.(*ast.StructLiteral)
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{let, "let total = add(1, (2 + 3)) * 4;"},
+		{let.Name, "total"},
+		{infix, "add(1, (2 + 3)) * 4"},
+		{call, "add(1, (2 + 3))"},
+		{call.Arguments[1], "2 + 3"},
+		{ifStmt, "if (total > 10) {\n  print(\"big\");\n} else {\n  print(\"small\");\n};"},
+		{ifExp.Consequence, "{\n  print(\"big\");\n}"},
+		{ifExp.Alternative, "{\n  print(\"small\");\n}"},
+		{lit, "Point { x: 1 }"},
+	}
+
+	for i, tt := range tests {
+		if got := source(tt.node); got != tt.expected {
+			t.Errorf("tests[%d] - wrong span. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+
+	if end := ifExp.End(); end.Line != 6 || end.Column != 2 {
+		t.Errorf("if expression ends at %d:%d, want 6:2", end.Line, end.Column)
+	}
+	if end := programe.End(); end.Offset != len(input) {
+		t.Errorf("program ends at offset %d, want %d", end.Offset, len(input))
+	}
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedHint    string
+		line, column    int
+	}{
+		{
+			"let x = 5\nlet y = 6;\nprint(x + y);",
+			"expected ';', found 'let'",
+			"statements end with ';' - add one at the end of the previous line",
+			2, 1,
+		},
+		{
+			"let add = fn(a b) { return a + b; };\nlet z = add(1, 2);",
+			"expected ')', found name \"b\"",
+			"add ')' to close the parenthesis",
+			1, 16,
+		},
+		{
+			"let f = fn() { let a = ; return 1; };\nlet g = 2;",
+			"expected an expression, found ';'",
+			"a value is missing before ';'",
+			1, 24,
+		},
+		{
+			"let fn = 3;\nlet ok = 1;",
+			"expected a name, found 'fn'",
+			"\"fn\" is a keyword and cannot be used as a name",
+			1, 5,
+		},
+		{
+			"let s = Point { x: 1, y: };\nprint(s);",
+			"expected an expression, found '}'",
+			"a value is missing before '}'",
+			1, 26,
+		},
+	}
+
+	for i, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParsePrograme()
+
+		errors := p.ParseErrors()
+		if len(errors) != 1 {
+			t.Errorf("tests[%d] - expected 1 error, got %d: %v", i, len(errors), p.Errors())
+			continue
+		}
+
+		err := errors[0]
+		if err.Message != tt.expectedMessage {
+			t.Errorf("tests[%d] - wrong message. expected=%q, got=%q", i, tt.expectedMessage, err.Message)
+		}
+		if err.Hint != tt.expectedHint {
+			t.Errorf("tests[%d] - wrong hint. expected=%q, got=%q", i, tt.expectedHint, err.Hint)
+		}
+		if err.Start.Line != tt.line || err.Start.Column != tt.column {
+			t.Errorf("tests[%d] - wrong position. expected=%d:%d, got=%d:%d",
+				i, tt.line, tt.column, err.Start.Line, err.Start.Column)
+		}
+	}
+}
+
+func TestParseErrorRecoveryKeepsLaterErrors(t *testing.T) {
+	input := `let a = ;
+let b = 2;
+let c = (1 + 2;
+let d = 4;`
+
+	p := New(lexer.New(input))
+	programe := p.ParsePrograme()
+
+	if len(p.Errors()) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(p.Errors()), p.Errors())
+	}
+
+	names := []string{}
+	for _, stmt := range programe.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok && let != nil && let.Value != nil {
+			names = append(names, let.Name.Value)
+		}
+	}
+	if strings.Join(names, ",") != "b,d" {
+		t.Errorf("expected the statements after each error to parse, got=%v", names)
+	}
+}
+
+func TestParseErrorSnippet(t *testing.T) {
+	input := "let x = 5\nlet y = 6;"
+
+	p := New(lexer.New(input))
+	p.ParsePrograme()
+	if len(p.ParseErrors()) != 1 {
+		t.Fatalf("expected 1 error, got %v", p.Errors())
+	}
+
+	expected := `error: expected ';', found 'let'
+ --> line 2, column 1
+  |
+2 | let y = 6;
+  | ^^^
+  = hint: statements end with ';' - add one at the end of the previous line
+`
+	if got := p.ParseErrors()[0].Snippet(input); got != expected {
+		t.Errorf("wrong snippet.\nexpected=\n%s\ngot=\n%s", expected, got)
+	}
+}
@@ -1,6 +1,7 @@
 package repl
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"github.com/walonCode/code-lang/internal/object"
 	"github.com/walonCode/code-lang/internal/parser"
 	"github.com/walonCode/code-lang/internal/std/general"
+	"github.com/walonCode/code-lang/internal/std/net"
 	"github.com/walonCode/code-lang/internal/symbol"
 )
 
@@ -31,6 +33,8 @@ func Start(out io.Writer) {
 	for name := range genMod.Members {
 		builder.Define(name, symbol.FUNCTION)
 	}
+	// Modules imported on one line are not loaded again by the next.
+	modules := map[string]*object.Module{}
 
 	home, _ := os.UserHomeDir()
 	historyPath := filepath.Join(home, ".code_lang_history")
@@ -65,7 +69,7 @@ func Start(out io.Writer) {
 
 		programe := p.ParsePrograme()
 		if len(p.Errors()) != 0 {
-			printParserError(out, p.Errors())
+			printParserErrors(out, line, p.ParseErrors())
 			continue
 		}
 
@@ -77,7 +81,7 @@ func Start(out io.Writer) {
 			continue
 		}
 
-		evaluator := &evaluator.Evaluator{Resolutions: builder.Resolutions}
+		evaluator := &evaluator.Evaluator{Resolutions: builder.Resolutions, Modules: modules}
 
 		evaluated := evaluator.Eval(programe, env)
 		if evaluated != nil {
@@ -88,39 +92,77 @@ func Start(out io.Writer) {
 	}
 }
 
-func printSymbolError(out io.Writer, errors []string) {
+func printSymbolError(out io.Writer, errors []symbol.Error) {
 	io.WriteString(out, " static analysis errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+	for _, err := range errors {
+		io.WriteString(out, "\t"+err.String()+"\n")
 	}
 }
 
-const CODE_LANG = `
-______          __                 __                         
-/ ____/  ____   / /  ___           / /   ____ _   ____    ____ _
-/ /      / __ \ / /  / _ \  ______ / /   / __ /  / __ \  / __ /
-/ /___   / /_/ // /  /  __/ /_____// /___/ /_/ /  / / / / / /_/ / 
-\____/   \____//_/   \___/        /_____/\__,_/  /_/ /_/  \__, /  
-                                                      /____/   
-
-`
-
-func printParserError(out io.Writer, errors []string) {
-	io.WriteString(out, CODE_LANG)
-	io.WriteString(out, "Whoops! We can in to some Code_lang business!\n")
-	io.WriteString(out, " parser errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+// printParserErrors shows each syntax error with the source line it is on
+// and a caret under the column.
+func printParserErrors(out io.Writer, source string, errors []*parser.ParseError) {
+	for _, err := range errors {
+		io.WriteString(out, err.Snippet(source))
 	}
 }
 
 func Execute(source string, out io.Writer) {
+	ExecuteFile("", source, out)
+}
+
+// ExecuteFile runs source as if it was read from path, so imports resolve
+// relative to the file's directory. What the program prints goes to out
+// along with its errors.
+func ExecuteFile(path, source string, out io.Writer) {
+	executeFile(context.Background(), path, source, nil, out)
+}
+
+// programExit is the panic with which os.exit ends a program run by
+// ExecuteFileContext.
+type programExit struct {
+	code int
+}
+
+// ExecuteFileContext is ExecuteFile for a program that stops once ctx is
+// done, closing the servers it listens with. Imports are also searched for
+// in the directories of searchPath, after CODELANG_PATH. os.exit ends the
+// program instead of the process, noting a non-zero status in out.
+func ExecuteFileContext(ctx context.Context, path, source string, searchPath []string, out io.Writer) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	defer func(exit func(int)) { general.Exit = exit }(general.Exit)
+	general.Exit = func(code int) {
+		stop()
+		panic(programExit{code: code})
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			exit, ok := r.(programExit)
+			if !ok {
+				panic(r)
+			}
+			if exit.code != 0 {
+				fmt.Fprintf(out, "exit status %d\n", exit.code)
+			}
+		}
+	}()
+
+	executeFile(ctx, path, source, searchPath, out)
+}
+
+func executeFile(ctx context.Context, path, source string, searchPath []string, out io.Writer) {
+	defer context.AfterFunc(ctx, net.Shutdown)()
+	defer func(stdout io.Writer) { general.Stdout = stdout }(general.Stdout)
+	general.Stdout = out
+
 	l := lexer.New(source)
 	p := parser.New(l)
 	program := p.ParsePrograme()
 
 	if len(p.Errors()) != 0 {
-		printParserError(out, p.Errors())
+		printParserErrors(out, source, p.ParseErrors())
 		return
 	}
 
@@ -131,6 +173,7 @@ func Execute(source string, out io.Writer) {
 	}
 
 	builder := symbol.NewBuilder()
+	builder.ModuleResolver = evaluator.ModuleResolver(path, searchPath...)
 	for name := range genMod.Members {
 		builder.Define(name, symbol.FUNCTION)
 	}
@@ -141,7 +184,7 @@ func Execute(source string, out io.Writer) {
 		return
 	}
 
-	evaluator := evaluator.Evaluator{Resolutions: builder.Resolutions}
+	evaluator := evaluator.Evaluator{Resolutions: builder.Resolutions, File: path, SearchPath: searchPath, Context: ctx}
 	evaluated := evaluator.Eval(program, env)
 	if evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
 		io.WriteString(out, evaluated.Inspect())
@@ -7,6 +7,13 @@ import (
 
 var arrayBuiltins = map[string]*object.Builtin{
 	"first": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "arr", Type: "array"},
+			},
+			Returns: "any",
+			Doc:     "Returns the first element of arr, or null when it is empty.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
@@ -25,6 +32,13 @@ var arrayBuiltins = map[string]*object.Builtin{
 		},
 	},
 	"last": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "arr", Type: "array"},
+			},
+			Returns: "any",
+			Doc:     "Returns the last element of arr, or null when it is empty.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
@@ -44,6 +58,13 @@ var arrayBuiltins = map[string]*object.Builtin{
 		},
 	},
 	"rest": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "arr", Type: "array"},
+			},
+			Returns: "array",
+			Doc:     "Returns a new array without the first element, or null when arr is empty.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
@@ -65,6 +86,14 @@ var arrayBuiltins = map[string]*object.Builtin{
 		},
 	},
 	"push": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "arr", Type: "array"},
+				{Name: "value", Type: "any"},
+			},
+			Returns: "array",
+			Doc:     "Returns a new array with value appended; arr is not changed.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=2", len(args))
@@ -18,6 +18,13 @@ func Module()*object.Module{
 
 func readFile()object.Object{
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "path", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Reads the whole file at path.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "fs.readfile() takes 1 argument")
@@ -42,6 +49,14 @@ func readFile()object.Object{
 
 func writeFile()object.Object{
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "path", Type: "string"},
+				{Name: "data", Type: "string"},
+			},
+			Returns: "bool",
+			Doc:     "Writes data to the file at path, replacing its contents.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args)!= 2 {
 				return object.NewError(node.Line(), node.Column(), "fs.writefile() takes 2 argument")
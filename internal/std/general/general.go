@@ -3,6 +3,7 @@ package general
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
@@ -13,6 +14,18 @@ import (
 	"github.com/walonCode/code-lang/internal/object"
 )
 
+// Stdout and Stdin are what print, printf, input and clear write to and
+// read from. Programs embedding the interpreter whose own standard streams
+// are taken, like the language server, point them elsewhere.
+var (
+	Stdout io.Writer = os.Stdout
+	Stdin  io.Reader = os.Stdin
+)
+
+// Exit ends the program with a status code when it calls os.exit. Programs
+// embedding the interpreter replace it so that only the script ends.
+var Exit = os.Exit
+
 func unwrapObject(obj object.Object) any {
 	switch o := obj.(type) {
 	case *object.Integer:
@@ -32,18 +45,35 @@ func unwrapObject(obj object.Object) any {
 
 var fmtBuiltins = map[string]*object.Builtin{
 	"print": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "values", Type: "any"},
+			},
+			Variadic: true,
+			Returns:  "null",
+			Doc:      "Prints its arguments separated by spaces, followed by a newline.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			for i, value := range args {
-				fmt.Print(value.Inspect())
+				fmt.Fprint(Stdout, value.Inspect())
 				if i < len(args)-1 {
-					fmt.Print(" ")
+					fmt.Fprint(Stdout, " ")
 				}
 			}
-			fmt.Println()
+			fmt.Fprintln(Stdout)
 			return nil
 		},
 	},
 	"printf": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "format", Type: "string"},
+				{Name: "values", Type: "any"},
+			},
+			Variadic: true,
+			Returns:  "null",
+			Doc:      "Prints values formatted with Go-style verbs such as %s and %d, followed by a newline.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) < 2 {
 				return nil
@@ -56,13 +86,20 @@ var fmtBuiltins = map[string]*object.Builtin{
 				for i, arg := range args[1:] {
 					goArgs[i] = unwrapObject(arg)
 				}
-				fmt.Printf(formatStr.Value, goArgs...)
-				fmt.Println()
+				fmt.Fprintf(Stdout, formatStr.Value, goArgs...)
+				fmt.Fprintln(Stdout)
 			}
 			return nil
 		},
 	},
 	"len": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "value", Type: "array | string"},
+			},
+			Returns: "int",
+			Doc:     "Returns the number of elements in an array or bytes in a string; strings.rune_len counts characters.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
@@ -79,14 +116,31 @@ var fmtBuiltins = map[string]*object.Builtin{
 		},
 	},
 	"typeof": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "value", Type: "any"},
+			},
+			Returns: "string",
+			Doc:     "Returns the type name of value, or the enum name for enum values.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
 			}
+			if enum, ok := args[0].(*object.EnumValue); ok {
+				return &object.String{Value: enum.EnumName}
+			}
 			return &object.String{Value: string(args[0].Type())}
 		},
 	},
 	"int": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "value", Type: "string"},
+			},
+			Returns: "int",
+			Doc:     "Parses a string as an integer.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
@@ -106,6 +160,13 @@ var fmtBuiltins = map[string]*object.Builtin{
 		},
 	},
 	"float": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "value", Type: "string | int"},
+			},
+			Returns: "float",
+			Doc:     "Converts an integer or parses a string as a float.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
@@ -128,6 +189,13 @@ var fmtBuiltins = map[string]*object.Builtin{
 		},
 	},
 	"input": {
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "prompt", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Prints prompt and reads one line from standard input.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "wrong number of arguments. got=%d, want=1", len(args))
@@ -138,8 +206,8 @@ var fmtBuiltins = map[string]*object.Builtin{
 				return object.NewError(node.Line(), node.Column(), "input must be a string")
 			}
 
-			fmt.Printf("%s: ", val.Value)
-			scanner := bufio.NewScanner(os.Stdin)
+			fmt.Fprintf(Stdout, "%s: ", val.Value)
+			scanner := bufio.NewScanner(Stdin)
 			scanner.Scan()
 			text := scanner.Text()
 
@@ -147,6 +215,10 @@ var fmtBuiltins = map[string]*object.Builtin{
 		},
 	},
 	"clear": {
+		Signature: &object.Signature{
+			Returns: "null",
+			Doc:     "Clears the terminal.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) == 1 {
 				return object.NewError(node.Line(), node.Column(), "function doesn't take any input")
@@ -159,7 +231,7 @@ var fmtBuiltins = map[string]*object.Builtin{
 			} else {
 				cmd = exec.Command("clear")
 			}
-			cmd.Stdout = os.Stdout
+			cmd.Stdout = Stdout
 			cmd.Run()
 
 			return nil
@@ -21,6 +21,13 @@ func Module() *object.Module {
 
 func keysFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "h", Type: "hash"},
+			},
+			Returns: "array",
+			Doc:     "Returns the keys of h.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "hash.keys() takes 1 argument")
@@ -41,6 +48,13 @@ func keysFunc() object.Object {
 
 func valuesFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "h", Type: "hash"},
+			},
+			Returns: "array",
+			Doc:     "Returns the values of h.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "hash.values() takes 1 argument")
@@ -61,6 +75,14 @@ func valuesFunc() object.Object {
 
 func containsKeyFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "h", Type: "hash"},
+				{Name: "key", Type: "any"},
+			},
+			Returns: "bool",
+			Doc:     "Reports whether h has key.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "hash.has_key() takes 2 arguments: hash and key")
@@ -83,6 +105,14 @@ func containsKeyFunc() object.Object {
 
 func mergeFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "a", Type: "hash"},
+				{Name: "b", Type: "hash"},
+			},
+			Returns: "hash",
+			Doc:     "Returns a new hash with the pairs of a and b; b wins on duplicate keys.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "hash.merge() takes 2 arguments")
@@ -104,6 +134,14 @@ func mergeFunc() object.Object {
 
 func deleteFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "h", Type: "hash"},
+				{Name: "key", Type: "any"},
+			},
+			Returns: "hash",
+			Doc:     "Removes key from h and returns h.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "hash.delete() takes 2 arguments: hash and key")
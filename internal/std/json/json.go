@@ -6,6 +6,7 @@ import (
 
 	"github.com/walonCode/code-lang/internal/ast"
 	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/std/general"
 )
 
 func JsonModule() *object.Module {
@@ -19,6 +20,13 @@ func JsonModule() *object.Module {
 
 func parse() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "text", Type: "string"},
+			},
+			Returns: "any",
+			Doc:     "Parses JSON text into hashes, arrays, strings, integers and booleans.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "json.parse expect 1 argument")
@@ -71,6 +79,13 @@ func toObject(val any) object.Object {
 
 func stringify() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "value", Type: "any"},
+			},
+			Returns: "string",
+			Doc:     "Encodes value as JSON. Hash keys must be strings.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "json.stringify expects 1 argument")
@@ -78,13 +93,13 @@ func stringify() object.Object {
 
 			data, err := toGoValue(args[0])
 			if err != nil {
-				fmt.Println("err2:", err)
+				fmt.Fprintln(general.Stdout, "err2:", err)
 				return object.NewError(node.Line(), node.Column(), "json.stringify: error")
 			}
 
 			bytes, err := json.Marshal(data)
 			if err != nil {
-				fmt.Println("err 3:", err)
+				fmt.Fprintln(general.Stdout, "err 3:", err)
 				return object.NewError(node.Line(), node.Column(), "json.stringify: error")
 			}
 
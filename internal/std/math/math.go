@@ -44,6 +44,13 @@ func extractFloat(obj object.Object) (float64, bool) {
 
 func sqrtFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the square root of x.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.sqrt() takes one argument")
@@ -59,6 +66,13 @@ func sqrtFunc() object.Object {
 
 func floorFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the greatest integer value less than or equal to x.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.floor() takes one argument")
@@ -74,6 +88,14 @@ func floorFunc() object.Object {
 
 func powFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "base", Type: "number"},
+				{Name: "exp", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns base raised to the power exp.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "math.pow() takes two arguments")
@@ -90,6 +112,13 @@ func powFunc() object.Object {
 
 func absFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the absolute value of x.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.abs() takes one argument")
@@ -105,6 +134,13 @@ func absFunc() object.Object {
 
 func sinFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the sine of x radians.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.sin() takes one argument")
@@ -120,6 +156,13 @@ func sinFunc() object.Object {
 
 func cosFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the cosine of x radians.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.cos() takes one argument")
@@ -135,6 +178,13 @@ func cosFunc() object.Object {
 
 func tanFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the tangent of x radians.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.tan() takes one argument")
@@ -150,6 +200,13 @@ func tanFunc() object.Object {
 
 func roundFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns x rounded to the nearest integer, rounding half away from zero.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.round() takes one argument")
@@ -165,6 +222,13 @@ func roundFunc() object.Object {
 
 func ceilFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the least integer value greater than or equal to x.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.ceil() takes one argument")
@@ -180,6 +244,13 @@ func ceilFunc() object.Object {
 
 func logFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the natural logarithm of x.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.log() takes one argument")
@@ -195,6 +266,13 @@ func logFunc() object.Object {
 
 func log10Func() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the base 10 logarithm of x.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.log10() takes one argument")
@@ -210,6 +288,13 @@ func log10Func() object.Object {
 
 func expFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns e raised to the power x.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.exp() takes one argument")
@@ -225,6 +310,13 @@ func expFunc() object.Object {
 
 func truncFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "x", Type: "number"},
+			},
+			Returns: "float",
+			Doc:     "Returns the integer value of x, dropping the fraction.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "math.trunc() takes one argument")
@@ -240,6 +332,14 @@ func truncFunc() object.Object {
 
 func minFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "values", Type: "number"},
+			},
+			Variadic: true,
+			Returns:  "float",
+			Doc:      "Returns the smallest of its arguments.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) < 1 {
 				return object.NewError(node.Line(), node.Column(), "math.min() takes at least one argument")
@@ -264,6 +364,14 @@ func minFunc() object.Object {
 
 func maxFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "values", Type: "number"},
+			},
+			Variadic: true,
+			Returns:  "float",
+			Doc:      "Returns the largest of its arguments.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) < 1 {
 				return object.NewError(node.Line(), node.Column(), "math.max() takes at least one argument")
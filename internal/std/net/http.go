@@ -51,6 +51,13 @@ func evalHttpResponse(node *ast.CallExpression, resp *http.Response, err error)
 
 func httpGet() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "url", Type: "string"},
+			},
+			Returns: "module",
+			Doc:     "Sends a GET request and returns a response with status, body and headers.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "http.get expects 1 argument (url)")
@@ -69,6 +76,15 @@ func httpGet() object.Object {
 
 func httpPost() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "url", Type: "string"},
+				{Name: "body", Type: "string"},
+				{Name: "contentType", Type: "string", Optional: true},
+			},
+			Returns: "module",
+			Doc:     "Sends a POST request with body, as application/json unless contentType is given.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) < 2 || len(args) > 3 {
 				return object.NewError(node.Line(), node.Column(), "http.post expects 2 or 3 arguments (url, body, [contentType])")
@@ -101,6 +117,15 @@ func httpPost() object.Object {
 
 func httpPatch() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "url", Type: "string"},
+				{Name: "body", Type: "string"},
+				{Name: "contentType", Type: "string", Optional: true},
+			},
+			Returns: "module",
+			Doc:     "Sends a PATCH request with body, as application/json unless contentType is given.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) < 2 || len(args) > 3 {
 				return object.NewError(node.Line(), node.Column(), "http.patch expects 2 or 3 arguments (url, body, [contentType])")
@@ -140,6 +165,13 @@ func httpPatch() object.Object {
 
 func httpDelete() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "url", Type: "string"},
+			},
+			Returns: "module",
+			Doc:     "Sends a DELETE request and returns a response with status, body and headers.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "http.delete expects 1 argument (url)")
@@ -3,17 +3,39 @@ package net
 import (
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/walonCode/code-lang/internal/ast"
 	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/std/general"
 )
 
+// listening are the servers started by listen that are still serving.
+var (
+	listeningMu sync.Mutex
+	listening   = map[*http.Server]bool{}
+)
+
+// Shutdown closes every server started by listen, so the listen calls
+// return and the programs serving them can finish.
+func Shutdown() {
+	listeningMu.Lock()
+	defer listeningMu.Unlock()
+	for srv := range listening {
+		srv.Close()
+	}
+}
+
 type ApplyFunctionFunc func(fn object.Object, args []object.Object, node *ast.CallExpression)object.Object
 
 func NetModule(applyFunc ApplyFunctionFunc) *object.Module {
 	members := map[string]object.Object{}
 
 	members["server"] = &object.Builtin{
+		Signature: &object.Signature{
+			Returns: "server",
+			Doc:     "Creates an HTTP server. Register routes with on and start it with listen.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			return NewServer(applyFunc)
 		},
@@ -32,6 +54,14 @@ func NewServer(applyFunc ApplyFunctionFunc) object.Object {
 	}
 
 	server.Members["listen"] = &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "port", Type: "int"},
+				{Name: "callback", Type: "fn", Optional: true},
+			},
+			Returns: "null",
+			Doc:     "Serves registered routes on port, calling callback once before it starts.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) == 0 || len(args) > 2 {
 				return object.NewError(node.Line(), node.Column(), "listen expect 1 arugment")
@@ -68,9 +98,17 @@ func NewServer(applyFunc ApplyFunctionFunc) object.Object {
 				}
 			}
 
-			err := http.ListenAndServe(fmt.Sprintf(":%d", port.Value), http.HandlerFunc(handler))
-			if err != nil {
-				fmt.Println("server error", err)
+			srv := &http.Server{Addr: fmt.Sprintf(":%d", port.Value), Handler: http.HandlerFunc(handler)}
+			listeningMu.Lock()
+			listening[srv] = true
+			listeningMu.Unlock()
+
+			err := srv.ListenAndServe()
+			listeningMu.Lock()
+			delete(listening, srv)
+			listeningMu.Unlock()
+			if err != nil && err != http.ErrServerClosed {
+				fmt.Fprintln(general.Stdout, "server error", err)
 			}
 
 			return nil
@@ -78,6 +116,15 @@ func NewServer(applyFunc ApplyFunctionFunc) object.Object {
 	}
 	
 	server.Members["on"] = &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "method", Type: "string"},
+				{Name: "path", Type: "string"},
+				{Name: "handler", Type: "fn"},
+			},
+			Returns: "null",
+			Doc:     "Registers handler for requests with method to path.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
    			if len(args) != 3 {
                 return object.NewError(node.Line(), node.Column(), "on expects 3 arguments: method, path, handler")
@@ -6,6 +6,7 @@ import (
 
 	"github.com/walonCode/code-lang/internal/ast"
 	"github.com/walonCode/code-lang/internal/object"
+	"github.com/walonCode/code-lang/internal/std/general"
 )
 
 func Module() *object.Module {
@@ -31,6 +32,13 @@ func Module() *object.Module {
 
 func getEnvFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "key", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Returns the value of the environment variable key, or an empty string.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "os.get_env() takes 1 argument")
@@ -46,6 +54,14 @@ func getEnvFunc() object.Object {
 
 func setEnvFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "key", Type: "string"},
+				{Name: "value", Type: "string"},
+			},
+			Returns: "null",
+			Doc:     "Sets the environment variable key to value.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "os.set_env() takes 2 arguments: key and value")
@@ -66,6 +82,10 @@ func setEnvFunc() object.Object {
 
 func getWdFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Returns: "string",
+			Doc:     "Returns the current working directory.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			wd, err := os.Getwd()
 			if err != nil {
@@ -78,6 +98,13 @@ func getWdFunc() object.Object {
 
 func exitFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "code", Type: "int", Optional: true},
+			},
+			Returns: "null",
+			Doc:     "Exits the program with code, 0 by default.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			code := 0
 			if len(args) == 1 {
@@ -85,7 +112,7 @@ func exitFunc() object.Object {
 					code = int(c.Value)
 				}
 			}
-			os.Exit(code)
+			general.Exit(code)
 			return nil
 		},
 	}
@@ -93,6 +120,10 @@ func exitFunc() object.Object {
 
 func hostnameFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Returns: "string",
+			Doc:     "Returns the host name of the machine.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			name, err := os.Hostname()
 			if err != nil {
@@ -2,6 +2,7 @@ package strings
 
 import (
 	"strings"
+	"unicode/utf8"
 
 	"github.com/walonCode/code-lang/internal/ast"
 	"github.com/walonCode/code-lang/internal/object"
@@ -25,12 +26,22 @@ func Module() *object.Module {
 			"count":       countFunc(),
 			"repeat":      repeatFunc(),
 			"reverse":     reverseFunc(),
+			"rune_len":    runeLenFunc(),
+			"rune_at":     runeAtFunc(),
+			"runes":       runesFunc(),
 		},
 	}
 }
 
 func toUpperFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Returns s with all letters in upper case.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "strings.to_upper() takes 1 argument")
@@ -46,6 +57,13 @@ func toUpperFunc() object.Object {
 
 func toLowerFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Returns s with all letters in lower case.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "strings.to_lower() takes 1 argument")
@@ -61,6 +79,14 @@ func toLowerFunc() object.Object {
 
 func splitFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "sep", Type: "string"},
+			},
+			Returns: "array",
+			Doc:     "Splits s around each occurrence of sep.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.split() takes 2 arguments: string and separator")
@@ -82,6 +108,14 @@ func splitFunc() object.Object {
 
 func joinFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "parts", Type: "array"},
+				{Name: "sep", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Joins the elements of parts with sep between them.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.join() takes 2 arguments: array and separator")
@@ -102,6 +136,14 @@ func joinFunc() object.Object {
 
 func containsFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "substr", Type: "string"},
+			},
+			Returns: "bool",
+			Doc:     "Reports whether substr is within s.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.contains() takes 2 arguments")
@@ -118,6 +160,15 @@ func containsFunc() object.Object {
 
 func replaceFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "old", Type: "string"},
+				{Name: "new", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Replaces every occurrence of old in s with new.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 3 {
 				return object.NewError(node.Line(), node.Column(), "strings.replace() takes 3 arguments: string, old, new")
@@ -135,6 +186,13 @@ func replaceFunc() object.Object {
 
 func trimFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Removes leading and trailing white space.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "strings.trim() takes 1 argument")
@@ -150,6 +208,13 @@ func trimFunc() object.Object {
 
 func trimLeftFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Removes leading white space.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "strings.trim_left() takes 1 argument")
@@ -165,6 +230,13 @@ func trimLeftFunc() object.Object {
 
 func trimRightFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Removes trailing white space.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "strings.trim_right() takes 1 argument")
@@ -180,6 +252,14 @@ func trimRightFunc() object.Object {
 
 func startsWithFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "prefix", Type: "string"},
+			},
+			Returns: "bool",
+			Doc:     "Reports whether s begins with prefix.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.starts_with() takes 2 arguments")
@@ -196,6 +276,14 @@ func startsWithFunc() object.Object {
 
 func endsWithFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "suffix", Type: "string"},
+			},
+			Returns: "bool",
+			Doc:     "Reports whether s ends with suffix.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.ends_with() takes 2 arguments")
@@ -212,6 +300,14 @@ func endsWithFunc() object.Object {
 
 func indexFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "substr", Type: "string"},
+			},
+			Returns: "int",
+			Doc:     "Returns the byte index of the first substr in s, or -1.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.index() takes 2 arguments")
@@ -228,6 +324,14 @@ func indexFunc() object.Object {
 
 func countFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "substr", Type: "string"},
+			},
+			Returns: "int",
+			Doc:     "Counts the non-overlapping occurrences of substr in s.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.count() takes 2 arguments")
@@ -244,6 +348,14 @@ func countFunc() object.Object {
 
 func repeatFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "count", Type: "int"},
+			},
+			Returns: "string",
+			Doc:     "Returns s repeated count times.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "strings.repeat() takes 2 arguments: string and count")
@@ -260,6 +372,13 @@ func repeatFunc() object.Object {
 
 func reverseFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Returns s with its characters in reverse order.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "strings.reverse() takes 1 argument")
@@ -276,3 +395,85 @@ func reverseFunc() object.Object {
 		},
 	}
 }
+
+func runeLenFunc() object.Object {
+	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "int",
+			Doc:     "Returns the number of characters (Unicode code points) in s, where len counts bytes.",
+		},
+		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewError(node.Line(), node.Column(), "strings.rune_len() takes 1 argument")
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.NewError(node.Line(), node.Column(), "argument must be a string")
+			}
+			return &object.Integer{Value: int64(utf8.RuneCountInString(s.Value))}
+		},
+	}
+}
+
+func runeAtFunc() object.Object {
+	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+				{Name: "i", Type: "int"},
+			},
+			Returns: "string",
+			Doc:     "Returns the character at index i of s counting characters, or null when i is out of range. s[i] counts bytes instead: it is the character starting at byte i, or \"\" inside one.",
+		},
+		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.NewError(node.Line(), node.Column(), "strings.rune_at() takes 2 arguments: string and index")
+			}
+			s, ok1 := args[0].(*object.String)
+			i, ok2 := args[1].(*object.Integer)
+			if !ok1 || !ok2 {
+				return object.NewError(node.Line(), node.Column(), "first argument must be a string, second must be an integer")
+			}
+			if i.Value < 0 {
+				return object.NULL
+			}
+			n := int64(0)
+			for _, r := range s.Value {
+				if n == i.Value {
+					return &object.String{Value: string(r)}
+				}
+				n++
+			}
+			return object.NULL
+		},
+	}
+}
+
+func runesFunc() object.Object {
+	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "s", Type: "string"},
+			},
+			Returns: "array",
+			Doc:     "Returns the characters of s as an array of one-character strings.",
+		},
+		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewError(node.Line(), node.Column(), "strings.runes() takes 1 argument")
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.NewError(node.Line(), node.Column(), "argument must be a string")
+			}
+			elements := []object.Object{}
+			for _, r := range s.Value {
+				elements = append(elements, &object.String{Value: string(r)})
+			}
+			return &object.Array{Elements: elements}
+		},
+	}
+}
@@ -29,6 +29,10 @@ func Module() *object.Module {
 
 func nowFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Returns: "time",
+			Doc:     "Returns the current local time.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			return &object.Time{Value: time.Now()}
 		},
@@ -37,6 +41,13 @@ func nowFunc() object.Object {
 
 func sleepFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "ms", Type: "int"},
+			},
+			Returns: "null",
+			Doc:     "Pauses for ms milliseconds.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.sleep() takes 1 argument (ms)")
@@ -53,6 +64,10 @@ func sleepFunc() object.Object {
 
 func unixFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Returns: "int",
+			Doc:     "Returns the current time as seconds since the Unix epoch.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			return &object.Integer{Value: time.Now().Unix()}
 		},
@@ -61,6 +76,14 @@ func unixFunc() object.Object {
 
 func formatFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+				{Name: "layout", Type: "string"},
+			},
+			Returns: "string",
+			Doc:     "Formats t with a Go layout such as time.RFC3339 or time.Kitchen.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return object.NewError(node.Line(), node.Column(), "time.format() takes 2 arguments: time and layout")
@@ -77,6 +100,13 @@ func formatFunc() object.Object {
 
 func sinceFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+			},
+			Returns: "int",
+			Doc:     "Returns the milliseconds elapsed since t.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.since() takes 1 argument (Time)")
@@ -93,6 +123,13 @@ func sinceFunc() object.Object {
 
 func yearFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+			},
+			Returns: "int",
+			Doc:     "Returns the year of t.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.year() takes 1 argument (Time)")
@@ -108,6 +145,13 @@ func yearFunc() object.Object {
 
 func monthFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+			},
+			Returns: "int",
+			Doc:     "Returns the month of t, from 1 to 12.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.month() takes 1 argument (Time)")
@@ -123,6 +167,13 @@ func monthFunc() object.Object {
 
 func dayFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+			},
+			Returns: "int",
+			Doc:     "Returns the day of the month of t.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.day() takes 1 argument (Time)")
@@ -138,6 +189,13 @@ func dayFunc() object.Object {
 
 func hourFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+			},
+			Returns: "int",
+			Doc:     "Returns the hour of t, from 0 to 23.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.hour() takes 1 argument (Time)")
@@ -153,6 +211,13 @@ func hourFunc() object.Object {
 
 func minuteFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+			},
+			Returns: "int",
+			Doc:     "Returns the minute of t.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.minute() takes 1 argument (Time)")
@@ -168,6 +233,13 @@ func minuteFunc() object.Object {
 
 func secondFunc() object.Object {
 	return &object.Builtin{
+		Signature: &object.Signature{
+			Params: []object.Param{
+				{Name: "t", Type: "time"},
+			},
+			Returns: "int",
+			Doc:     "Returns the second of t.",
+		},
 		Fn: func(node *ast.CallExpression, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return object.NewError(node.Line(), node.Column(), "time.second() takes 1 argument (Time)")
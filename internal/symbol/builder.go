@@ -4,17 +4,49 @@ import (
 	"fmt"
 
 	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/token"
 )
 
 type Builder struct {
-	Global      *Scope
-	Current     *Scope
-	Errors      []string
+	Global  *Scope
+	Current *Scope
+	Errors  []Error
+	// Warnings are problems in code that still runs, such as unused
+	// bindings, shadowed names and unreachable statements.
+	Warnings    []Warning
 	Resolutions map[ast.Node]int
+	// ModuleResolver returns the exports of a user module imported by
+	// path, or nil when the module is unknown. When set, member accesses
+	// on imported modules are checked against those exports.
+	ModuleResolver func(path string) map[string]SymbolKind
+	// Script marks a program that nothing imports, so that its unused
+	// top-level lets are reported unless they are marked with export.
+	Script bool
+
+	// loops counts the loops around the statement being visited, within
+	// the current function.
+	loops int
+	// imports are the names the program's imports define, checked for
+	// use once the whole program is visited.
+	imports []*Symbol
+}
+
+// Error is a problem that keeps the program from running, such as an
+// undefined name, with the range of the node it is about.
+type Error struct {
+	Start   token.Position
+	End     token.Position
+	Message string
 }
 
-func (b *Builder) error(line, col int, format string, args ...any) {
-	b.Errors = append(b.Errors, fmt.Sprintf("[Line %d, Column %d] %s", line, col, fmt.Sprintf(format, args...)))
+// String renders the error in the "[Line l, Column c] message" form the
+// evaluator's errors use.
+func (e Error) String() string {
+	return fmt.Sprintf("[Line %d, Column %d] %s", e.Start.Line, e.Start.Column, e.Message)
+}
+
+func (b *Builder) error(node ast.Node, format string, args ...any) {
+	b.Errors = append(b.Errors, Error{Start: node.Pos(), End: node.End(), Message: fmt.Sprintf(format, args...)})
 }
 
 func NewBuilder() *Builder {
@@ -35,6 +67,9 @@ func (b *Builder) Visit(node ast.Node) {
 		for _, stmt := range n.Statements {
 			b.Visit(stmt)
 		}
+		b.checkUnreachable(n.Statements)
+		b.reportUnusedImports()
+		b.reportUnusedGlobals(n)
 	case ast.Statement:
 		b.VisitStatement(n)
 	case ast.Expression:
@@ -52,21 +87,15 @@ func (b *Builder) VisitStatement(stmt ast.Statement) {
 			return
 		}
 		if fn, ok := s.Value.(*ast.FunctionLiteral); ok {
-			sym := b.Define(s.Name.Value, FUNCTION)
-			b.EnterScope("fn")
-			for _, param := range fn.Parameters {
-				b.Define(param.Value, PARAMETER)
-			}
-			b.VisitStatement(&fn.Body)
-			sym.NestedScope = b.Current
-			b.ExitScope()
+			sym := b.declare(s.Name, FUNCTION)
+			sym.NestedScope = b.function(fn)
 		} else {
 			if existing := b.Current.Symbols[s.Name.Value]; existing != nil {
 				if existing.Kind == CONSTANT {
-					b.error(s.Name.Line(), s.Name.Column(), "cannot re-declare constant: %s", s.Name.Value)
+					b.error(s.Name, "cannot re-declare constant: %s", s.Name.Value)
 				}
 			}
-			b.Define(s.Name.Value, VARIABLE)
+			b.declare(s.Name, VARIABLE)
 			if s.Value != nil {
 				b.VisitExpression(s.Value)
 			}
@@ -76,19 +105,13 @@ func (b *Builder) VisitStatement(stmt ast.Statement) {
 			return
 		}
 		if existing := b.Current.Symbols[s.Name.Value]; existing != nil {
-			b.error(s.Name.Line(), s.Name.Column(), "identifier already defined: %s", s.Name.Value)
+			b.error(s.Name, "identifier already defined: %s", s.Name.Value)
 		}
 		if fn, ok := s.Value.(*ast.FunctionLiteral); ok {
-			sym := b.Define(s.Name.Value, CONSTANT)
-			b.EnterScope("fn")
-			for _, param := range fn.Parameters {
-				b.Define(param.Value, PARAMETER)
-			}
-			b.VisitStatement(&fn.Body)
-			sym.NestedScope = b.Current
-			b.ExitScope()
+			sym := b.declare(s.Name, CONSTANT)
+			sym.NestedScope = b.function(fn)
 		} else {
-			b.Define(s.Name.Value, CONSTANT)
+			b.declare(s.Name, CONSTANT)
 			if s.Value != nil {
 				b.VisitExpression(s.Value)
 			}
@@ -115,25 +138,76 @@ func (b *Builder) VisitStatement(stmt ast.Statement) {
 		for _, stmt := range s.Statements {
 			b.VisitStatement(stmt)
 		}
+		b.checkUnreachable(s.Statements)
 		b.ExitScope()
 	case *ast.StructStatement:
 		if s == nil {
 			return
 		}
-		sym := b.Define(s.Name.Value, STRUCT)
+		sym := b.declare(s.Name, STRUCT)
 		b.EnterScope(s.Name.Value)
 		for name := range s.Fields {
 			b.Define(name, STRUCT_FIELD)
 		}
 		sym.NestedScope = b.Current
 		b.ExitScope()
+	case *ast.EnumStatement:
+		if s == nil {
+			return
+		}
+		sym := b.declare(s.Name, ENUM)
+		b.EnterScope(s.Name.Value)
+		for _, variant := range s.Variants {
+			b.Define(variant.Name.Value, ENUM_VARIANT)
+		}
+		sym.NestedScope = b.Current
+		b.ExitScope()
 	case *ast.ImportStatement:
 		if s == nil {
 			return
 		}
-		b.Define(s.Path, MODULE)
-	case *ast.BreakStatement, *ast.ContinueStatement:
-		// No symbols to define
+		var exports map[string]SymbolKind
+		if b.ModuleResolver != nil {
+			exports = b.ModuleResolver(s.Path)
+		}
+		if len(s.Names) > 0 {
+			for _, name := range s.Names {
+				kind := VARIABLE
+				if exports != nil {
+					k, ok := exports[name.Value]
+					if !ok {
+						b.error(name, "module %q does not export %s", s.Path, name.Value)
+					}
+					kind = k
+				}
+				b.imports = append(b.imports, b.declare(name, kind))
+			}
+			return
+		}
+		sym := b.Define(s.Name(), MODULE)
+		sym.Decl = s
+		b.imports = append(b.imports, sym)
+		if exports != nil {
+			b.EnterScope(s.Name())
+			for name, kind := range exports {
+				b.Define(name, kind)
+			}
+			sym.NestedScope = b.Current
+			b.ExitScope()
+		}
+	case *ast.ExportStatement:
+		if s == nil {
+			return
+		}
+		b.VisitStatement(s.Statement)
+	case *ast.BreakStatement:
+		if s != nil && b.loops == 0 {
+			b.warn(s, LoopControl, "break is not inside a loop")
+		}
+	case *ast.ContinueStatement:
+		if s != nil && b.loops == 0 {
+			b.warn(s, LoopControl, "continue is not inside a loop")
+		}
 	}
 }
 
@@ -146,9 +220,10 @@ func (b *Builder) VisitExpression(expr ast.Expression) {
 		if e == nil {
 			return
 		}
-		if _, distance := b.Current.ResolveWithDistance(e.Value); distance == -1 {
-			b.error(e.Line(), e.Column(), "undefined identifier: %s", e.Value)
+		if sym, distance := b.Current.ResolveWithDistance(e.Value); distance == -1 {
+			b.error(e, "undefined identifier: %s", e.Value)
 		} else {
+			sym.Used = true
 			b.Resolutions[e] = distance
 		}
 	case *ast.IntegerLiteral, *ast.Boolean, *ast.StringLiteral, *ast.FloatLiteral, *ast.CharLiteral:
@@ -163,14 +238,20 @@ func (b *Builder) VisitExpression(expr ast.Expression) {
 			return
 		}
 
-		if isAssignmentOp(e.Operator) {
+		if IsAssignmentOp(e.Operator) {
 			if ident, ok := e.Left.(*ast.Identifier); ok {
 				if sym := b.Resolve(ident.Value); sym != nil && sym.Kind == CONSTANT {
-					b.error(ident.Line(), ident.Column(), "cannot reassign to const: %s", ident.Value)
+					b.error(ident, "cannot reassign to const: %s", ident.Value)
 				}
 			}
 		}
 
+		if ident, ok := e.Left.(*ast.Identifier); ok && e.Operator == "=" {
+			b.VisitExpression(e.Right)
+			b.assign(ident)
+			return
+		}
+
 		b.VisitExpression(e.Left)
 		b.VisitExpression(e.Right)
 	case *ast.IfExpression:
@@ -190,12 +271,7 @@ func (b *Builder) VisitExpression(expr ast.Expression) {
 		if e == nil {
 			return
 		}
-		b.EnterScope("fn")
-		for _, param := range e.Parameters {
-			b.Define(param.Value, PARAMETER)
-		}
-		b.VisitStatement(&e.Body)
-		b.ExitScope()
+		b.function(e)
 	case *ast.CallExpression:
 		if e == nil {
 			return
@@ -209,6 +285,18 @@ func (b *Builder) VisitExpression(expr ast.Expression) {
 			return
 		}
 		b.VisitExpression(e.Object)
+		if ident, ok := e.Object.(*ast.Identifier); ok && e.Property != nil {
+			if sym := b.Resolve(ident.Value); sym != nil && sym.NestedScope != nil {
+				if _, ok := sym.NestedScope.Symbols[e.Property.Value]; !ok {
+					switch sym.Kind {
+					case ENUM:
+						b.error(e.Property, "enum %s has no variant %s", ident.Value, e.Property.Value)
+					case MODULE:
+						b.error(e.Property, "module %s has no exported member %s", ident.Value, e.Property.Value)
+					}
+				}
+			}
+		}
 	case *ast.ForExpression:
 		if e == nil {
 			return
@@ -223,7 +311,9 @@ func (b *Builder) VisitExpression(expr ast.Expression) {
 		if e.Post != nil {
 			b.VisitStatement(e.Post)
 		}
+		b.loops++
 		b.VisitStatement(e.Body)
+		b.loops--
 		b.ExitScope()
 	case *ast.WhileExpression:
 		if e == nil {
@@ -231,7 +321,9 @@ func (b *Builder) VisitExpression(expr ast.Expression) {
 		}
 		b.EnterScope("while")
 		b.VisitExpression(e.Condition)
+		b.loops++
 		b.VisitStatement(e.Body)
+		b.loops--
 		b.ExitScope()
 	case *ast.ArrayLiteral:
 		if e == nil {
@@ -258,6 +350,11 @@ func (b *Builder) VisitExpression(expr ast.Expression) {
 		if e == nil {
 			return
 		}
+		if e.Name != nil {
+			if sym := b.Resolve(e.Name.Value); sym != nil {
+				sym.Used = true
+			}
+		}
 		for _, v := range e.Fields {
 			b.VisitExpression(v)
 		}
@@ -269,12 +366,45 @@ func (b *Builder) EnterScope(name string) {
 	b.Current = newScope
 }
 
+// ExitScope leaves the current scope, warning about the bindings in it
+// that were never used.
 func (b *Builder) ExitScope() {
 	if b.Current.Parent != nil {
+		b.reportUnused(b.Current)
 		b.Current = b.Current.Parent
 	}
 }
 
+// function visits a function literal in a scope of its own, which it
+// returns. Loops around the literal do not surround its body.
+func (b *Builder) function(fn *ast.FunctionLiteral) *Scope {
+	loops := b.loops
+	b.loops = 0
+
+	b.EnterScope("fn")
+	for _, param := range fn.Parameters {
+		b.declare(param, PARAMETER)
+	}
+	b.VisitStatement(&fn.Body)
+	scope := b.Current
+	b.ExitScope()
+
+	b.loops = loops
+	return scope
+}
+
+// assign resolves the target of a plain assignment, which does not read
+// it. A name that was never declared is defined in the current scope, the
+// way the evaluator does at run time.
+func (b *Builder) assign(ident *ast.Identifier) {
+	if _, distance := b.Current.ResolveWithDistance(ident.Value); distance != -1 {
+		b.Resolutions[ident] = distance
+		return
+	}
+	b.warn(ident, UndeclaredAssignment, "assignment to undeclared name %s; declare it with let", ident.Value)
+	b.Define(ident.Value, VARIABLE)
+}
+
 func (b *Builder) Define(name string, kind SymbolKind) *Symbol {
 	sym := &Symbol{Name: name, Kind: kind}
 	b.Current.Define(sym)
@@ -285,7 +415,8 @@ func (b *Builder) Resolve(name string) *Symbol {
 	return b.Current.Resolve(name)
 }
 
-func isAssignmentOp(op string) bool {
+// IsAssignmentOp reports whether op assigns to its left operand.
+func IsAssignmentOp(op string) bool {
 	switch op {
 	case "=", "+=", "-=", "*=", "/=", "%=", "**=", "//=":
 		return true
@@ -0,0 +1,88 @@
+package symbol
+
+import (
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/ast"
+)
+
+// IsPrivate reports whether a top-level name is hidden from importers by
+// the leading-underscore convention.
+func IsPrivate(name string) bool {
+	return strings.HasPrefix(name, "_")
+}
+
+// ModuleExports returns the names a .cl module exposes to its importers
+// together with their kinds.
+//
+// When the module uses `export` at least once, only exported declarations
+// are public. Otherwise every top-level let, const, struct and enum is
+// public unless its name starts with an underscore. Imported modules and
+// names pulled in with `from ... import` are never re-exported.
+func ModuleExports(program *ast.Program) map[string]SymbolKind {
+	exports := map[string]SymbolKind{}
+	if program == nil {
+		return exports
+	}
+
+	explicit := false
+	for _, stmt := range program.Statements {
+		if _, ok := stmt.(*ast.ExportStatement); ok {
+			explicit = true
+			break
+		}
+	}
+
+	for _, stmt := range program.Statements {
+		exported := false
+		if es, ok := stmt.(*ast.ExportStatement); ok {
+			stmt = es.Statement
+			exported = true
+		}
+
+		name, kind, ok := declaration(stmt)
+		if !ok {
+			continue
+		}
+
+		if explicit && !exported {
+			continue
+		}
+		if !explicit && IsPrivate(name) {
+			continue
+		}
+
+		exports[name] = kind
+	}
+
+	return exports
+}
+
+func declaration(stmt ast.Statement) (string, SymbolKind, bool) {
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		if s == nil || s.Name == nil {
+			return "", VARIABLE, false
+		}
+		if _, ok := s.Value.(*ast.FunctionLiteral); ok {
+			return s.Name.Value, FUNCTION, true
+		}
+		return s.Name.Value, VARIABLE, true
+	case *ast.ConstStatement:
+		if s == nil || s.Name == nil {
+			return "", CONSTANT, false
+		}
+		return s.Name.Value, CONSTANT, true
+	case *ast.StructStatement:
+		if s == nil || s.Name == nil {
+			return "", STRUCT, false
+		}
+		return s.Name.Value, STRUCT, true
+	case *ast.EnumStatement:
+		if s == nil || s.Name == nil {
+			return "", ENUM, false
+		}
+		return s.Name.Value, ENUM, true
+	}
+	return "", VARIABLE, false
+}
@@ -1,5 +1,7 @@
 package symbol
 
+import "github.com/walonCode/code-lang/internal/ast"
+
 type SymbolKind int
 
 const (
@@ -10,6 +12,8 @@ const (
 	STRUCT
 	CONSTANT
 	MODULE
+	ENUM
+	ENUM_VARIANT
 )
 
 func (k SymbolKind) String() string {
@@ -28,6 +32,10 @@ func (k SymbolKind) String() string {
 		return "constant"
 	case MODULE:
 		return "module"
+	case ENUM:
+		return "enum"
+	case ENUM_VARIANT:
+		return "enum_variant"
 	default:
 		return "unknown"
 	}
@@ -37,4 +45,10 @@ type Symbol struct {
 	Name        string
 	Kind        SymbolKind
 	NestedScope *Scope
+	// Decl is the node that declared the symbol: the identifier of a let,
+	// const or parameter, or the statement of an import. It is nil for
+	// predefined names such as builtins.
+	Decl ast.Node
+	// Used is set once the symbol is read.
+	Used bool
 }
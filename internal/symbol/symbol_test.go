@@ -1,6 +1,7 @@
 package symbol
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/walonCode/code-lang/internal/lexer"
@@ -124,3 +125,233 @@ let x = 10;
 		t.Errorf("x has kind %s, expected VARIABLE", symX.Kind)
 	}
 }
+
+func TestEnumVariants(t *testing.T) {
+	input := `
+enum Status { Active, Suspended(reason) };
+let a = Status.Active;
+let b = Status.Actve;
+`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParsePrograme()
+
+	builder := NewBuilder()
+	builder.Visit(program)
+
+	sym := builder.Global.Resolve("Status")
+	if sym == nil || sym.Kind != ENUM {
+		t.Fatalf("Status not defined as ENUM. got=%v", sym)
+	}
+	if v := sym.NestedScope.Resolve("Suspended"); v == nil || v.Kind != ENUM_VARIANT {
+		t.Errorf("Suspended not defined as ENUM_VARIANT. got=%v", v)
+	}
+
+	if len(builder.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(builder.Errors), builder.Errors)
+	}
+	err := builder.Errors[0]
+	if err.Message != "enum Status has no variant Actve" {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err.Start.Line != 4 || err.Start.Column != 16 || err.End.Column != 21 {
+		t.Errorf("error not at Actve: %+v to %+v", err.Start, err.End)
+	}
+	if err.String() != "[Line 4, Column 16] enum Status has no variant Actve" {
+		t.Errorf("wrong string form: %s", err)
+	}
+}
+
+func TestModuleExports(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]SymbolKind
+	}{
+		{
+			`import "math";
+let add = fn(a, b) { return a + b; };
+let _helper = fn() { return 1; };
+const LIMIT = 10;
+struct Point { x: 0 };`,
+			map[string]SymbolKind{"add": FUNCTION, "LIMIT": CONSTANT, "Point": STRUCT},
+		},
+		{
+			`export let add = fn(a, b) { return a + b; };
+let sub = fn(a, b) { return a - b; };
+export enum Color { Red }`,
+			map[string]SymbolKind{"add": FUNCTION, "Color": ENUM},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		program := p.ParsePrograme()
+
+		exports := ModuleExports(program)
+		if len(exports) != len(tt.expected) {
+			t.Errorf("wrong number of exports. want=%v, got=%v", tt.expected, exports)
+			continue
+		}
+		for name, kind := range tt.expected {
+			if got, ok := exports[name]; !ok || got != kind {
+				t.Errorf("export %s wrong. want=%s, got=%s (present=%t)", name, kind, got, ok)
+			}
+		}
+	}
+}
+
+func TestModuleMemberChecks(t *testing.T) {
+	input := `
+import "util";
+from "util" import add, _hidden;
+util.add(1, 2);
+util._hidden();
+`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParsePrograme()
+
+	builder := NewBuilder()
+	builder.ModuleResolver = func(path string) map[string]SymbolKind {
+		if path == "util" {
+			return map[string]SymbolKind{"add": FUNCTION}
+		}
+		return nil
+	}
+	builder.Visit(program)
+
+	expected := []string{
+		`module "util" does not export _hidden`,
+		"module util has no exported member _hidden",
+	}
+	if len(builder.Errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(builder.Errors), builder.Errors)
+	}
+	for i, msg := range expected {
+		if !strings.Contains(builder.Errors[i].Message, msg) {
+			t.Errorf("error %d wrong. want to contain %q, got %q", i, msg, builder.Errors[i])
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	input := `import "math";
+let x = 1;
+let f = fn(a, b, _c) {
+	let unused = 2;
+	let x = a;
+	return x;
+	x = 3;
+};
+y = 4;
+let g = fn() {
+	while (true) {
+		let h = fn() { continue; };
+		h();
+		break;
+	};
+	break;
+};
+print(f, g, y);
+`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParsePrograme()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has %d errors: %v", len(p.Errors()), p.Errors())
+	}
+
+	builder := NewBuilder()
+	builder.Define("print", FUNCTION)
+	builder.Visit(program)
+
+	if len(builder.Errors) != 0 {
+		t.Fatalf("expected no errors, got %v", builder.Errors)
+	}
+
+	expected := []struct {
+		code string
+		line int
+		msg  string
+	}{
+		{Unreachable, 7, "unreachable code"},
+		{UnusedParameter, 3, "unused parameter: b"},
+		{UnusedVariable, 4, "unused variable: unused"},
+		{Shadow, 5, "x shadows the declaration on line 2"},
+		{UndeclaredAssignment, 9, "assignment to undeclared name y"},
+		{LoopControl, 12, "continue is not inside a loop"},
+		{LoopControl, 16, "break is not inside a loop"},
+		{UnusedImport, 1, "unused import: math"},
+	}
+
+	got := builder.Warnings
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d warnings, got %d: %v", len(expected), len(got), got)
+	}
+	for _, exp := range expected {
+		found := false
+		for _, w := range got {
+			if w.Code == exp.code && w.Start.Line == exp.line && strings.Contains(w.Message, exp.msg) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing %s warning %q on line %d in %v", exp.code, exp.msg, exp.line, got)
+		}
+	}
+}
+
+func TestUnusedGlobals(t *testing.T) {
+	tests := []struct {
+		input    string
+		script   bool
+		expected []string
+	}{
+		{"let unused = 5; let used = 1; used;", false, nil},
+		{"let unused = 5; let used = 1; used;", true, []string{"unused variable: unused"}},
+		{"let _skip = 5; let f = fn() {}; let g = fn() { g(); };", true, []string{"unused function: f"}},
+		{"export let api = 1; let helper = fn() {};", false, []string{"unused function: helper"}},
+		{"export let api = 1; let kept = 2; export let total = kept;", true, nil},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParsePrograme()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser has %d errors: %v", len(p.Errors()), p.Errors())
+		}
+
+		builder := NewBuilder()
+		builder.Script = tt.script
+		builder.Visit(program)
+
+		var got []string
+		for _, w := range builder.Warnings {
+			if w.Code != UnusedVariable {
+				t.Errorf("%q: unexpected warning %v", tt.input, w)
+				continue
+			}
+			got = append(got, w.Message)
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("%q (script=%v): expected warnings %q, got %q", tt.input, tt.script, tt.expected, got)
+		}
+	}
+}
+
+func TestAssignmentToUndeclaredNameDefinesIt(t *testing.T) {
+	l := lexer.New("total = 1; total += 2;")
+	p := parser.New(l)
+	program := p.ParsePrograme()
+
+	builder := NewBuilder()
+	builder.Visit(program)
+
+	if len(builder.Errors) != 0 {
+		t.Errorf("expected later uses to resolve, got %v", builder.Errors)
+	}
+	if len(builder.Warnings) != 1 || builder.Warnings[0].Code != UndeclaredAssignment {
+		t.Errorf("expected one undeclared-assignment warning, got %v", builder.Warnings)
+	}
+}
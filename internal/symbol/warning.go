@@ -0,0 +1,160 @@
+package symbol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/walonCode/code-lang/internal/ast"
+	"github.com/walonCode/code-lang/internal/token"
+)
+
+// Warning codes name each kind of problem the builder reports as a
+// warning. Tools use them to filter or configure warnings.
+const (
+	UnusedVariable       = "unused-variable"
+	UnusedParameter      = "unused-parameter"
+	UnusedImport         = "unused-import"
+	Shadow               = "shadow"
+	Unreachable          = "unreachable"
+	LoopControl          = "loop-control"
+	UndeclaredAssignment = "undeclared-assignment"
+)
+
+// Warning is a problem in code that still runs, such as a binding that is
+// never used or a statement that can never be reached.
+type Warning struct {
+	Start   token.Position
+	End     token.Position
+	Code    string
+	Message string
+}
+
+// String renders the warning in the "[Line l, Column c] message" form
+// Error uses.
+func (w Warning) String() string {
+	return fmt.Sprintf("[Line %d, Column %d] %s", w.Start.Line, w.Start.Column, w.Message)
+}
+
+func (b *Builder) warn(node ast.Node, code, format string, args ...any) {
+	b.warnRange(node.Pos(), node.End(), code, format, args...)
+}
+
+func (b *Builder) warnRange(start, end token.Position, code, format string, args ...any) {
+	b.Warnings = append(b.Warnings, Warning{
+		Start:   start,
+		End:     end,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// declare defines the name ident introduces in the current scope, warning
+// when it hides a declaration of an outer scope.
+func (b *Builder) declare(ident *ast.Identifier, kind SymbolKind) *Symbol {
+	if b.Current != b.Global && b.Current.Parent != nil {
+		if outer := b.Current.Parent.Resolve(ident.Value); outer != nil && outer.Decl != nil {
+			b.warn(ident, Shadow, "%s shadows the declaration on line %d", ident.Value, outer.Decl.Line())
+		}
+	}
+	sym := &Symbol{Name: ident.Value, Kind: kind, Decl: ident}
+	b.Current.Define(sym)
+	return sym
+}
+
+// reportUnused warns about the variables, functions and parameters of
+// scope that were never read, in source order. Names starting with an
+// underscore are unused on purpose.
+func (b *Builder) reportUnused(scope *Scope) {
+	var unused []*Symbol
+	for _, sym := range scope.Symbols {
+		if sym.Used || sym.Decl == nil || strings.HasPrefix(sym.Name, "_") {
+			continue
+		}
+		switch sym.Kind {
+		case VARIABLE, FUNCTION, PARAMETER:
+			unused = append(unused, sym)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool { return unused[i].Decl.Pos().Offset < unused[j].Decl.Pos().Offset })
+
+	for _, sym := range unused {
+		switch sym.Kind {
+		case PARAMETER:
+			b.warn(sym.Decl, UnusedParameter, "unused parameter: %s", sym.Name)
+		case FUNCTION:
+			b.warn(sym.Decl, UnusedVariable, "unused function: %s", sym.Name)
+		default:
+			b.warn(sym.Decl, UnusedVariable, "unused variable: %s", sym.Name)
+		}
+	}
+}
+
+// reportUnusedImports warns about the modules and imported names that
+// were never read.
+func (b *Builder) reportUnusedImports() {
+	for _, sym := range b.imports {
+		if !sym.Used {
+			b.warn(sym.Decl, UnusedImport, "unused import: %s", sym.Name)
+		}
+	}
+	b.imports = nil
+}
+
+// reportUnusedGlobals warns about the top-level lets of program that were
+// never read and that no importer can see: those left out of an explicit
+// export list, and in a script every one not marked with export.
+func (b *Builder) reportUnusedGlobals(program *ast.Program) {
+	exports := ModuleExports(program)
+	for _, stmt := range program.Statements {
+		if _, ok := stmt.(*ast.ExportStatement); ok {
+			continue
+		}
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || let == nil || let.Name == nil {
+			continue
+		}
+		if _, exported := exports[let.Name.Value]; exported && !b.Script {
+			continue
+		}
+
+		sym := b.Global.Symbols[let.Name.Value]
+		if sym == nil || sym.Used || sym.Decl != let.Name || strings.HasPrefix(sym.Name, "_") {
+			continue
+		}
+		if sym.Kind == FUNCTION {
+			b.warn(sym.Decl, UnusedVariable, "unused function: %s", sym.Name)
+		} else {
+			b.warn(sym.Decl, UnusedVariable, "unused variable: %s", sym.Name)
+		}
+	}
+}
+
+// checkUnreachable warns once about the statements of a block that follow
+// a return, break or continue.
+func (b *Builder) checkUnreachable(stmts []ast.Statement) {
+	for i, stmt := range stmts {
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.BreakStatement, *ast.ContinueStatement:
+		default:
+			continue
+		}
+
+		rest := present(stmts[i+1:])
+		if len(rest) > 0 {
+			b.warnRange(rest[0].Pos(), rest[len(rest)-1].End(), Unreachable, "unreachable code")
+		}
+		return
+	}
+}
+
+// present drops the statements the parser gave up on.
+func present(stmts []ast.Statement) []ast.Statement {
+	var out []ast.Statement
+	for _, stmt := range stmts {
+		if !ast.IsNil(stmt) {
+			out = append(out, stmt)
+		}
+	}
+	return out
+}
@@ -2,11 +2,29 @@ package token
 
 type TokenType string
 
+// Position is a place in the source. Offset is a byte offset into the
+// input; Line and Column are 1-based, with Column counted in bytes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
 type Token struct {
 	Type    TokenType
 	Literal string
 	Line    int
 	Column  int
+	// Offset is the byte offset of the token's first byte and End is the
+	// position just past its last, quotes of string and char literals
+	// included.
+	Offset int
+	End    Position
+}
+
+// Pos returns the position of the token's first byte.
+func (t Token) Pos() Position {
+	return Position{Offset: t.Offset, Line: t.Line, Column: t.Column}
 }
 
 const (
@@ -72,11 +90,15 @@ const (
 	BREAK    = "BREAK"
 	STRUCT   = "STRUCT"
 	CONST    = "CONST"
+	ENUM     = "ENUM"
 
 	//accessor thing
 	DOT = "."
 
 	IMPORT = "IMPORT"
+	FROM   = "FROM"
+	AS     = "AS"
+	EXPORT = "EXPORT"
 
 	//comment
 	COMMENT             = "#"
@@ -98,8 +120,12 @@ var keywords = map[string]TokenType{
 	"break":    BREAK,
 	"continue": CONTINUE,
 	"import":   IMPORT,
+	"from":     FROM,
+	"as":       AS,
+	"export":   EXPORT,
 	"struct":   STRUCT,
 	"const":    CONST,
+	"enum":     ENUM,
 }
 
 func LookUpIdent(ident string) TokenType {
@@ -0,0 +1,164 @@
+// Package plugin lets native Go code be imported from code-lang scripts.
+//
+// A plugin is a separate executable that speaks JSON-RPC 2.0 over its
+// stdin/stdout, using the same Content-Length framing as the language
+// server. A package declares it in code-lang.json:
+//
+//	{"plugins": {"mylib": "./bin/mylib"}}
+//
+// and scripts use it like any std module: `import "mylib"; mylib.add(1, 2);`.
+//
+// The interpreter sends an "initialize" request, expects the list of
+// function names back, then sends a "call" request for each call and an
+// "exit" notification when it is done. Serve implements that side.
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/walonCode/code-lang/internal/jsonrpc"
+)
+
+const (
+	MethodInitialize = "initialize"
+	MethodCall       = "call"
+	MethodExit       = "exit"
+
+	// Error codes returned in Response.Error.
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeFunctionFailed = 1
+)
+
+// Func is a native function. Arguments and the result are plain JSON
+// values: nil, bool, string, json.Number (or any Go number), []any and
+// map[string]any. A returned error is reported to the script as a runtime
+// error.
+type Func func(args []any) (any, error)
+
+type Request struct {
+	RPC    string          `json:"jsonrpc"`
+	ID     *int            `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type Response struct {
+	RPC    string          `json:"jsonrpc"`
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+type InitializeParams struct {
+	Module string `json:"module"`
+}
+
+type InitializeResult struct {
+	Functions []string `json:"functions"`
+}
+
+type CallParams struct {
+	Function string `json:"function"`
+	Args     []any  `json:"args"`
+}
+
+// Serve answers interpreter requests on stdin/stdout until the exit
+// notification arrives or stdin is closed.
+func Serve(funcs map[string]Func) error {
+	return ServeConn(os.Stdin, os.Stdout, funcs)
+}
+
+// ServeConn is Serve over an arbitrary connection.
+func ServeConn(r io.Reader, w io.Writer, funcs map[string]Func) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	scanner.Split(jsonrpc.Spilt)
+
+	for scanner.Scan() {
+		method, content, err := jsonrpc.DecodeMessage(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+		if method == MethodExit {
+			return nil
+		}
+
+		var req Request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return err
+		}
+		if req.ID == nil {
+			continue
+		}
+
+		result, rpcErr := handle(req, funcs)
+		resp := Response{RPC: "2.0", ID: *req.ID, Error: rpcErr}
+		if rpcErr == nil {
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				resp.Error = &Error{Code: CodeFunctionFailed, Message: err.Error()}
+			} else {
+				resp.Result = encoded
+			}
+		}
+
+		reply, err := jsonrpc.EncodeMessage(resp)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, reply); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func handle(req Request, funcs map[string]Func) (any, *Error) {
+	switch req.Method {
+	case MethodInitialize:
+		names := make([]string, 0, len(funcs))
+		for name := range funcs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return InitializeResult{Functions: names}, nil
+	case MethodCall:
+		var params CallParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		fn, ok := funcs[params.Function]
+		if !ok {
+			return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown function %s", params.Function)}
+		}
+		result, err := fn(params.Args)
+		if err != nil {
+			return nil, &Error{Code: CodeFunctionFailed, Message: err.Error()}
+		}
+		return result, nil
+	}
+
+	return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown method %s", req.Method)}
+}
+
+// decodeParams keeps numbers as json.Number so integers survive the trip.
+func decodeParams(raw json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
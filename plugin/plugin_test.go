@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/walonCode/code-lang/internal/jsonrpc"
+)
+
+func TestServeConn(t *testing.T) {
+	var in bytes.Buffer
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"module":"mylib"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"call","params":{"function":"echo","args":[1,"a"]}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"call","params":{"function":"fail","args":[]}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"call","params":{"function":"nope","args":[]}}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"initialize"}`,
+	} {
+		encoded, err := jsonrpc.EncodeMessage(rawMessage(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.WriteString(encoded)
+	}
+
+	var out bytes.Buffer
+	err := ServeConn(&in, &out, map[string]Func{
+		"echo": func(args []any) (any, error) { return args, nil },
+		"fail": func(args []any) (any, error) { return nil, errors.New("boom") },
+	})
+	if err != nil {
+		t.Fatalf("ServeConn failed: %s", err)
+	}
+
+	expected := []string{
+		`{"jsonrpc":"2.0","id":1,"result":{"functions":["echo","fail"]}}`,
+		`{"jsonrpc":"2.0","id":2,"result":[1,"a"]}`,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":1,"message":"boom"}}`,
+		`{"jsonrpc":"2.0","id":4,"error":{"code":-32601,"message":"unknown function nope"}}`,
+	}
+
+	got := out.String()
+	for _, want := range expected {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing response %s in %s", want, got)
+		}
+	}
+	if strings.Contains(got, `"id":5`) {
+		t.Errorf("requests after exit should not be answered: %s", got)
+	}
+}
+
+type rawMessage string
+
+func (m rawMessage) MarshalJSON() ([]byte, error) { return []byte(m), nil }